@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"crypto/ed25519"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
 	"os"
 	"runtime"
 	"sort"
@@ -36,15 +41,26 @@ func init() {
 	rootCmd.AddCommand(rich)
 
 	get.AddCommand(getTX)
+	getRates.Flags().Uint32("from", 0, "The first height of a range")
+	getRates.Flags().Uint32("to", 0, "The last height of a range")
+	getRates.Flags().StringSlice("assets", nil, "Only show these assets for a range")
+	getRates.Flags().Uint32("bucket", 0, "Aggregate a range into buckets of this many blocks")
+	getRates.Flags().Bool("csv", false, "Print a range as csv")
 	get.AddCommand(getRates)
 	getBank.Flags().Bool("raw", false, "Print the full json data")
 	get.AddCommand(getBank)
+	get.AddCommand(getBlock)
+	get.AddCommand(getSubmission)
 	getTXs.Flags().Bool("burn", false, "Show burns")
 	getTXs.Flags().Bool("cvt", false, "Show converions")
 	getTXs.Flags().Bool("tran", false, "Show transfers")
 	getTXs.Flags().Bool("coin", false, "Show coinbases")
 	getTXs.Flags().String("asset", "", "Filter by specific asset")
 	getTXs.Flags().Int("offset", 0, "Specify an offset for pagination")
+	getTXs.Flags().Int("fromheight", 0, "Only show transactions at or above this height")
+	getTXs.Flags().Int("toheight", 0, "Only show transactions at or below this height")
+	getTXs.Flags().String("fromtime", "", "Only show transactions at or after this RFC3339 time")
+	getTXs.Flags().String("totime", "", "Only show transactions before this RFC3339 time")
 
 	get.AddCommand(getTXs)
 	rootCmd.AddCommand(get)
@@ -66,14 +82,12 @@ var minerDistro = &cobra.Command{
 	PreRun:           SoftReadConfig,
 	Args:             cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
-		cl := srv.NewClient()
-		cl.PegnetdServer = viper.GetString(config.Pegnetd)
+		cl := pegnetdClient()
 
 		var params srv.ParamsGetMiningDominance
 		n, err := strconv.Atoi(args[0])
 		if err != nil {
-			fmt.Println("Arguments must be valid integers")
-			os.Exit(1)
+			exitError(cmd, usageError{fmt.Errorf("arguments must be valid integers")})
 		}
 		if len(args) == 1 {
 			if n <= 0 {
@@ -84,58 +98,68 @@ var minerDistro = &cobra.Command{
 		} else {
 			n2, err := strconv.Atoi(args[1])
 			if err != nil {
-				fmt.Println("Arguments must be valid integers")
-				os.Exit(1)
+				exitError(cmd, usageError{fmt.Errorf("arguments must be valid integers")})
 			}
 			params.Start = n
 			params.Stop = n2
 		}
 
-		var res pegnet.MinerDominanceResult
-		err = cl.Request("get-miner-distribution", params, &res)
+		res, err := cl.GetMinerDistribution(context.Background(), params)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			exitError(cmd, err)
 		}
 
 		// Dump all the data
-		if long, _ := cmd.Flags().GetBool("long"); long {
+		if raw, _ := cmd.Flags().GetBool("raw"); raw && !structuredOutput(cmd) {
 			d, _ := json.Marshal(res)
 			fmt.Println(string(d))
-			os.Exit(0)
+			return
 		}
 
-		// Print the shortened data
-		fmt.Printf("Miner distribution for block range %d -> %d (%d blocks)\n", res.Start, res.Stop, res.Stop-res.Start)
-		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		_, _ = fmt.Fprintf(tw, "Address\t1st ID\t# IDs\t Win %%\t Graded %%\n")
-		_, _ = fmt.Fprintf(tw, "-------\t------\t-----\t -----\t --------\n")
-
-		// To slice so we can print in sorted order
-		slice := make([]struct {
-			Address string
-			Miner   pegnet.MinerDominance
-		}, len(res.Miners))
-		var i int
-		for add, miner := range res.Miners {
-			slice[i].Address = add
-			slice[i].Miner = miner
-			i++
-		}
-
-		sort.Slice(slice, func(i, j int) bool {
-			return slice[i].Miner.WinPercentage > slice[j].Miner.WinPercentage
+		printOutput(cmd, res, func() { printMinerDistribution(res) }, func() [][]string {
+			rows := [][]string{{"address", "identities", "totalwins", "totalgraded", "winpercent", "gradedpercent"}}
+			for add, miner := range res.Miners {
+				rows = append(rows, []string{add, strings.Join(miner.Identities, " "),
+					strconv.Itoa(int(miner.TotalWins)), strconv.Itoa(int(miner.TotalGraded)),
+					strconv.FormatFloat(miner.WinPercentage, 'f', -1, 64), strconv.FormatFloat(miner.GradedPercentage, 'f', -1, 64)})
+			}
+			sort.Slice(rows[1:], func(i, j int) bool { return rows[i+1][0] < rows[j+1][0] })
+			return rows
 		})
-
-		for i := range slice {
-			add := slice[i].Address
-			miner := slice[i].Miner
-			_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%6.3f%%\t%6.3f%%\n", add, miner.Identities[0], len(miner.Identities), miner.WinPercentage*100, miner.GradedPercentage*100)
-		}
-		tw.Flush()
 	},
 }
 
+func printMinerDistribution(res pegnet.MinerDominanceResult) {
+	// Print the shortened data
+	fmt.Printf("Miner distribution for block range %d -> %d (%d blocks)\n", res.Start, res.Stop, res.Stop-res.Start)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Address\t1st ID\t# IDs\t Win %%\t Graded %%\n")
+	_, _ = fmt.Fprintf(tw, "-------\t------\t-----\t -----\t --------\n")
+
+	// To slice so we can print in sorted order
+	slice := make([]struct {
+		Address string
+		Miner   pegnet.MinerDominance
+	}, len(res.Miners))
+	var i int
+	for add, miner := range res.Miners {
+		slice[i].Address = add
+		slice[i].Miner = miner
+		i++
+	}
+
+	sort.Slice(slice, func(i, j int) bool {
+		return slice[i].Miner.WinPercentage > slice[j].Miner.WinPercentage
+	})
+
+	for i := range slice {
+		add := slice[i].Address
+		miner := slice[i].Miner
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%6.3f%%\t%6.3f%%\n", add, miner.Identities[0], len(miner.Identities), miner.WinPercentage*100, miner.GradedPercentage*100)
+	}
+	tw.Flush()
+}
+
 var rich = &cobra.Command{
 	Use:              "richlist [ASSET]",
 	Short:            "Get a list of richest addresses",
@@ -144,8 +168,7 @@ var rich = &cobra.Command{
 	PreRun:           SoftReadConfig,
 	Args:             cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		cl := srv.NewClient()
-		cl.PegnetdServer = viper.GetString(config.Pegnetd)
+		cl := pegnetdClient()
 		count, _ := cmd.Flags().GetInt("count")
 		if count == 0 {
 			count = 100
@@ -154,58 +177,69 @@ var rich = &cobra.Command{
 		if len(args) > 0 {
 			ticker := fat2.StringToTicker(args[0])
 			if ticker == fat2.PTickerInvalid {
-				cmd.PrintErrln(fmt.Errorf("invalid asset specified"))
-				os.Exit(1)
+				exitError(cmd, fmt.Errorf("invalid asset specified"))
 			}
 
-			assetRich(cl, ticker.String(), count)
+			assetRich(cmd, cl, ticker.String(), count)
 		} else {
-			globalRich(cl, count)
+			globalRich(cmd, cl, count)
 		}
 	},
 }
 
-func assetRich(cl *srv.Client, asset string, count int) {
+func assetRich(cmd *cobra.Command, cl *srv.Client, asset string, count int) {
 	var params srv.ParamsGetRichList
 	params.Asset = asset
 	params.Count = count
 
-	var res []srv.ResultGetRichList
-	err := cl.Request("get-rich-list", params, &res)
+	res, err := cl.GetRichList(context.Background(), params)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		exitError(cmd, err)
 	}
 
-	fmt.Printf("Top %d %s Rich List\n", count, asset)
-	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(tw, "Pos\tAddress\t%s\tpUSD\t\n", asset)
-	fmt.Fprintf(tw, "---\t-------\t%s\t----\t\n", strings.Repeat("-", len(asset)))
-	for i, e := range res {
-		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t\n", i+1, e.Address, FactoshiToFactoid(int64(e.Amount)), FactoshiToFactoid(int64(e.Equiv)))
-	}
-	tw.Flush()
+	printOutput(cmd, res, func() {
+		fmt.Printf("Top %d %s Rich List\n", count, asset)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Pos\tAddress\t%s\tpUSD\t\n", asset)
+		fmt.Fprintf(tw, "---\t-------\t%s\t----\t\n", strings.Repeat("-", len(asset)))
+		for i, e := range res {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t\n", i+1, e.Address, FactoshiToFactoid(int64(e.Amount)), FactoshiToFactoid(int64(e.Equiv)))
+		}
+		tw.Flush()
+	}, func() [][]string {
+		rows := [][]string{{"pos", "address", "amount", "pusd"}}
+		for i, e := range res {
+			rows = append(rows, []string{strconv.Itoa(i + 1), e.Address, strconv.FormatUint(e.Amount, 10), strconv.FormatUint(e.Equiv, 10)})
+		}
+		return rows
+	})
 }
 
-func globalRich(cl *srv.Client, count int) {
+func globalRich(cmd *cobra.Command, cl *srv.Client, count int) {
 	var params srv.ParamsGetGlobalRichList
 	params.Count = count
 
-	var res []srv.ResultGlobalRichList
-	err := cl.Request("get-global-rich-list", params, &res)
+	res, err := cl.GetGlobalRichList(context.Background(), params)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		exitError(cmd, err)
 	}
 
-	fmt.Printf("Top %d Global Rich List\n", count)
-	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(tw, "Pos\tAddress\tpUSD\t\n")
-	fmt.Fprintf(tw, "---\t-------\t----\t\n")
-	for i, e := range res {
-		fmt.Fprintf(tw, "%d\t%s\t%s\t\n", i+1, e.Address, FactoshiToFactoid(int64(e.Equiv)))
-	}
-	tw.Flush()
+	printOutput(cmd, res, func() {
+		fmt.Printf("Top %d Global Rich List\n", count)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Pos\tAddress\tpUSD\t\n")
+		fmt.Fprintf(tw, "---\t-------\t----\t\n")
+		for i, e := range res {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t\n", i+1, e.Address, FactoshiToFactoid(int64(e.Equiv)))
+		}
+		tw.Flush()
+	}, func() [][]string {
+		rows := [][]string{{"pos", "address", "pusd"}}
+		for i, e := range res {
+			rows = append(rows, []string{strconv.Itoa(i + 1), e.Address, strconv.FormatUint(e.Equiv, 10)})
+		}
+		return rows
+	})
 }
 
 var burn = &cobra.Command{
@@ -228,44 +262,41 @@ var burn = &cobra.Command{
 
 		amount, err := FactoidToFactoshi(amt)
 		if err != nil {
-			cmd.PrintErrln(fmt.Errorf("invalid amount specified"))
-			os.Exit(1)
+			exitError(cmd, fmt.Errorf("invalid amount specified"))
 		}
 
 		addr, err := factom.NewFAAddress(source)
 		if err != nil {
-			cmd.PrintErrln("invalid input address specified")
-			os.Exit(1)
+			exitError(cmd, fmt.Errorf("invalid input address specified"))
 		}
 		faddr := factom.Bytes32(addr)
 
-		priv, err := addr.GetFsAddress(nil, cl)
+		signer, err := signerFor(source, cl)
 		if err != nil {
-			cmd.PrintErrf("unable to get private key: %s\n", err.Error())
-			os.Exit(1)
+			exitErrorf(cmd, "unable to get private key: %s", err)
+		}
+		priv, ok := signer.(factom.FsAddress)
+		if !ok {
+			exitError(cmd, fmt.Errorf("the address is not compatible with factoid transactions, must be rcd type 1"))
 		}
 
 		rcd, _, err := factom.DecodeRCD(priv.RCD())
 		if err != nil {
-			cmd.PrintErrf("unable to decode private key: %s\n", err.Error())
-			os.Exit(1)
+			exitErrorf(cmd, "unable to decode private key: %s", err)
 		}
 
 		rcd1, ok := rcd.(*factom.RCD1)
 		if !ok {
-			cmd.PrintErrln("the address is not compatible with factoid transactions, must be rcd type 1")
-			os.Exit(1)
+			exitError(cmd, fmt.Errorf("the address is not compatible with factoid transactions, must be rcd type 1"))
 		}
 
 		balance, err := addr.GetBalance(nil, cl)
 		if err != nil {
-			cmd.PrintErrln("unable to retrieve balance:" + err.Error())
-			os.Exit(1)
+			exitErrorf(cmd, "unable to retrieve balance: %s", err)
 		}
 
 		if balance < uint64(amount) {
-			cmd.PrintErrf("not enough balance to cover the amount. balance = %s\n", FactoshiToFactoid(int64(balance)))
-			os.Exit(1)
+			exitErrorf(cmd, "not enough balance to cover the amount. balance = %s", FactoshiToFactoid(int64(balance)))
 		}
 
 		burnAddress, _ := factom.NewECAddress(node.BurnAddress)
@@ -292,8 +323,7 @@ var burn = &cobra.Command{
 
 		data, err := trans.MarshalLedgerBinary()
 		if err != nil { // should not happen
-			cmd.PrintErrf("unable to marshal for signature: %s\n", err.Error())
-			os.Exit(1)
+			exitErrorf(cmd, "unable to marshal for signature: %s", err)
 		}
 
 		sig := ed25519.Sign(priv.PrivateKey(), data)
@@ -301,8 +331,7 @@ var burn = &cobra.Command{
 
 		raw, err := trans.MarshalBinary()
 		if err != nil { // should not happen
-			cmd.PrintErrf("unable to marshal transaction: %s\n", err.Error())
-			os.Exit(1)
+			exitErrorf(cmd, "unable to marshal transaction: %s", err)
 		}
 
 		params := struct {
@@ -316,12 +345,13 @@ var burn = &cobra.Command{
 
 		err = cl.FactomdRequest(nil, "factoid-submit", params, &result)
 		if err != nil {
-			cmd.PrintErrf("unable to submit transaction: %s\n", err.Error())
-			os.Exit(1)
+			exitErrorf(cmd, "unable to submit transaction: %s", err)
 		}
 
-		fmt.Println(result.Message)
-		fmt.Printf("Transaction ID: %s\n", result.TXID)
+		printOutput(cmd, result, func() {
+			fmt.Println(result.Message)
+			fmt.Printf("Transaction ID: %s\n", result.TXID)
+		}, nil)
 	},
 }
 
@@ -349,44 +379,30 @@ var conv = &cobra.Command{
 		cl := node.FactomClientFromConfig(viper.GetViper())
 		payment, originalSource, srcAsset, amt, destAsset := args[0], args[1], args[2], args[3], args[4]
 
-		// Let's check the pXXX -> pFCT first
-		status := getStatus()
-		if (destAsset == "pFCT" || destAsset == "FCT") && uint32(status.Current) >= config.OneWaypFCTConversions {
-			cmd.PrintErrln(fmt.Sprintf("pXXX -> pFCT conversions are not allowed since block height %d. If you need to acquire pFCT, you have to burn FCT -> pFCT", config.OneWaypFCTConversions))
-			os.Exit(1)
-		}
-
-		// Let's check the pXXX -> pSmallAssets
-		// pSmallAssets means the 16 pAssets which have got small market cap.
-		if (destAsset == "PEG" || destAsset == "pDCR" || destAsset == "pDGB" || destAsset == "pDOGE" || destAsset == "pHBAR" ||
-			destAsset == "pONT" || destAsset == "pRVN" || destAsset == "pBAT" || destAsset == "pALGO" || destAsset == "pBIF" ||
-			destAsset == "pETB" || destAsset == "pKES" || destAsset == "pNGN" || destAsset == "pRWF" || destAsset == "pTZS" ||
-			destAsset == "pUGX") && uint32(status.Current) >= config.OneWaySmallAssetsConversions {
-			cmd.PrintErrln(fmt.Sprintf("pXXX -> pSmallAssets conversions are not allowed since block height %d.", config.OneWaySmallAssetsConversions))
-			os.Exit(1)
+		if err := conversionAllowed(destAsset); err != nil {
+			exitError(cmd, err)
 		}
 
 		// Build the transaction from the args
 		var trans fat2.Transaction
 		if err := setTransactionInput(&trans, cl, originalSource, srcAsset, amt); err != nil {
-			cmd.PrintErrln(err.Error())
-			os.Exit(1)
+			exitError(cmd, err)
 		}
 
 		if trans.Conversion, err = ticker(destAsset); err != nil {
-			cmd.PrintErrln("invalid ticker type")
-			os.Exit(1)
+			exitError(cmd, fmt.Errorf("invalid ticker type"))
 		}
 
 		err, commit, reveal := signAndSend(originalSource, &trans, cl, payment)
 		if err != nil {
-			cmd.PrintErrln(err.Error())
-			os.Exit(1)
+			exitError(cmd, err)
 		}
 
-		fmt.Printf("conversion sent:\n")
-		fmt.Printf("\t%10s: %s\n", "EntryHash", reveal)
-		fmt.Printf("\t%10s: %s\n", "Commit", commit)
+		printOutput(cmd, ResultSent{EntryHash: reveal.String(), Commit: commit.String()}, func() {
+			fmt.Printf("conversion sent:\n")
+			fmt.Printf("\t%10s: %s\n", "EntryHash", reveal)
+			fmt.Printf("\t%10s: %s\n", "Commit", commit)
+		}, nil)
 		printFeWarning(cmd, originalSource)
 	},
 }
@@ -414,31 +430,29 @@ var tx = &cobra.Command{
 		// Build the transaction from the args
 		var trans fat2.Transaction
 		if err := setTransactionInput(&trans, cl, source, asset, amt); err != nil {
-			cmd.PrintErrln(err.Error())
-			os.Exit(1)
+			exitError(cmd, err)
 		}
 
 		if err := setTransferOutput(&trans, cl, dest, amt); err != nil {
-			cmd.PrintErrln(err.Error())
-			os.Exit(1)
+			exitError(cmd, err)
 		}
 
 		// Before we sign and send, check the in/out rules
 		err := addressRules(source, dest)
 		if err != nil {
-			cmd.PrintErrln(err.Error())
-			os.Exit(1)
+			exitError(cmd, err)
 		}
 
 		err, commit, reveal := signAndSend(source, &trans, cl, payment)
 		if err != nil {
-			cmd.PrintErrln(err.Error())
-			os.Exit(1)
+			exitError(cmd, err)
 		}
 
-		fmt.Printf("transaction sent:\n")
-		fmt.Printf("\t%10s: %s\n", "EntryHash", reveal)
-		fmt.Printf("\t%10s: %s\n", "Commit", commit)
+		printOutput(cmd, ResultSent{EntryHash: reveal.String(), Commit: commit.String()}, func() {
+			fmt.Printf("transaction sent:\n")
+			fmt.Printf("\t%10s: %s\n", "EntryHash", reveal)
+			fmt.Printf("\t%10s: %s\n", "Commit", commit)
+		}, nil)
 
 		printFeWarning(cmd, source, dest)
 
@@ -457,70 +471,71 @@ var balance = &cobra.Command{
 	PersistentPreRun: always,
 	PreRun:           SoftReadConfig,
 	Args: CombineCobraArgs(
-		CustomArgOrderValidationBuilder(true, ArgValidatorAssetOrP, ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe)),
+		CustomArgOrderValidationBuilder(true, ArgValidatorAssetOrP, ArgValidatorAddressOrEth(ADD_FA|ADD_FE|ADD_Fe)),
 		cobra.MinimumNArgs(1)),
 	Run: func(cmd *cobra.Command, args []string) {
 		res, err := queryBalances(args[1])
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			exitError(cmd, err)
 		}
 
 		ticker := fat2.StringToTicker(toP(args[0]))
 		balance := res[ticker]
-		humanBal := FactoshiToFactoid(int64(balance))
-		fmt.Printf("%s %s\n", humanBal, ticker.String())
+		printOutput(cmd, srv.ResultPegnetTickerMap{ticker: balance}, func() {
+			humanBal := FactoshiToFactoid(int64(balance))
+			fmt.Printf("%s %s\n", humanBal, ticker.String())
+		}, func() [][]string { return tickerMapRows(srv.ResultPegnetTickerMap{ticker: balance}, "balance") })
 		printFeWarning(cmd, args[1])
 	},
 }
 
 var balances = &cobra.Command{
 	Use:              "balances <factoid-address>",
-	Short:            "Fetch all balances for a given factoid address",
+	Short:            "Fetch all balances for a given factoid or 0x ethereum address",
 	Example:          "pegnetd balances FA2CEc2JSkhuckEXy42K111MvM9bycUDkbrrHjd9bNkBfvPBSGKd",
 	PersistentPreRun: always,
 	PreRun:           SoftReadConfig,
 	Args: CombineCobraArgs(
-		CustomArgOrderValidationBuilder(true, ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe)),
+		CustomArgOrderValidationBuilder(true, ArgValidatorAddressOrEth(ADD_FA|ADD_FE|ADD_Fe)),
 		cobra.MinimumNArgs(1)),
 	Run: func(cmd *cobra.Command, args []string) {
 		res, err := queryBalances(args[0])
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			exitError(cmd, err)
 		}
 
-		// Change the units to be human readable
-		humanBals := make(map[string]string)
-		for k, bal := range res {
-			humanBals[k.String()] = FactoshiToFactoid(int64(bal))
-		}
+		printOutput(cmd, res, func() {
+			// Change the units to be human readable
+			humanBals := make(map[string]string)
+			for k, bal := range res {
+				humanBals[k.String()] = FactoshiToFactoid(int64(bal))
+			}
 
-		data, err := json.Marshal(humanBals)
-		if err != nil {
-			panic(err)
-		}
-		fmt.Println(string(data))
+			data, err := json.Marshal(humanBals)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println(string(data))
+		}, func() [][]string { return tickerMapRows(res, "balance") })
 		printFeWarning(cmd, args[0])
 	},
 }
 
 func queryBalances(humanAddress string) (srv.ResultPegnetTickerMap, error) {
-	cl := srv.NewClient()
-	cl.PegnetdServer = viper.GetString(config.Pegnetd)
-	addr, err := underlyingFA(humanAddress)
-	if err != nil {
-		// TODO: Better error
-		fmt.Println("1", err)
-		os.Exit(1)
+	cl := pegnetdClient()
+	// 0x ethereum addresses are resolved by pegnetd
+	address := humanAddress
+	if !pegnet.IsEthAddress(humanAddress) {
+		addr, err := underlyingFA(humanAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse input: %s", err.Error())
+		}
+		address = addr.String()
 	}
 
-	var res srv.ResultPegnetTickerMap
-	err = cl.Request("get-pegnet-balances", srv.ParamsGetPegnetBalances{addr.String()}, &res)
+	res, err := cl.GetBalances(context.Background(), address)
 	if err != nil {
-		// TODO: Better error
-		fmt.Println("2", err)
-		os.Exit(1)
+		return nil, err
 	}
 
 	return res, nil
@@ -532,33 +547,32 @@ var issuance = &cobra.Command{
 	PersistentPreRun: always,
 	PreRun:           SoftReadConfig,
 	Run: func(cmd *cobra.Command, args []string) {
-		cl := srv.NewClient()
-		cl.PegnetdServer = viper.GetString(config.Pegnetd)
-		var res srv.ResultGetIssuance
-		err := cl.Request("get-pegnet-issuance", nil, &res)
+		cl := pegnetdClient()
+		res, err := cl.GetIssuance(context.Background())
 		if err != nil {
-			fmt.Printf("Failed to make RPC request\nDetails:\n%v\n", err)
-			os.Exit(1)
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
 		}
 
-		// Change the units to be human readable
-		humanIssuance := make(map[string]string)
-		for k, bal := range res.Issuance {
-			humanIssuance[k.String()] = FactoshiToFactoid(int64(bal))
-		}
-		humanResult := struct {
-			SyncStatus srv.ResultGetSyncStatus `json:"sync-status"`
-			Issuance   map[string]string       `json:"issuance"`
-		}{
-			SyncStatus: res.SyncStatus,
-			Issuance:   humanIssuance,
-		}
+		printOutput(cmd, res, func() {
+			// Change the units to be human readable
+			humanIssuance := make(map[string]string)
+			for k, bal := range res.Issuance {
+				humanIssuance[k.String()] = FactoshiToFactoid(int64(bal))
+			}
+			humanResult := struct {
+				SyncStatus srv.ResultGetSyncStatus `json:"sync-status"`
+				Issuance   map[string]string       `json:"issuance"`
+			}{
+				SyncStatus: res.SyncStatus,
+				Issuance:   humanIssuance,
+			}
 
-		data, err := json.Marshal(humanResult)
-		if err != nil {
-			panic(err)
-		}
-		fmt.Println(string(data))
+			data, err := json.Marshal(humanResult)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println(string(data))
+		}, func() [][]string { return tickerMapRows(res.Issuance, "issuance") })
 	},
 }
 
@@ -568,12 +582,17 @@ var status = &cobra.Command{
 	PersistentPreRun: always,
 	PreRun:           SoftReadConfig,
 	Run: func(cmd *cobra.Command, args []string) {
-		res := getStatus()
-		data, err := json.Marshal(res)
+		res, err := getStatus()
 		if err != nil {
-			panic(err)
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
 		}
-		fmt.Println(string(data))
+		printOutput(cmd, res, func() {
+			data, err := json.Marshal(res)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println(string(data))
+		}, nil)
 	},
 }
 
@@ -600,10 +619,8 @@ var resetDB = &cobra.Command{
 }
 
 func getProperties() srv.PegnetdProperties {
-	cl := srv.NewClient()
-	cl.PegnetdServer = viper.GetString(config.Pegnetd)
-	var res srv.PegnetdProperties
-	err := cl.Request("properties", nil, &res)
+	cl := pegnetdClient()
+	res, err := cl.Properties(context.Background())
 	if err != nil {
 		return srv.PegnetdProperties{
 			BuildVersion:  "Unknown/Unable",
@@ -615,16 +632,9 @@ func getProperties() srv.PegnetdProperties {
 	return res
 }
 
-func getStatus() srv.ResultGetSyncStatus {
-	cl := srv.NewClient()
-	cl.PegnetdServer = viper.GetString(config.Pegnetd)
-	var res srv.ResultGetSyncStatus
-	err := cl.Request("get-sync-status", nil, &res)
-	if err != nil {
-		fmt.Printf("Failed to make RPC request\nDetails:\n%v\n", err)
-		os.Exit(1)
-	}
-	return res
+func getStatus() (srv.ResultGetSyncStatus, error) {
+	cl := pegnetdClient()
+	return cl.GetSyncStatus(context.Background())
 }
 
 var get = &cobra.Command{
@@ -641,50 +651,63 @@ var getTX = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		_, _, err := pegnet.SplitTxID(args[0])
 		if err != nil {
-			cmd.PrintErrf("txid is invalid: %s", err.Error())
+			exitError(cmd, usageError{fmt.Errorf("txid is invalid: %s", err.Error())})
 		}
 
-		cl := srv.NewClient()
-		cl.PegnetdServer = viper.GetString(config.Pegnetd)
-		var res srv.ResultGetTransactions
-		err = cl.Request("get-transaction", srv.ParamsGetPegnetTransaction{TxID: args[0]}, &res)
+		cl := pegnetdClient()
+		res, err := cl.GetTransaction(context.Background(), args[0])
 		if err != nil {
-			fmt.Printf("Failed to make RPC request\nDetails:\n%v\n", err)
-			os.Exit(1)
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
 		}
 
-		data, err := json.Marshal(res)
-		if err != nil {
-			panic(err)
-		}
-		fmt.Println(string(data))
+		printTransactions(cmd, res)
 	},
 }
 
 var getTXs = &cobra.Command{
-	Use:   "txs <entryhash | FA address | height>",
-	Short: "Fetch all transactions for an entryhash, FA address, or height",
+	Use:   "txs [entryhash | FA address | height]",
+	Short: "Fetch all transactions for an entryhash, FA address, height, or range",
 	Long: "Fetch all transactions for an entryhash, FA address, or height. " +
 		"If a --burn, --cvt, --tran, or --coin is provided, then only the flags" +
 		" provided will be displayed. If you specify --asset=pAsset, only transactions" +
-		" involving that asset will be returned.",
-	Example:          "pegnetd txs 07cebdd5d3f5216f36f792d71f030af07ddaa99147929d9af477833ee4c586a5",
+		" involving that asset will be returned. The results can be limited to a" +
+		" window using --fromheight/--toheight (inclusive) or --fromtime/--totime" +
+		" (RFC3339, totime is exclusive). If no argument is given, all transactions" +
+		" within the window are returned.",
+	Example:          "pegnetd txs 07cebdd5d3f5216f36f792d71f030af07ddaa99147929d9af477833ee4c586a5\npegnetd txs FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q --fromtime 2020-01-01T00:00:00Z --totime 2021-01-01T00:00:00Z\npegnetd txs --fromheight 222270 --toheight 222300",
 	PersistentPreRun: always,
 	PreRun:           SoftReadConfig,
-	Args:             cobra.ExactArgs(1),
+	Args:             cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var height int
+		var err error
 		// determine the params
 		var params srv.ParamsGetPegnetTransaction
 		var add factom.FAAddress
 
+		params.FromHeight, _ = cmd.Flags().GetInt("fromheight")
+		params.ToHeight, _ = cmd.Flags().GetInt("toheight")
+		params.FromTime, _ = cmd.Flags().GetString("fromtime")
+		params.ToTime, _ = cmd.Flags().GetString("totime")
+		if len(args) == 0 {
+			if params.FromHeight == 0 && params.ToHeight == 0 && params.FromTime == "" && params.ToTime == "" {
+				exitError(cmd, fmt.Errorf("specify an entryhash, address, or height, or a range with --fromheight, --toheight, --fromtime, or --totime"))
+			}
+			goto FoundParams
+		}
+
 		// An entryhash?
-		bytes, err := hex.DecodeString(args[0])
-		if err == nil && len(bytes) == 32 {
+		if bytes, err := hex.DecodeString(args[0]); err == nil && len(bytes) == 32 {
 			params.Hash = args[0]
 			goto FoundParams
 		}
 
+		// An ethereum address, which pegnetd resolves?
+		if pegnet.IsEthAddress(args[0]) {
+			params.Address = args[0]
+			goto FoundParams
+		}
+
 		// A factoid address maybe?
 		add, err = underlyingFA(args[0])
 		if err == nil {
@@ -702,7 +725,7 @@ var getTXs = &cobra.Command{
 		}
 
 		// I give up.
-		cmd.PrintErrf("param invalid. could not determine type")
+		exitError(cmd, usageError{fmt.Errorf("param invalid. could not determine type")})
 	FoundParams:
 
 		params.Conversion, _ = cmd.Flags().GetBool("cvt")
@@ -712,66 +735,274 @@ var getTXs = &cobra.Command{
 		params.Asset, _ = cmd.Flags().GetString("asset")
 		params.Offset, _ = cmd.Flags().GetInt("offset")
 
-		cl := srv.NewClient()
-		cl.PegnetdServer = viper.GetString(config.Pegnetd)
-		var res srv.ResultGetTransactions
-		err = cl.Request("get-transactions", params, &res)
+		cl := pegnetdClient()
+		res, err := cl.GetTransactions(context.Background(), params)
 		if err != nil {
-			fmt.Printf("Failed to make RPC request\nDetails:\n%v\n", err)
-			os.Exit(1)
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
 		}
 
+		printTransactions(cmd, res)
+	},
+}
+
+// printTransactions prints the result of get-transaction(s). The csv has a
+// row per action.
+func printTransactions(cmd *cobra.Command, res srv.ResultGetTransactions) {
+	printOutput(cmd, res, func() {
 		data, err := json.Marshal(res)
 		if err != nil {
 			panic(err)
 		}
 		fmt.Println(string(data))
-	},
+	}, func() [][]string {
+		rows, err := jsonRows(res.Actions)
+		if err != nil {
+			exitError(cmd, err)
+		}
+		return rows
+	})
 }
 
 var getRates = &cobra.Command{
-	Use:              "rates <height>",
-	Short:            "Fetch the pegnet quotes for the assets at a given height (if their are quotes)",
+	Use:   "rates <height>",
+	Short: "Fetch the pegnet quotes for the assets at a given height (if their are quotes)",
+	Long: "Fetch the pegnet quotes for the assets at a given height (if their are quotes). " +
+		"Use --from and --to instead of a height to fetch the quotes of a range of heights. " +
+		"If --bucket is given, the quotes are aggregated into open/high/low/close/avg buckets of that many blocks.",
+	Example:          "pegnetd get rates 222270\npegnetd get rates --from 222270 --to 226590 --assets pXBT,pUSD --bucket 144 --csv",
 	PersistentPreRun: always,
 	PreRun:           SoftReadConfig,
 	Args:             cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetUint32("from")
+		to, _ := cmd.Flags().GetUint32("to")
+		if from > 0 || to > 0 {
+			if len(args) > 0 {
+				exitError(cmd, usageError{fmt.Errorf("cannot specify a height together with --from and --to")})
+			}
+			getRatesRange(cmd, from, to)
+			return
+		}
+
 		var height int
 		var err error
 		if len(args) > 0 {
 			height, err = strconv.Atoi(args[0])
 			if height <= 0 || err != nil {
-				cmd.PrintErrf("height must be a number greater than 0")
-				os.Exit(1)
+				exitError(cmd, usageError{fmt.Errorf("height must be a number greater than 0")})
 			}
 		}
 
-		cl := srv.NewClient()
-		cl.PegnetdServer = viper.GetString(config.Pegnetd)
-		res, err := getPegnetRates(uint32(height), cl)
+		cl := pegnetdClient()
+		res, err := cl.GetRates(context.Background(), uint32(height))
 		if err != nil {
-			fmt.Printf("Failed to make RPC request\nDetails:\n%v\n", err)
-			os.Exit(1)
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
 		}
 
-		// Change the units to be human readable
-		humanBals := make(map[string]string)
-		for k, bal := range res {
-			humanBals[k.String()] = FactoshiToFactoid(int64(bal))
+		printOutput(cmd, res, func() {
+			// Change the units to be human readable
+			humanBals := make(map[string]string)
+			for k, bal := range res {
+				humanBals[k.String()] = FactoshiToFactoid(int64(bal))
+			}
+
+			data, err := json.Marshal(humanBals)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println(string(data))
+		}, func() [][]string { return tickerMapRows(res, "rate") })
+	},
+}
+
+func getRatesRange(cmd *cobra.Command, from, to uint32) {
+	if from == 0 || to < from {
+		exitError(cmd, usageError{fmt.Errorf("--from must be > 0 and --to must be >= --from")})
+	}
+
+	var params srv.ParamsGetPegnetRatesRange
+	params.Assets, _ = cmd.Flags().GetStringSlice("assets")
+	for i := range params.Assets {
+		params.Assets[i] = toP(params.Assets[i])
+	}
+	params.Bucket, _ = cmd.Flags().GetUint32("bucket")
+
+	bucket := params.Bucket
+	if uint64(bucket) > uint64(to)-uint64(from)+1 {
+		exitError(cmd, usageError{fmt.Errorf("--bucket must be <= the number of blocks from --from to --to")})
+	}
+
+	cl := pegnetdClient()
+
+	// Ranges that are too large for a single request are split up. The
+	// chunks are a multiple of the bucket size, so the buckets stay aligned.
+	// The arithmetic is in uint64, the heights go up to the largest uint32.
+	chunk := uint64(srv.MaxRatesRangeResults)
+	if bucket > 0 {
+		chunk *= uint64(bucket)
+	}
+	var res srv.ResultGetPegnetRatesRange
+	for start := uint64(from); start <= uint64(to); start += chunk {
+		end := start + chunk - 1
+		if end > uint64(to) {
+			end = uint64(to)
+		}
+		params.FromHeight, params.ToHeight = uint32(start), uint32(end)
+		// The last chunk may be shorter than a bucket, which is then
+		// requested as a bucket of the rest of the range
+		if bucket > 0 && end-start+1 < uint64(bucket) {
+			params.Bucket = uint32(end - start + 1)
 		}
 
-		data, err := json.Marshal(humanBals)
-		if err != nil {
-			panic(err)
+		part, err := cl.GetRatesRange(context.Background(), params)
+		// A chunk without any rates is not an error
+		if err != nil && !errors.Is(err, srv.ErrorNotFound) {
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
 		}
-		fmt.Println(string(data))
-	},
+		res.Rates = append(res.Rates, part.Rates...)
+		for _, b := range part.Buckets {
+			// As a single request would have it
+			if last := uint64(b.FromHeight) + uint64(bucket) - 1; last <= math.MaxUint32 {
+				b.ToHeight = uint32(last)
+			} else {
+				b.ToHeight = math.MaxUint32
+			}
+			res.Buckets = append(res.Buckets, b)
+		}
+	}
+
+	// --csv predates --output and prints human readable units
+	if asCSV, _ := cmd.Flags().GetBool("csv"); !asCSV || structuredOutput(cmd) {
+		printOutput(cmd, res, func() {
+			data, err := json.Marshal(res)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println(string(data))
+		}, func() [][]string { return ratesRangeRows(res) })
+		return
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	if params.Bucket == 0 {
+		// One row per height, one column per asset
+		var tickers []fat2.PTicker
+		seen := make(map[fat2.PTicker]bool)
+		for _, r := range res.Rates {
+			for ticker := range r.Rates {
+				if !seen[ticker] {
+					seen[ticker] = true
+					tickers = append(tickers, ticker)
+				}
+			}
+		}
+		sort.Slice(tickers, func(i, j int) bool { return tickers[i] < tickers[j] })
+
+		header := []string{"height"}
+		for _, ticker := range tickers {
+			header = append(header, ticker.String())
+		}
+		_ = w.Write(header)
+		for _, r := range res.Rates {
+			row := []string{strconv.FormatUint(uint64(r.Height), 10)}
+			for _, ticker := range tickers {
+				rate, ok := r.Rates[ticker]
+				if !ok {
+					row = append(row, "")
+					continue
+				}
+				row = append(row, FactoshiToFactoid(int64(rate)))
+			}
+			_ = w.Write(row)
+		}
+	} else {
+		_ = w.Write([]string{"fromheight", "toheight", "asset", "open", "high", "low", "close", "avg"})
+		for _, b := range res.Buckets {
+			assets := make([]string, 0, len(b.Rates))
+			for asset := range b.Rates {
+				assets = append(assets, asset)
+			}
+			sort.Strings(assets)
+			for _, asset := range assets {
+				ohlc := b.Rates[asset]
+				_ = w.Write([]string{
+					strconv.FormatUint(uint64(b.FromHeight), 10),
+					strconv.FormatUint(uint64(b.ToHeight), 10),
+					asset,
+					FactoshiToFactoid(int64(ohlc.Open)),
+					FactoshiToFactoid(int64(ohlc.High)),
+					FactoshiToFactoid(int64(ohlc.Low)),
+					FactoshiToFactoid(int64(ohlc.Close)),
+					FactoshiToFactoid(int64(ohlc.Avg)),
+				})
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		exitError(cmd, err)
+	}
 }
 
-func getPegnetRates(height uint32, cl *srv.Client) (srv.ResultPegnetTickerMap, error) {
-	var res srv.ResultPegnetTickerMap
-	err := cl.Request("get-pegnet-rates", srv.ParamsGetPegnetRates{Height: height}, &res)
-	return res, err
+// ratesRangeRows are the csv rows of --output csv, a row per height and asset,
+// or per bucket and asset
+func ratesRangeRows(res srv.ResultGetPegnetRatesRange) [][]string {
+	if len(res.Buckets) == 0 {
+		rows := [][]string{{"height", "asset", "rate"}}
+		for _, r := range res.Rates {
+			for _, row := range tickerMapRows(r.Rates, "rate")[1:] {
+				rows = append(rows, append([]string{strconv.FormatUint(uint64(r.Height), 10)}, row...))
+			}
+		}
+		return rows
+	}
+
+	rows := [][]string{{"fromheight", "toheight", "asset", "open", "high", "low", "close", "avg"}}
+	for _, b := range res.Buckets {
+		assets := make([]string, 0, len(b.Rates))
+		for asset := range b.Rates {
+			assets = append(assets, asset)
+		}
+		sort.Strings(assets)
+		for _, asset := range assets {
+			ohlc := b.Rates[asset]
+			rows = append(rows, []string{
+				strconv.FormatUint(uint64(b.FromHeight), 10),
+				strconv.FormatUint(uint64(b.ToHeight), 10),
+				asset,
+				strconv.FormatUint(ohlc.Open, 10),
+				strconv.FormatUint(ohlc.High, 10),
+				strconv.FormatUint(ohlc.Low, 10),
+				strconv.FormatUint(ohlc.Close, 10),
+				strconv.FormatUint(ohlc.Avg, 10),
+			})
+		}
+	}
+	return rows
+}
+
+// pegnetdClient returns a client of the --pegnetd endpoint
+func pegnetdClient() *srv.Client {
+	cl := srv.NewClient()
+	cl.PegnetdServer = viper.GetString(config.Pegnetd)
+	if user := viper.GetString(config.PegnetdUser); user != "" {
+		cl.BasicAuth = true
+		cl.User = user
+		cl.Password = viper.GetString(config.PegnetdPass)
+	}
+	if token := viper.GetString(config.PegnetdToken); token != "" {
+		cl.Header = http.Header{"Authorization": []string{"Bearer " + token}}
+	}
+
+	ca, cert, key := viper.GetString(config.PegnetdTLSCA), viper.GetString(config.PegnetdTLSCert), viper.GetString(config.PegnetdTLSKey)
+	if ca != "" || cert != "" || key != "" {
+		tlsConfig, err := srv.ClientTLSConfig(ca, cert, key)
+		if err != nil {
+			exitErrorf(rootCmd, "failed to load the pegnetd tls config: %s", err)
+		}
+		cl.Transport = &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig}
+	}
+	return cl
 }
 
 var getBank = &cobra.Command{
@@ -786,21 +1017,21 @@ var getBank = &cobra.Command{
 		if len(args) > 0 {
 			height, err = strconv.Atoi(args[0])
 			if height <= 0 || err != nil {
-				cmd.PrintErrf("height must be a number greater than 0")
-				os.Exit(1)
+				exitError(cmd, usageError{fmt.Errorf("height must be a number greater than 0")})
 			}
 		}
 
-		cl := srv.NewClient()
-		cl.PegnetdServer = viper.GetString(config.Pegnetd)
-		var res pegnet.BankEntry
-		err = cl.Request("get-bank", srv.ParamsGetBank{Height: int32(height)}, &res) //TODO: height should be uint32
+		cl := pegnetdClient()
+		res, err := cl.GetBank(context.Background(), int32(height)) //TODO: height should be uint32
 		if err != nil {
-			fmt.Printf("Failed to make RPC request\nDetails:\n%v\n", err)
-			os.Exit(1)
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
 		}
 
-		if raw, _ := cmd.Flags().GetBool("raw"); raw {
+		if raw, _ := cmd.Flags().GetBool("raw"); raw || structuredOutput(cmd) {
+			if structuredOutput(cmd) {
+				printOutput(cmd, res, nil, nil)
+				return
+			}
 			data, err := json.Marshal(res)
 			if err != nil {
 				panic(err)
@@ -810,8 +1041,7 @@ var getBank = &cobra.Command{
 		}
 
 		if res.Height == -1 {
-			fmt.Println("No bank details found for this height. This is not a valid pegnet block. It could be skipped by miners, the block is in the future, or the block was before the bank was implemented.")
-			os.Exit(1)
+			exitError(cmd, fmt.Errorf("No bank details found for this height. This is not a valid pegnet block. It could be skipped by miners, the block is in the future, or the block was before the bank was implemented."))
 		}
 		// Pretty print
 		fmt.Printf("Bank details for height %d\n", res.Height)
@@ -819,7 +1049,7 @@ var getBank = &cobra.Command{
 		fmt.Printf("PEG Consumed  : %s PEG\n", FactoshiToFactoid(res.BankUsed))
 		fmt.Printf("PEG Requested : %s PEG\n", FactoshiToFactoid(res.PEGRequested))
 
-		rates, err := getPegnetRates(uint32(res.Height), cl)
+		rates, err := cl.GetRates(context.Background(), uint32(res.Height))
 		if err == nil {
 			fmt.Println("")
 			fmt.Println("Value in USD")
@@ -835,6 +1065,103 @@ var getBank = &cobra.Command{
 	},
 }
 
+var getBlock = &cobra.Command{
+	Use:              "block <height>",
+	Short:            "Fetch what syncing a height did: its winners, transactions, conversions and payouts. Put no height for the latest",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var height uint64
+		if len(args) > 0 {
+			var err error
+			height, err = strconv.ParseUint(args[0], 10, 32)
+			if height == 0 || err != nil {
+				exitError(cmd, usageError{fmt.Errorf("height must be a number greater than 0")})
+			}
+		}
+
+		res, err := pegnetdClient().GetBlockSummary(context.Background(), uint32(height))
+		if err != nil {
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
+		}
+
+		printOutput(cmd, res, func() {
+			fmt.Printf("Block %d, %s\n", res.Height, res.Timestamp.UTC().Format(time.RFC3339))
+			fmt.Printf("DBlock KeyMR  : %s\n", res.KeyMR)
+			fmt.Printf("OPR entries   : %d, %d winners\n", res.OPREntries, len(res.OPRWinners))
+			fmt.Printf("SPR entries   : %d, %d winners\n", res.SPREntries, len(res.SPRWinners))
+			fmt.Printf("Rates         : %t\n", res.Rates)
+			fmt.Printf("Transactions  : %d executed, %d rejected, %d held\n", res.Executed, res.Rejected, res.Held)
+			if res.Bank > 0 || res.PEGRequested > 0 {
+				fmt.Printf("PEG Bank      : %s PEG\n", FactoshiToFactoid(int64(res.Bank)))
+				fmt.Printf("PEG Requested : %s PEG\n", FactoshiToFactoid(int64(res.PEGRequested)))
+				fmt.Printf("PEG Allocated : %s PEG\n", FactoshiToFactoid(int64(res.PEGAllocated)))
+			}
+
+			if len(res.Payouts) > 0 {
+				fmt.Println("Payouts")
+				tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				for _, category := range []string{pegnet.PayoutMiners, pegnet.PayoutStakers, pegnet.PayoutHolders, pegnet.PayoutDevelopers} {
+					if amount, ok := res.Payouts[category]; ok {
+						_, _ = fmt.Fprintf(tw, "  %s\t%s PEG\t\n", category, FactoshiToFactoid(int64(amount)))
+					}
+				}
+				_ = tw.Flush()
+			}
+		}, func() [][]string {
+			rows := [][]string{{"height", "timestamp", "keymr", "oprentries", "oprwinners", "sprentries", "sprwinners", "rates",
+				"executed", "rejected", "held", "bank", "pegrequested", "pegallocated",
+				pegnet.PayoutMiners, pegnet.PayoutStakers, pegnet.PayoutHolders, pegnet.PayoutDevelopers}}
+			return append(rows, []string{
+				strconv.FormatUint(uint64(res.Height), 10), res.Timestamp.UTC().Format(time.RFC3339), res.KeyMR.String(),
+				strconv.Itoa(res.OPREntries), strconv.Itoa(len(res.OPRWinners)), strconv.Itoa(res.SPREntries), strconv.Itoa(len(res.SPRWinners)),
+				strconv.FormatBool(res.Rates), strconv.Itoa(res.Executed), strconv.Itoa(res.Rejected), strconv.Itoa(res.Held),
+				strconv.FormatUint(res.Bank, 10), strconv.FormatUint(res.PEGRequested, 10), strconv.FormatUint(res.PEGAllocated, 10),
+				strconv.FormatUint(res.Payouts[pegnet.PayoutMiners], 10), strconv.FormatUint(res.Payouts[pegnet.PayoutStakers], 10),
+				strconv.FormatUint(res.Payouts[pegnet.PayoutHolders], 10), strconv.FormatUint(res.Payouts[pegnet.PayoutDevelopers], 10),
+			})
+		})
+	},
+}
+
+var getSubmission = &cobra.Command{
+	Use:              "submission <idempotencykey>",
+	Short:            "Fetch the status of a transaction submitted to pegnetd. The idempotency key is the entry hash by default",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		res, err := pegnetdClient().GetSubmissionStatus(context.Background(), args[0])
+		if err != nil {
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
+		}
+
+		printOutput(cmd, res, func() {
+			fmt.Printf("Entry hash : %s\n", res.EntryHash)
+			fmt.Printf("Status     : %s\n", res.Status)
+			if res.Height > 0 {
+				fmt.Printf("Height     : %d\n", res.Height)
+			}
+			fmt.Printf("Attempts   : %d\n", res.Attempts)
+			if res.Error != "" {
+				fmt.Printf("Last error : %s\n", res.Error)
+			}
+		}, func() [][]string {
+			var txID string
+			if res.TxID != nil {
+				txID = res.TxID.String()
+			}
+			return [][]string{
+				{"idempotencykey", "entryhash", "txid", "status", "submitted", "height", "attempts", "error", "created", "updated"},
+				{res.Key, res.EntryHash.String(), txID, res.Status, strconv.FormatUint(uint64(res.Submitted), 10),
+					strconv.FormatUint(uint64(res.Height), 10), strconv.Itoa(res.Attempts), res.Error,
+					res.Created.UTC().Format(time.RFC3339), res.Updated.UTC().Format(time.RFC3339)},
+			}
+		})
+	},
+}
+
 func toP(asset string) string {
 	if strings.ToLower(asset) == "PEG" {
 		return "PEG"
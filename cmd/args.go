@@ -7,6 +7,7 @@ import (
 	"github.com/Factom-Asset-Tokens/factom"
 
 	"github.com/pegnet/pegnet/modules/opr"
+	"github.com/pegnet/pegnetd/node/pegnet"
 	"github.com/spf13/cobra"
 )
 
@@ -130,6 +131,18 @@ func ArgValidatorAddress(flag uint8) func(cmd *cobra.Command, arg string) error
 	}
 }
 
+// ArgValidatorAddressOrEth is ArgValidatorAddress that also accepts 0x
+// ethereum addresses, which pegnetd resolves to the FA address of the RCD-e
+func ArgValidatorAddressOrEth(flag uint8) func(cmd *cobra.Command, arg string) error {
+	validator := ArgValidatorAddress(flag)
+	return func(cmd *cobra.Command, arg string) error {
+		if pegnet.IsEthAddress(arg) {
+			return nil
+		}
+		return validator(cmd, arg)
+	}
+}
+
 // ArgValidatorFCTAddress checks for FCT address
 func ArgValidatorFCTAddress(cmd *cobra.Command, arg string) error {
 	if len(arg) > 2 && arg[:2] != "FA" {
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/pegnet/pegnetd/node"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	batchCmd.AddCommand(batchNew)
+	batchCmd.AddCommand(batchAddTransfer)
+	batchCmd.AddCommand(batchAddConversion)
+	batchSign.Flags().String("keyfile", "", "Sign with the keys in this file instead of factom-walletd")
+	batchSign.Flags().String("out", "", "Write the signed batch to this file instead of overwriting the input")
+	batchCmd.AddCommand(batchSign)
+	batchCmd.AddCommand(batchSend)
+	rootCmd.AddCommand(batchCmd)
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch <subcommand>",
+	Short: "Build a batch of pegnet transactions that are executed together",
+	Long: "Build a batch of pegnet transactions that are signed together and executed atomically: either " +
+		"every transaction in the batch succeeds, or none do. The batch file uses the same format as " +
+		"'tx build', so it can be signed on an offline machine.\n\n" +
+		"The batch file format supports several input addresses, but the pegnet protocol currently only " +
+		"accepts batches with a single input address. Adding a transaction from a second input address " +
+		"is refused.\n\n" +
+		"Adding a transaction renews the timestamp of the batch and drops all signatures, so sign the " +
+		"batch after the last transaction is added. The batch has to be sent within 12 hours of that.",
+}
+
+var batchNew = &cobra.Command{
+	Use:              "new <file>",
+	Short:            "Create an empty batch file",
+	Example:          "pegnetd batch new batch.json",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, err := os.Stat(args[0]); err == nil {
+			exitErrorf(cmd, "%s already exists", args[0])
+		}
+
+		o := &fat2.OfflineBatch{Version: fat2.OfflineBatchVersion, ChainID: &config.TransactionChain}
+		if err := writeOfflineBatch(args[0], o); err != nil {
+			exitErrorf(cmd, "failed to write batch: %s", err)
+		}
+		printMessage(cmd, "Created empty batch %s", args[0])
+	},
+}
+
+var batchAddTransfer = &cobra.Command{
+	Use:   "add-transfer <file> <FA-SOURCE> <ASSET> <AMOUNT> <FA-DESTINATION>",
+	Short: "Add a transfer to a batch file",
+	Example: "pegnetd batch add-transfer batch.json FA33kNzXwUt3cn4tLR56kyHEAryazAGPuMC6GjUubSbwrrNv8e7t PEG 200 " +
+		"FA32xV6SoPBSbAZAVyuiHWwyoMYhnSyMmAHZfK29H8dx7bJXFLja",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args: CombineCobraArgs(
+		CustomArgOrderValidationBuilder(
+			true,
+			func(cmd *cobra.Command, arg string) error { return nil },
+			ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe),
+			ArgValidatorAssetOrP,
+			ArgValidatorFCTAmount,
+			ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe)),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		cl := node.FactomClientFromConfig(viper.GetViper())
+		path, source, asset, amt, dest := args[0], args[1], args[2], args[3], args[4]
+
+		if err := addressRules(source, dest); err != nil {
+			exitError(cmd, err)
+		}
+
+		var trans fat2.Transaction
+		if err := setTransactionInput(&trans, cl, source, asset, amt); err != nil {
+			exitError(cmd, err)
+		}
+		if err := setTransferOutput(&trans, cl, dest, amt); err != nil {
+			exitError(cmd, err)
+		}
+
+		addToBatch(cmd, path, source, trans)
+		printFeWarning(cmd, source, dest)
+	},
+}
+
+var batchAddConversion = &cobra.Command{
+	Use:              "add-conversion <file> <FA-SOURCE> <SRC-ASSET> <AMOUNT> <DEST-ASSET>",
+	Short:            "Add a conversion to a batch file",
+	Example:          "pegnetd batch add-conversion batch.json FA32xV6SoPBSbAZAVyuiHWwyoMYhnSyMmAHZfK29H8dx7bJXFLja pFCT 100 pUSD",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args: CombineCobraArgs(
+		CustomArgOrderValidationBuilder(
+			true,
+			func(cmd *cobra.Command, arg string) error { return nil },
+			ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe),
+			ArgValidatorAssetOrP,
+			ArgValidatorFCTAmount,
+			ArgValidatorAssetOrP),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		cl := node.FactomClientFromConfig(viper.GetViper())
+		path, source, srcAsset, amt, destAsset := args[0], args[1], args[2], args[3], args[4]
+
+		if err := conversionAllowed(destAsset); err != nil {
+			exitError(cmd, err)
+		}
+
+		var trans fat2.Transaction
+		if err := setTransactionInput(&trans, cl, source, srcAsset, amt); err != nil {
+			exitError(cmd, err)
+		}
+		if trans.Conversion, err = ticker(destAsset); err != nil {
+			exitError(cmd, fmt.Errorf("invalid ticker type"))
+		}
+
+		addToBatch(cmd, path, source, trans)
+		printFeWarning(cmd, source)
+	},
+}
+
+var batchSign = &cobra.Command{
+	Use:   "sign <file>",
+	Short: "Sign a batch file",
+	Long: "Sign a batch file with the keys of its input addresses. The keys are fetched from factom-walletd, " +
+		"from the keystore with --keystore, or read from a key file with --keyfile, see 'pegnetd tx sign --help' " +
+		"for the key file format. The command can be run on several machines until every input has signed.",
+	Example:          "pegnetd batch sign batch.json --keyfile keys.txt",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.ExactArgs(1),
+	Run:              signOfflineBatch,
+}
+
+var batchSend = &cobra.Command{
+	Use:              "send <ECAddress> <file>",
+	Short:            "Submit a signed batch file as a single entry",
+	Example:          "pegnetd batch send EC3eX8VxGH64Xv3NFd9g4Y7PxSMnH3EGz5jQQrrQS8VZGnv4JY2K batch.json",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.ExactArgs(2),
+	Run:              submitOfflineBatch,
+}
+
+// addToBatch adds the transaction to the batch file, after checking that the
+// input can cover all of its transactions in the batch
+func addToBatch(cmd *cobra.Command, path, source string, trans fat2.Transaction) {
+	o, err := readOfflineBatch(path)
+	if err != nil {
+		exitError(cmd, err)
+	}
+
+	// setTransactionInput only checked the balance against this transaction
+	total := trans.Input.Amount
+	for _, tx := range o.Transactions() {
+		if tx.Input.Address == trans.Input.Address && tx.Input.Type == trans.Input.Type {
+			total += tx.Input.Amount
+		}
+	}
+	if total > trans.Input.Amount {
+		pBals, err := queryBalances(source)
+		if err != nil {
+			exitErrorf(cmd, "failed to get asset balance: %s", err)
+		}
+		if pBals[trans.Input.Type] < total {
+			exitErrorf(cmd, "not enough %s to cover all the transactions of %s in the batch", trans.Input.Type, source)
+		}
+	}
+
+	if err := o.AddTransaction(trans, source); err != nil {
+		exitErrorf(cmd, "unable to add the transaction: %s", err)
+	}
+	if err := writeOfflineBatch(path, o); err != nil {
+		exitErrorf(cmd, "failed to write batch: %s", err)
+	}
+	printMessage(cmd, "Added transaction %d to %s", len(o.Transactions())-1, path)
+}
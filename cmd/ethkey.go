@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/keystore"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// EthKeystorePasswordEnv is the environment variable that holds the password
+// of the --ethkeystore file for unattended use
+const EthKeystorePasswordEnv = "PEGNETD_ETHKEYSTORE_PASSWORD"
+
+func init() {
+	keys.AddCommand(keysEth)
+}
+
+var keysEth = &cobra.Command{
+	Use:   "eth [Fe/FE/FA address]",
+	Short: "Show the addresses of the --ethkey or --ethkeystore key",
+	Long: "Show the 0x ethereum address, the Fe address and the underlying FA address of the key given with " +
+		"--ethkey or --ethkeystore, so they can be checked before signing. If an address is given, the command " +
+		"fails unless the key belongs to it.",
+	Example:          "pegnetd keys eth --ethkeystore UTC--2020-01-01T00-00-00.000000000Z--0x1234.json",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args: CombineCobraArgs(
+		cobra.MaximumNArgs(1),
+		CustomArgOrderValidationBuilder(false, ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe)),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		secret, ok, err := ethKeyFromFlags()
+		if err != nil {
+			exitError(cmd, err)
+		}
+		if !ok {
+			exitError(cmd, fmt.Errorf("no key given, use --ethkey or --ethkeystore"))
+		}
+
+		if len(args) > 0 {
+			if err := ethKeyMatches(secret, args[0]); err != nil {
+				exitError(cmd, err)
+			}
+		}
+		addrs := keystore.Addresses(*secret)
+		printOutput(cmd, addrs, func() {
+			printEthAddresses(addrs)
+			if len(args) > 0 {
+				fmt.Printf("The key belongs to %s\n", args[0])
+			}
+		}, nil)
+	},
+}
+
+func printEthAddresses(addrs keystore.EthAddresses) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Eth Address\t%s\n", addrs.EthAddress)
+	_, _ = fmt.Fprintf(tw, "Fe Address\t%s\n", addrs.FeAddress)
+	_, _ = fmt.Fprintf(tw, "FA Address\t%s\n", addrs.FAAddress)
+	_ = tw.Flush()
+}
+
+// cachedEthKey is the key once it was loaded, so commands that sign many
+// times only ask for the password once
+var cachedEthKey *factom.EthSecret
+
+// ethKeyFromFlags loads the key of --ethkey or --ethkeystore. It returns false
+// if neither is set.
+func ethKeyFromFlags() (*factom.EthSecret, bool, error) {
+	if cachedEthKey != nil {
+		return cachedEthKey, true, nil
+	}
+
+	keyPath := os.ExpandEnv(viper.GetString(config.EthKey))
+	keystorePath := os.ExpandEnv(viper.GetString(config.EthKeystore))
+	var secret factom.EthSecret
+	switch {
+	case keyPath != "" && keystorePath != "":
+		return nil, false, fmt.Errorf("--ethkey and --ethkeystore can not be used together")
+	case keyPath != "":
+		data, err := ioutil.ReadFile(keyPath)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read the ethereum key: %s", err.Error())
+		}
+		if secret, err = keystore.ParseEthKey(string(data)); err != nil {
+			return nil, false, fmt.Errorf("invalid ethereum key in %s: %s", keyPath, err.Error())
+		}
+	case keystorePath != "":
+		data, err := ioutil.ReadFile(keystorePath)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read the ethereum keystore file: %s", err.Error())
+		}
+		password, ok := os.LookupEnv(EthKeystorePasswordEnv)
+		if !ok {
+			pw, err := readSecret("Ethereum keystore password: ")
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to read the password: %s", err.Error())
+			}
+			password = string(pw)
+		}
+		if secret, err = keystore.DecryptEthKeystore(data, password); err != nil {
+			return nil, false, fmt.Errorf("failed to decrypt %s: %s", keystorePath, err.Error())
+		}
+	default:
+		return nil, false, nil
+	}
+
+	cachedEthKey = &secret
+	return cachedEthKey, true, nil
+}
+
+// ethKeyMatches checks that the key belongs to the FA, Fe or FE address
+func ethKeyMatches(secret *factom.EthSecret, address string) error {
+	fa, err := underlyingFA(address)
+	if err != nil {
+		return fmt.Errorf("failed to parse input: %s", err.Error())
+	}
+	if fa != secret.FAAddress() {
+		return fmt.Errorf("the ethereum key %s belongs to %s, not %s", secret.EthAddress(), secret.FeAddress(), address)
+	}
+	return nil
+}
+
+// ethSigner returns the --ethkey or --ethkeystore key for the source, after
+// printing its addresses. It returns false if neither flag is set.
+func ethSigner(source string) (*factom.EthSecret, bool, error) {
+	secret, ok, err := ethKeyFromFlags()
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if err := ethKeyMatches(secret, source); err != nil {
+		return nil, true, err
+	}
+
+	addrs := keystore.Addresses(*secret)
+	if structuredOutput(rootCmd) {
+		return secret, true, nil
+	}
+	fmt.Printf("Signing with ethereum key %s (%s, %s)\n", addrs.EthAddress, addrs.FeAddress, addrs.FAAddress)
+	return secret, true, nil
+}
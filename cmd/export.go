@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pegnet/pegnetd/node/pegnet"
+	"github.com/pegnet/pegnetd/srv"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	exportHistory.Flags().String("format", "csv", "The output format, either 'csv' or 'jsonl'")
+	exportHistory.Flags().String("from", "", "Start of the window, either a height or a date (inclusive)")
+	exportHistory.Flags().String("to", "", "End of the window, either a height (inclusive) or a date (exclusive)")
+
+	export.AddCommand(exportHistory)
+	rootCmd.AddCommand(export)
+}
+
+var export = &cobra.Command{
+	Use:   "export <subcommand>",
+	Short: "Export pegnet data from the daemon for use in other tools.",
+}
+
+var exportHistory = &cobra.Command{
+	Use:   "history <address>",
+	Short: "Export the balance changes of an address",
+	Long: "Export the balance changes of an address, one row per change to the balance of an asset. " +
+		"Transfers have one row per output, conversions have a row for each asset with the rates and " +
+		"PIP-10 averages applied. The csv format uses decimal amounts and rates, jsonl writes the rows " +
+		"as returned by the export-history api. The --from and --to flags accept a height, a RFC3339 time, " +
+		"or a date in the form 2006-01-02.",
+	Example:          "pegnetd export history FA2CEc2JSkhuckEXy42K111MvM9bycUDkbrrHjd9bNkBfvPBSGKd --from 2020-01-01 --to 2021-01-01 > 2020.csv",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             CombineCobraArgs(CustomArgOrderValidationBuilder(true, ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe)), cobra.ExactArgs(1)),
+	Run: func(cmd *cobra.Command, args []string) {
+		params := srv.ParamsExportHistory{Address: args[0]}
+
+		format, _ := cmd.Flags().GetString("format")
+		if format != "csv" && format != "jsonl" {
+			exitErrorf(cmd, "unknown format '%s', must be 'csv' or 'jsonl'", format)
+		}
+
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		var err error
+		if params.FromHeight, params.FromTime, err = parseWindowBound(from); err != nil {
+			exitErrorf(cmd, "--from: %s", err)
+		}
+		if params.ToHeight, params.ToTime, err = parseWindowBound(to); err != nil {
+			exitErrorf(cmd, "--to: %s", err)
+		}
+
+		var write func(row pegnet.HistoryBalanceEffect) error
+		var flush func() error
+		switch format {
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			_ = w.Write([]string{"timestamp", "height", "executed", "txid", "action", "asset", "amount", "counterparty",
+				"fromasset", "toasset", "fromrate", "torate", "fromaverage", "toaverage"})
+			write = func(row pegnet.HistoryBalanceEffect) error {
+				return w.Write([]string{
+					row.Timestamp.UTC().Format(time.RFC3339),
+					strconv.FormatInt(row.Height, 10),
+					strconv.FormatInt(int64(row.Executed), 10),
+					row.TxID,
+					row.TxAction.String(),
+					row.Asset,
+					signedFactoshiToFactoid(row.Amount),
+					row.Counterparty,
+					row.FromAsset,
+					row.ToAsset,
+					optionalRate(row.FromRate),
+					optionalRate(row.ToRate),
+					optionalRate(row.FromAverage),
+					optionalRate(row.ToAverage),
+				})
+			}
+			flush = func() error {
+				w.Flush()
+				return w.Error()
+			}
+		case "jsonl":
+			enc := json.NewEncoder(os.Stdout)
+			write = func(row pegnet.HistoryBalanceEffect) error { return enc.Encode(row) }
+			flush = func() error { return nil }
+		}
+
+		cl := pegnetdClient()
+		// Rows are written a page at a time, so large histories don't
+		// have to be held in memory
+		for {
+			res, err := cl.ExportHistory(context.Background(), params)
+			if err != nil {
+				exitErrorf(cmd, "failed to make RPC request: %s", err)
+			}
+			for _, row := range res.Rows {
+				if err := write(row); err != nil {
+					exitError(cmd, err)
+				}
+			}
+			if res.NextOffset == 0 {
+				break
+			}
+			params.Offset = res.NextOffset
+		}
+
+		if err := flush(); err != nil {
+			exitError(cmd, err)
+		}
+	},
+}
+
+// parseWindowBound parses a window flag into either a height or an RFC3339
+// time. An empty flag is unbounded.
+func parseWindowBound(arg string) (int, string, error) {
+	if arg == "" {
+		return 0, "", nil
+	}
+	if height, err := strconv.Atoi(arg); err == nil {
+		if height <= 0 {
+			return 0, "", fmt.Errorf("height must be > 0")
+		}
+		return height, "", nil
+	}
+	if _, err := time.Parse(time.RFC3339, arg); err == nil {
+		return 0, arg, nil
+	}
+	if t, err := time.Parse("2006-01-02", arg); err == nil {
+		return 0, t.Format(time.RFC3339), nil
+	}
+	return 0, "", fmt.Errorf("'%s' is not a height, RFC3339 time, or date", arg)
+}
+
+// signedFactoshiToFactoid is FactoshiToFactoid for negative amounts
+func signedFactoshiToFactoid(i int64) string {
+	if i < 0 {
+		return "-" + FactoshiToFactoid(-i)
+	}
+	return FactoshiToFactoid(i)
+}
+
+// optionalRate leaves unset rates empty instead of printing 0
+func optionalRate(rate uint64) string {
+	if rate == 0 {
+		return ""
+	}
+	return FactoshiToFactoid(int64(rate))
+}
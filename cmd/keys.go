@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/pegnet/pegnetd/keystore"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// DefaultKeystore is the keystore used by the keys commands, and by the
+// transaction commands when --keystore is given without a directory
+const DefaultKeystore = "$HOME/.pegnetd/keys"
+
+// KeystorePasswordEnv is the environment variable that holds the keystore
+// password for unattended use
+const KeystorePasswordEnv = "PEGNETD_KEYSTORE_PASSWORD"
+
+func init() {
+	keysDelete.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+
+	keys.AddCommand(keysImport)
+	keys.AddCommand(keysGenerate)
+	keys.AddCommand(keysList)
+	keys.AddCommand(keysExport)
+	keys.AddCommand(keysDelete)
+	rootCmd.AddCommand(keys)
+}
+
+var keys = &cobra.Command{
+	Use:   "keys <subcommand>",
+	Short: "Manage the local encrypted keystore",
+	Long: "Manage the local keystore, which holds encrypted Fs, Es, and ethereum (RCD-e) keys so transactions " +
+		"can be signed without factom-walletd. Use --keystore on the transaction commands to sign from it. " +
+		"The keystore is in " + DefaultKeystore + " unless --keystore is set to another directory.\n\n" +
+		"The password is read from the terminal, or from the " + KeystorePasswordEnv + " environment variable.",
+}
+
+var keysImport = &cobra.Command{
+	Use:   "import",
+	Short: "Import an Fs address, Es address, or 0x ethereum secret",
+	Long: "Import a private key into the keystore. The key is read from the terminal, or from stdin if it is not a terminal. " +
+		"Ethereum keys can also be imported from a hex key file with --ethkey, or from a V3 keystore file with --ethkeystore.",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := mustOpenKeystore(cmd)
+		var secret []byte
+		if eth, ok, err := ethKeyFromFlags(); err != nil {
+			exitError(cmd, err)
+		} else if ok {
+			secret = []byte(eth.String())
+		} else if secret, err = readSecret("Private key: "); err != nil {
+			exitErrorf(cmd, "failed to read the key: %s", err)
+		}
+		password, err := newKeystorePassword()
+		if err != nil {
+			exitError(cmd, err)
+		}
+
+		key, err := store.Import(string(secret), password)
+		if err != nil {
+			exitErrorf(cmd, "failed to import the key: %s", err)
+		}
+		printKeys(cmd, []keystore.Key{key})
+	},
+}
+
+var keysGenerate = &cobra.Command{
+	Use:              "generate <fs|es|eth>",
+	Short:            "Generate a new key",
+	Long:             "Generate a new key: 'fs' for an FA address, 'es' for an EC address, or 'eth' for an ethereum linked Fe address.",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := mustOpenKeystore(cmd)
+		password, err := newKeystorePassword()
+		if err != nil {
+			exitError(cmd, err)
+		}
+
+		key, err := store.Generate(args[0], password)
+		if err != nil {
+			exitErrorf(cmd, "failed to generate the key: %s", err)
+		}
+		printKeys(cmd, []keystore.Key{key})
+	},
+}
+
+var keysList = &cobra.Command{
+	Use:              "list",
+	Short:            "List the addresses in the keystore",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := mustOpenKeystore(cmd)
+		list, err := store.List()
+		if err != nil {
+			exitErrorf(cmd, "failed to list the keys: %s", err)
+		}
+		printKeys(cmd, list)
+	},
+}
+
+var keysExport = &cobra.Command{
+	Use:              "export <address>",
+	Short:            "Print the private key of an address",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             CombineCobraArgs(CustomArgOrderValidationBuilder(true, ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe|ADD_EC))),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := mustOpenKeystore(cmd)
+		password, err := keystorePassword()
+		if err != nil {
+			exitError(cmd, err)
+		}
+		secret, err := store.Export(args[0], password)
+		if err != nil {
+			exitErrorf(cmd, "failed to export the key: %s", err)
+		}
+		printOutput(cmd, struct {
+			Secret string `json:"secret"`
+		}{secret}, func() { fmt.Println(secret) }, nil)
+	},
+}
+
+var keysDelete = &cobra.Command{
+	Use:              "delete <address>",
+	Short:            "Delete the key of an address",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             CombineCobraArgs(CustomArgOrderValidationBuilder(true, ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe|ADD_EC))),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := mustOpenKeystore(cmd)
+		key, err := store.Find(args[0])
+		if err != nil {
+			exitErrorf(cmd, "failed to find the key: %s", err)
+		}
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes && !confirm(fmt.Sprintf("Delete the key of %s? Funds are lost if there is no backup", key.Address)) {
+			printMessage(cmd, "Delete cancelled")
+			return
+		}
+		if err := store.Delete(key.Address); err != nil {
+			exitErrorf(cmd, "failed to delete the key: %s", err)
+		}
+		printMessage(cmd, "Deleted %s", key.Address)
+	},
+}
+
+func mustOpenKeystore(cmd *cobra.Command) *keystore.Store {
+	store, err := openKeystore()
+	if err != nil {
+		exitError(cmd, err)
+	}
+	return store
+}
+
+func printKeys(cmd *cobra.Command, list []keystore.Key) {
+	printOutput(cmd, list, func() {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Kind\tAddress\tFA Address\tEth Address\n")
+		for _, key := range list {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", key.Kind, key.Address, key.FAAddress, key.EthAddress)
+		}
+		_ = tw.Flush()
+	}, func() [][]string {
+		rows := [][]string{{"kind", "address", "faaddress", "ethaddress"}}
+		for _, key := range list {
+			rows = append(rows, []string{key.Kind, key.Address, key.FAAddress, key.EthAddress})
+		}
+		return rows
+	})
+}
+
+// keystoreDir returns the directory of --keystore, or "" if the transaction
+// commands should use factom-walletd
+func keystoreDir() string {
+	return os.ExpandEnv(viper.GetString(config.Keystore))
+}
+
+// openKeystore opens the --keystore directory, or the default keystore
+func openKeystore() (*keystore.Store, error) {
+	dir := keystoreDir()
+	if dir == "" {
+		dir = os.ExpandEnv(DefaultKeystore)
+	}
+	store, err := keystore.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the keystore: %s", err.Error())
+	}
+	return store, nil
+}
+
+// cachedPassword is the keystore password once it was entered, so commands
+// that sign many times only ask once
+var cachedPassword []byte
+
+// keystorePassword returns the password of the keystore
+func keystorePassword() ([]byte, error) {
+	if cachedPassword != nil {
+		return cachedPassword, nil
+	}
+	if env, ok := os.LookupEnv(KeystorePasswordEnv); ok {
+		cachedPassword = []byte(env)
+		return cachedPassword, nil
+	}
+
+	password, err := readSecret("Keystore password: ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the password: %s", err.Error())
+	}
+	cachedPassword = password
+	return cachedPassword, nil
+}
+
+// newKeystorePassword returns the password for a new key, which has to be
+// entered twice
+func newKeystorePassword() ([]byte, error) {
+	if _, ok := os.LookupEnv(KeystorePasswordEnv); ok {
+		return keystorePassword()
+	}
+
+	password, err := keystorePassword()
+	if err != nil {
+		return nil, err
+	}
+	again, err := readSecret("Repeat password: ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the password: %s", err.Error())
+	}
+	if !bytes.Equal(password, again) {
+		return nil, fmt.Errorf("the passwords do not match")
+	}
+	if len(password) == 0 {
+		return nil, fmt.Errorf("the password can not be empty")
+	}
+	return password, nil
+}
+
+// stdin is shared by everything that reads from stdin, so no buffered input
+// is lost between reads
+var stdin = bufio.NewReader(os.Stdin)
+
+// readSecret reads a line from the terminal without echo, or from stdin if it
+// is not a terminal
+func readSecret(prompt string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if terminal.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		defer fmt.Fprintln(os.Stderr)
+		return terminal.ReadPassword(fd)
+	}
+	line, err := stdin.ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// keystoreSigner returns the key of an FA, Fe, or FE address from the keystore
+func keystoreSigner(source string) (fat2.OfflineSigner, error) {
+	store, err := openKeystore()
+	if err != nil {
+		return nil, err
+	}
+	password, err := keystorePassword()
+	if err != nil {
+		return nil, err
+	}
+	priv, err := store.Signer(source, password)
+	if err != nil {
+		return nil, fmt.Errorf("[keystore] unable to get private key of %s: %s\n", source, err.Error())
+	}
+	return priv, nil
+}
+
+// keystoreEsAddress returns the key of an EC address from the keystore
+func keystoreEsAddress(payment string) (factom.EsAddress, error) {
+	store, err := openKeystore()
+	if err != nil {
+		return factom.EsAddress{}, err
+	}
+	password, err := keystorePassword()
+	if err != nil {
+		return factom.EsAddress{}, err
+	}
+	es, err := store.EsAddress(payment, password)
+	if err != nil {
+		return es, fmt.Errorf("[keystore] unable to get private key of %s: %s\n", payment, err.Error())
+	}
+	return es, nil
+}
@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/pegnet/pegnetd/node"
+	"github.com/pegnet/pegnetd/node/pegnet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	txBuild.PersistentFlags().String("out", "", "Write the unsigned transaction to this file instead of stdout")
+	txBuild.AddCommand(txBuildTransfer)
+	txBuild.AddCommand(txBuildConversion)
+	txCmd.AddCommand(txBuild)
+
+	txSign.Flags().String("keyfile", "", "Sign with the keys in this file instead of factom-walletd")
+	txSign.Flags().String("out", "", "Write the signed transaction to this file instead of overwriting the input")
+	txCmd.AddCommand(txSign)
+
+	txCmd.AddCommand(txSubmit)
+	rootCmd.AddCommand(txCmd)
+}
+
+var txCmd = &cobra.Command{
+	Use:   "tx <subcommand>",
+	Short: "Build, sign, and submit pegnet transactions in separate steps",
+	Long: "Build, sign, and submit pegnet transactions in separate steps, so the private keys can stay " +
+		"on an offline machine. 'tx build' creates an unsigned transaction file on a machine connected to " +
+		"pegnetd, 'tx sign' adds the signatures and needs no network access when a key file is used, and " +
+		"'tx submit' pays for and submits the entry.\n\n" +
+		"The transaction file is json, see the README for the format. The transaction has to be submitted " +
+		"within 12 hours of being built.",
+}
+
+var txBuild = &cobra.Command{
+	Use:   "build <subcommand>",
+	Short: "Build an unsigned transaction file",
+}
+
+var txBuildTransfer = &cobra.Command{
+	Use:   "transfer <FA-SOURCE> <ASSET> <AMOUNT> <FA-DESTINATION>",
+	Short: "Build an unsigned pegnet transaction",
+	Example: "pegnetd tx build transfer FA33kNzXwUt3cn4tLR56kyHEAryazAGPuMC6GjUubSbwrrNv8e7t PEG 200 " +
+		"FA32xV6SoPBSbAZAVyuiHWwyoMYhnSyMmAHZfK29H8dx7bJXFLja --out tx.json",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args: CombineCobraArgs(
+		CustomArgOrderValidationBuilder(
+			true,
+			ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe),
+			ArgValidatorAssetOrP,
+			ArgValidatorFCTAmount,
+			ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe)),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		cl := node.FactomClientFromConfig(viper.GetViper())
+		source, asset, amt, dest := args[0], args[1], args[2], args[3]
+
+		if err := addressRules(source, dest); err != nil {
+			exitError(cmd, err)
+		}
+
+		var trans fat2.Transaction
+		if err := setTransactionInput(&trans, cl, source, asset, amt); err != nil {
+			exitError(cmd, err)
+		}
+		if err := setTransferOutput(&trans, cl, dest, amt); err != nil {
+			exitError(cmd, err)
+		}
+
+		buildOfflineBatch(cmd, source, trans)
+		printFeWarning(cmd, source, dest)
+	},
+}
+
+var txBuildConversion = &cobra.Command{
+	Use:              "conversion <FA-SOURCE> <SRC-ASSET> <AMOUNT> <DEST-ASSET>",
+	Short:            "Build an unsigned pegnet conversion",
+	Example:          "pegnetd tx build conversion FA32xV6SoPBSbAZAVyuiHWwyoMYhnSyMmAHZfK29H8dx7bJXFLja pFCT 100 pUSD --out tx.json",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args: CombineCobraArgs(
+		CustomArgOrderValidationBuilder(
+			true,
+			ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe),
+			ArgValidatorAssetOrP,
+			ArgValidatorFCTAmount,
+			ArgValidatorAssetOrP),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		cl := node.FactomClientFromConfig(viper.GetViper())
+		source, srcAsset, amt, destAsset := args[0], args[1], args[2], args[3]
+
+		if err := conversionAllowed(destAsset); err != nil {
+			exitError(cmd, err)
+		}
+
+		var trans fat2.Transaction
+		if err := setTransactionInput(&trans, cl, source, srcAsset, amt); err != nil {
+			exitError(cmd, err)
+		}
+		if trans.Conversion, err = ticker(destAsset); err != nil {
+			exitError(cmd, fmt.Errorf("invalid ticker type"))
+		}
+
+		buildOfflineBatch(cmd, source, trans)
+		printFeWarning(cmd, source)
+	},
+}
+
+var txSign = &cobra.Command{
+	Use:   "sign <file>",
+	Short: "Sign a transaction file",
+	Long: "Sign a transaction file built with 'tx build'. The keys are fetched from factom-walletd, from the " +
+		"keystore with --keystore, or read from a key file with --keyfile. A key file has one private key per line, either an Fs address or a " +
+		"0x prefixed ethereum secret for Fe/FE addresses. Empty lines and lines starting with '#' are ignored. " +
+		"Fe/FE inputs can also be signed with a hex private key file with --ethkey, or an ethereum V3 keystore " +
+		"file with --ethkeystore. Signing with a key file does not need a connection to any daemon.",
+	Example:          "pegnetd tx sign tx.json --keyfile keys.txt",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.ExactArgs(1),
+	Run:              signOfflineBatch,
+}
+
+var txSubmit = &cobra.Command{
+	Use:   "submit <ECAddress> <file>",
+	Short: "Submit a signed transaction file",
+	Long: "Submit a transaction file signed with 'tx sign'. The entry is paid for with the EC address, " +
+		"whose private key is fetched from factom-walletd, or from the keystore with --keystore. An Es address " +
+		"can be given instead.",
+	Example:          "pegnetd tx submit EC3eX8VxGH64Xv3NFd9g4Y7PxSMnH3EGz5jQQrrQS8VZGnv4JY2K tx.json",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.ExactArgs(2),
+	Run:              submitOfflineBatch,
+}
+
+// buildOfflineBatch writes the unsigned batch of a single transaction
+func buildOfflineBatch(cmd *cobra.Command, source string, trans fat2.Transaction) {
+	var batch fat2.TransactionBatch
+	batch.Version = 1
+	batch.Transactions = []fat2.Transaction{trans}
+
+	o, err := fat2.NewOfflineBatch(&config.TransactionChain, batch, []string{source})
+	if err != nil {
+		exitErrorf(cmd, "invalid tx: %s", err)
+	}
+
+	path, _ := cmd.Flags().GetString("out")
+	if err := writeOfflineBatch(path, o); err != nil {
+		exitErrorf(cmd, "failed to write transaction: %s", err)
+	}
+	if path != "" {
+		printMessage(cmd, "Wrote unsigned transaction to %s", path)
+	}
+}
+
+// readOfflineBatch reads a transaction file
+func readOfflineBatch(path string) (*fat2.OfflineBatch, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction: %s", err.Error())
+	}
+	var o fat2.OfflineBatch
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %s", err.Error())
+	}
+	return &o, nil
+}
+
+// writeOfflineBatch writes a transaction file, or to stdout if the path is
+// empty
+func writeOfflineBatch(path string, o *fat2.OfflineBatch) error {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readKeyFile parses a file of Fs addresses and 0x prefixed eth secrets
+func readKeyFile(path string) ([]fat2.OfflineSigner, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var signers []fat2.OfflineSigner
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		key := strings.TrimSpace(scanner.Text())
+		switch {
+		case key == "" || strings.HasPrefix(key, "#"):
+		case strings.HasPrefix(key, "0x"):
+			secret, err := factom.NewEthSecret(key)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err.Error())
+			}
+			signers = append(signers, secret)
+		default:
+			fs, err := factom.NewFsAddress(key)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err.Error())
+			}
+			signers = append(signers, fs)
+		}
+	}
+	return signers, scanner.Err()
+}
+
+// printOfflineBatch prints what is being signed
+func printOfflineBatch(o *fat2.OfflineBatch, batch *fat2.TransactionBatch) {
+	fmt.Printf("Transaction chain: %s\n", o.ChainID)
+	for i, tx := range batch.Transactions {
+		fmt.Printf("Transaction %d:\n", i)
+		fmt.Printf("\t%10s: %s %s %s\n", "Input", tx.Input.Address, FactoshiToFactoid(int64(tx.Input.Amount)), tx.Input.Type)
+		if tx.IsConversion() {
+			fmt.Printf("\t%10s: %s\n", "Convert to", tx.Conversion)
+		}
+		for _, out := range tx.Transfers {
+			fmt.Printf("\t%10s: %s %s %s\n", "Output", out.Address, FactoshiToFactoid(int64(out.Amount)), tx.Input.Type)
+		}
+	}
+}
+
+// signOfflineBatch signs a transaction file with a key file or walletd
+func signOfflineBatch(cmd *cobra.Command, args []string) {
+	o, err := readOfflineBatch(args[0])
+	if err != nil {
+		exitError(cmd, err)
+	}
+	if len(o.Transactions()) == 0 {
+		exitError(cmd, fmt.Errorf("the transaction file has no transactions"))
+	}
+	batch, err := o.TransactionBatch()
+	if err != nil {
+		exitErrorf(cmd, "invalid transaction file: %s", err)
+	}
+	if !structuredOutput(cmd) {
+		printOfflineBatch(o, batch)
+	}
+
+	var signers []fat2.OfflineSigner
+	if path, _ := cmd.Flags().GetString("keyfile"); path != "" {
+		if signers, err = readKeyFile(path); err != nil {
+			exitErrorf(cmd, "failed to read key file: %s", err)
+		}
+	} else {
+		cl := node.FactomClientFromConfig(viper.GetViper())
+		for _, addr := range o.Unsigned() {
+			priv, err := signerFor(addr, cl)
+			if err != nil {
+				exitError(cmd, err)
+			}
+			signers = append(signers, priv)
+		}
+	}
+
+	signed := 0
+	for _, signer := range signers {
+		ok, err := o.Sign(signer)
+		if err != nil {
+			exitErrorf(cmd, "failed to sign: %s", err)
+		}
+		if ok {
+			signed++
+		}
+	}
+	if signed == 0 {
+		exitError(cmd, fmt.Errorf("none of the keys belong to an input of the transaction"))
+	}
+
+	missing := o.Unsigned()
+	if len(missing) == 0 {
+		// Check the signatures before anything leaves this machine
+		if _, err := o.Entry(); err != nil {
+			exitErrorf(cmd, "signed transaction is invalid: %s", err)
+		}
+	}
+
+	path, _ := cmd.Flags().GetString("out")
+	if path == "" {
+		path = args[0]
+	}
+	if err := writeOfflineBatch(path, o); err != nil {
+		exitErrorf(cmd, "failed to write transaction: %s", err)
+	}
+
+	res := ResultSigned{Path: path, Signed: signed, Unsigned: missing}
+	printOutput(cmd, res, func() {
+		fmt.Printf("Added %d signature(s), wrote %s\n", signed, path)
+		if len(missing) > 0 {
+			fmt.Printf("Still needs to be signed by: %s\n", strings.Join(missing, ", "))
+		}
+	}, nil)
+}
+
+// ResultSigned is the output of signing a transaction file
+type ResultSigned struct {
+	Path     string   `json:"path"`
+	Signed   int      `json:"signed"`
+	Unsigned []string `json:"unsigned"`
+}
+
+// submitOfflineBatch pays for and submits a signed transaction file
+func submitOfflineBatch(cmd *cobra.Command, args []string) {
+	cl := node.FactomClientFromConfig(viper.GetViper())
+	payment, path := args[0], args[1]
+
+	o, err := readOfflineBatch(path)
+	if err != nil {
+		exitError(cmd, err)
+	}
+	if len(o.Transactions()) == 0 {
+		exitError(cmd, fmt.Errorf("the transaction file has no transactions"))
+	}
+	entry, err := o.Entry()
+	if err != nil {
+		exitErrorf(cmd, "invalid tx: %s", err)
+	}
+	if *entry.ChainID != config.TransactionChain {
+		exitErrorf(cmd, "transaction is for chain %s, expected %s", entry.ChainID, config.TransactionChain)
+	}
+
+	var es factom.EsAddress
+	if strings.HasPrefix(payment, "Es") {
+		if es, err = factom.NewEsAddress(payment); err != nil {
+			exitErrorf(cmd, "failed to parse input: %s", err)
+		}
+	} else {
+		ec, err := factom.NewECAddress(payment)
+		if err != nil {
+			exitErrorf(cmd, "failed to parse input: %s", err)
+		}
+		if es, err = esAddressFor(ec, cl); err != nil {
+			exitError(cmd, err)
+		}
+	}
+
+	bal, err := es.ECAddress().GetBalance(nil, cl)
+	if err != nil {
+		exitErrorf(cmd, "failed to get ec balance: %s", err)
+	}
+	if cost, err := entry.Cost(); err != nil || uint64(cost) > bal {
+		exitError(cmd, fmt.Errorf("not enough ec balance for the transaction"))
+	}
+
+	commit, err := entry.ComposeCreate(nil, cl, es)
+	if err != nil {
+		exitErrorf(cmd, "failed to submit entry: %s", err)
+	}
+
+	res := ResultSent{EntryHash: entry.Hash.String(), Commit: commit.String()}
+	for i := range o.Transactions() {
+		res.TxIDs = append(res.TxIDs, pegnet.FormatTxID(i, entry.Hash.String()))
+	}
+	printOutput(cmd, res, func() {
+		fmt.Printf("transaction sent:\n")
+		fmt.Printf("\t%10s: %s\n", "EntryHash", entry.Hash)
+		fmt.Printf("\t%10s: %s\n", "Commit", commit)
+		for _, txid := range res.TxIDs {
+			fmt.Printf("\t%10s: %s\n", "TxID", txid)
+		}
+	}, nil)
+}
@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+
+	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/pegnet/pegnetd/srv"
+	"github.com/spf13/cobra"
+)
+
+// The formats of --output
+const (
+	// OutputTable is the human readable output, which is the default
+	OutputTable = "table"
+	// OutputJSON prints the result types of the RPC API as json
+	OutputJSON = "json"
+	// OutputCSV prints the result types of the RPC API as csv rows
+	OutputCSV = "csv"
+)
+
+// The exit codes of the cli. RPC errors of pegnetd exit with the code of
+// their srv error, see exitCodes.
+const (
+	ExitError       = 1 // A local error, like invalid input
+	ExitUsage       = 2 // Invalid arguments or flags
+	ExitUnreachable = 3 // The daemon could not be reached
+	ExitRPCError    = 4 // An RPC error that has no code of its own
+)
+
+// exitCodes maps the RPC error codes to exit codes
+var exitCodes = map[jrpc.ErrorCode]int{
+	jrpc.ErrorCodeInvalidParams:       5,
+	jrpc.ErrorCodeMethodNotFound:      6,
+	jrpc.ErrorCodeInternal:            7,
+	srv.ErrorTokenNotFound.Code:       10,
+	srv.ErrorTransactionNotFound.Code: 13,
+	srv.ErrorInvalidTransaction.Code:  14,
+	srv.ErrorTokenSyncing.Code:        15,
+	srv.ErrorNoEC.Code:                16,
+	srv.ErrorPendingDisabled.Code:     17,
+	srv.ErrorAddressNotFound.Code:     18,
+	srv.ErrorNotFound.Code:            19,
+	srv.ErrorUnauthorized.Code:        20,
+	srv.ErrorRateLimited.Code:         21,
+	srv.ErrorTimeout.Code:             22,
+}
+
+// outputFormat returns the --output format of the command
+func outputFormat(cmd *cobra.Command) string {
+	// The persistent flag is shared by all commands, and also set if the
+	// command failed to parse its arguments
+	format, _ := cmd.Root().PersistentFlags().GetString("output")
+	if format == "" {
+		return OutputTable
+	}
+	return format
+}
+
+// structuredOutput is true if the command prints json or csv
+func structuredOutput(cmd *cobra.Command) bool {
+	return outputFormat(cmd) != OutputTable
+}
+
+// validOutputFormat checks the --output flag
+func validOutputFormat(format string) error {
+	switch format {
+	case "", OutputTable, OutputJSON, OutputCSV:
+		return nil
+	}
+	return fmt.Errorf("unknown output format '%s', must be one of %s, %s, %s", format, OutputTable, OutputJSON, OutputCSV)
+}
+
+// printOutput prints the result in the --output format. The table func prints
+// the human readable form. The rows func returns the csv rows, the first row
+// being the header. If rows is nil, the rows are derived from the json of the
+// result.
+func printOutput(cmd *cobra.Command, result interface{}, table func(), rows func() [][]string) {
+	switch outputFormat(cmd) {
+	case OutputJSON:
+		data, err := json.Marshal(result)
+		if err != nil {
+			exitError(cmd, err)
+		}
+		fmt.Println(string(data))
+	case OutputCSV:
+		var records [][]string
+		if rows != nil {
+			records = rows()
+		} else {
+			var err error
+			if records, err = jsonRows(result); err != nil {
+				exitError(cmd, err)
+			}
+		}
+		w := csv.NewWriter(os.Stdout)
+		_ = w.WriteAll(records)
+		if err := w.Error(); err != nil {
+			exitError(cmd, err)
+		}
+	default:
+		table()
+	}
+}
+
+// printMessage prints the message of a command that has no other result. With
+// json the message is printed as {"message": ...}.
+func printMessage(cmd *cobra.Command, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	printOutput(cmd, ResultMessage{Message: msg}, func() { fmt.Println(msg) }, nil)
+}
+
+// ResultMessage is the output of printMessage
+type ResultMessage struct {
+	Message string `json:"message"`
+}
+
+// tickerMapRows are the csv rows of a ticker map, sorted by ticker
+func tickerMapRows(m srv.ResultPegnetTickerMap, column string) [][]string {
+	tickers := make([]fat2.PTicker, 0, len(m))
+	for ticker := range m {
+		tickers = append(tickers, ticker)
+	}
+	sort.Slice(tickers, func(i, j int) bool { return tickers[i] < tickers[j] })
+
+	rows := [][]string{{"asset", column}}
+	for _, ticker := range tickers {
+		rows = append(rows, []string{ticker.String(), strconv.FormatUint(m[ticker], 10)})
+	}
+	return rows
+}
+
+// ResultSent is the output of the commands that submit an entry
+type ResultSent struct {
+	EntryHash string   `json:"entryhash"`
+	Commit    string   `json:"commit"`
+	TxIDs     []string `json:"txids,omitempty"`
+}
+
+// ResultProperties is the output of the properties command
+type ResultProperties struct {
+	CLI     srv.PegnetdProperties `json:"cli"`
+	Pegnetd srv.PegnetdProperties `json:"pegnetd"`
+	Factomd struct {
+		FactomdVersion    string `json:"factomdversion"`
+		FactomdAPIVersion string `json:"factomdapiversion"`
+	} `json:"factomd"`
+	Walletd struct {
+		WalletdVersion    string `json:"walletversion"`
+		WalletdAPIVersion string `json:"walletapiversion"`
+	} `json:"walletd"`
+}
+
+// jsonRows derives csv rows from the json of a result. An array of objects
+// has one row per object and a column per key. An object has one row per key.
+// Nested values are written as json.
+func jsonRows(result interface{}) ([][]string, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	switch v := v.(type) {
+	case []interface{}:
+		var keys []string
+		seen := make(map[string]bool)
+		for _, elem := range v {
+			obj, ok := elem.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for key := range obj {
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+		sort.Strings(keys)
+		if len(keys) == 0 {
+			rows := [][]string{{"value"}}
+			for _, elem := range v {
+				rows = append(rows, []string{csvCell(elem)})
+			}
+			return rows, nil
+		}
+
+		rows := [][]string{keys}
+		for _, elem := range v {
+			obj, _ := elem.(map[string]interface{})
+			row := make([]string, len(keys))
+			for i, key := range keys {
+				row[i] = csvCell(obj[key])
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		rows := [][]string{{"key", "value"}}
+		for _, key := range keys {
+			rows = append(rows, []string{key, csvCell(v[key])})
+		}
+		return rows, nil
+	default:
+		return [][]string{{"value"}, {csvCell(v)}}, nil
+	}
+}
+
+func csvCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprintf("%t", v)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// ResultError is the structured error printed with --output json or csv
+type ResultError struct {
+	Error struct {
+		// Code is the RPC error code, if the error came from pegnetd
+		Code     jrpc.ErrorCode `json:"code,omitempty"`
+		Message  string         `json:"message"`
+		Data     interface{}    `json:"data,omitempty"`
+		ExitCode int            `json:"exitcode"`
+	} `json:"error"`
+}
+
+// exitError prints the error and exits with its exit code. With --output
+// json or csv the error is printed as a ResultError.
+func exitError(cmd *cobra.Command, err error) {
+	var res ResultError
+	res.Error.Message = err.Error()
+	res.Error.ExitCode = exitCode(err)
+	var jerr jrpc.Error
+	if errors.As(err, &jerr) {
+		res.Error.Code = jerr.Code
+		res.Error.Data = jerr.Data
+		switch err.(type) {
+		case jrpc.Error, srv.RPCError:
+			res.Error.Message = jerr.Message
+		}
+	}
+
+	if !structuredOutput(cmd) {
+		cmd.PrintErrln(err.Error())
+		os.Exit(res.Error.ExitCode)
+	}
+	data, _ := json.Marshal(res)
+	fmt.Fprintln(os.Stderr, string(data))
+	os.Exit(res.Error.ExitCode)
+}
+
+// exitErrorf is exitError with a formatted message. The exit code is the one
+// of the first error in args.
+func exitErrorf(cmd *cobra.Command, format string, args ...interface{}) {
+	err := fmt.Errorf(format, args...)
+	for _, arg := range args {
+		if cause, ok := arg.(error); ok {
+			err = wrappedError{msg: err.Error(), cause: cause}
+			break
+		}
+	}
+	exitError(cmd, err)
+}
+
+// wrappedError keeps the cause of a formatted error for its exit code
+type wrappedError struct {
+	msg   string
+	cause error
+}
+
+func (e wrappedError) Error() string { return e.msg }
+func (e wrappedError) Unwrap() error { return e.cause }
+
+// usageError is an error in the arguments or flags of a command
+type usageError struct {
+	error
+}
+
+func (e usageError) Unwrap() error { return e.error }
+
+// exitCode returns the exit code of an error
+func exitCode(err error) int {
+	var usage usageError
+	if errors.As(err, &usage) {
+		return ExitUsage
+	}
+	var jerr jrpc.Error
+	if errors.As(err, &jerr) {
+		if code, ok := exitCodes[jerr.Code]; ok {
+			return code
+		}
+		return ExitRPCError
+	}
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return ExitUnreachable
+	}
+	return ExitError
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/pegnet/pegnetd/node"
+	"github.com/pegnet/pegnetd/node/pegnet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	payout.Flags().String("file", "", "The csv file of '<address>,<amount>' lines to pay out")
+	payout.Flags().String("receipt", "", "Write the receipt to this file (default <file>.receipt.csv)")
+	payout.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
+	_ = payout.MarkFlagRequired("file")
+	rootCmd.AddCommand(payout)
+}
+
+// payoutExtIDsLen is the most the ExtIDs of a single input batch can take up:
+// the timestamp salt, and the RCD and signature of an RCD-e input, each with
+// a two byte length prefix
+const payoutExtIDsLen = 2 + 11 + 2 + 65 + 2 + 65
+
+var payout = &cobra.Command{
+	Use:   "payout <ECAddress> <FA-SOURCE> <ASSET> --file payouts.csv",
+	Short: "Pay out an asset to many addresses",
+	Long: "Pay out an asset to every address in a csv file. Each line of the file is '<address>,<amount>', " +
+		"a header line and lines starting with '#' are ignored. The outputs are packed into as few entries " +
+		"as the entry size limit allows, so paying many addresses costs a fraction of sending them one by one.\n\n" +
+		"The receipt is a csv file with the transaction id of every output. If an entry fails to submit, " +
+		"the receipt lists the outputs that were sent before the failure.",
+	Example:          "pegnetd payout EC3eX8VxGH64Xv3NFd9g4Y7PxSMnH3EGz5jQQrrQS8VZGnv4JY2K FA33kNzXwUt3cn4tLR56kyHEAryazAGPuMC6GjUubSbwrrNv8e7t PEG --file payouts.csv",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args: CombineCobraArgs(
+		CustomArgOrderValidationBuilder(
+			true,
+			ArgValidatorECAddress,
+			ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe),
+			ArgValidatorAssetOrP),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		cl := node.FactomClientFromConfig(viper.GetViper())
+		payment, source, asset := args[0], args[1], args[2]
+
+		path, _ := cmd.Flags().GetString("file")
+		outputs, err := readPayouts(cmd, source, path)
+		if err != nil {
+			exitErrorf(cmd, "invalid payout file: %s", err)
+		}
+		if len(outputs) == 0 {
+			exitError(cmd, fmt.Errorf("the payout file has no outputs"))
+		}
+
+		// The input amount is set per batch, the balance check is on the total
+		var total uint64
+		for _, out := range outputs {
+			total += out.Amount
+		}
+		var trans fat2.Transaction
+		if err := setTransactionInput(&trans, cl, source, asset, FactoshiToFactoid(int64(total))); err != nil {
+			exitError(cmd, err)
+		}
+
+		batches, err := packPayouts(trans.Input, outputs)
+		if err != nil {
+			exitError(cmd, err)
+		}
+
+		var cost uint64
+		for _, b := range batches {
+			content, _ := json.Marshal(b)
+			c, err := factom.EntryCost(factom.EntryHeaderLen+len(content)+payoutExtIDsLen, false)
+			if err != nil {
+				exitError(cmd, err)
+			}
+			cost += uint64(c)
+		}
+
+		ec, _ := factom.NewECAddress(payment) // checked by the arg validator
+		bal, err := ec.GetBalance(nil, cl)
+		if err != nil {
+			exitErrorf(cmd, "failed to get ec balance: %s", err)
+		}
+
+		if !structuredOutput(cmd) {
+			fmt.Printf("Paying %s %s to %d address(es) from %s\n", FactoshiToFactoid(int64(total)), trans.Input.Type, len(outputs), source)
+			fmt.Printf("\t%10s: %d\n", "Entries", len(batches))
+			fmt.Printf("\t%10s: %d EC (balance %d EC)\n", "Cost", cost, bal)
+		}
+		if cost > bal {
+			exitError(cmd, fmt.Errorf("not enough ec balance for the payout"))
+		}
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes && !confirm("Send the payout?") {
+			printMessage(cmd, "Payout cancelled")
+			return
+		}
+
+		receipt, _ := cmd.Flags().GetString("receipt")
+		if receipt == "" {
+			receipt = path + ".receipt.csv"
+		}
+		f, err := os.Create(receipt)
+		if err != nil {
+			exitErrorf(cmd, "failed to create receipt: %s", err)
+		}
+		defer f.Close()
+		w := csv.NewWriter(f)
+		_ = w.Write([]string{"address", "amount", "txid", "entryhash"})
+
+		names := make(map[factom.FAAddress]string)
+		for _, out := range outputs {
+			names[out.Address] = out.Human
+		}
+		for i := range batches {
+			err, _, reveal := signAndSendBatch(source, &batches[i], cl, payment)
+			if err != nil {
+				w.Flush()
+				exitErrorf(cmd, "failed to send entry %d of %d: %s\nthe outputs that were sent are in %s", i+1, len(batches), err, receipt)
+			}
+			for j, tx := range batches[i].Transactions {
+				txid := pegnet.FormatTxID(j, reveal.String())
+				for _, out := range tx.Transfers {
+					_ = w.Write([]string{names[out.Address], FactoshiToFactoid(int64(out.Amount)), txid, reveal.String()})
+				}
+			}
+			w.Flush()
+			if !structuredOutput(cmd) {
+				fmt.Printf("Sent entry %d of %d: %s\n", i+1, len(batches), reveal)
+			}
+		}
+		if err := w.Error(); err != nil {
+			exitErrorf(cmd, "failed to write receipt: %s", err)
+		}
+		printMessage(cmd, "Wrote receipt to %s", receipt)
+	},
+}
+
+// payoutOutput is a single line of the payout file
+type payoutOutput struct {
+	fat2.AddressAmountTuple
+	// The address as written in the file, which can be an Fe/FE address
+	Human string
+}
+
+// readPayouts parses and validates the payout file
+func readPayouts(cmd *cobra.Command, source, path string) ([]payoutOutput, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(bufio.NewReader(f))
+	r.Comment = '#'
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+
+	var outputs []payoutOutput
+	seen := make(map[factom.FAAddress]bool)
+	for row := 1; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		addr, amt := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if len(outputs) == 0 && strings.ToLower(addr) == "address" {
+			continue // header
+		}
+
+		if err := ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe)(cmd, addr); err != nil {
+			return nil, fmt.Errorf("row %d: %s", row, err.Error())
+		}
+		if err := ArgValidatorFCTAmount(cmd, amt); err != nil {
+			return nil, fmt.Errorf("row %d: invalid amount: %s", row, err.Error())
+		}
+		if err := addressRules(source, addr); err != nil {
+			return nil, fmt.Errorf("row %d: %s", row, err.Error())
+		}
+
+		var out payoutOutput
+		out.Human = addr
+		out.Address, _ = underlyingFA(addr)
+		out.Amount, _ = FactoidToFactoshi(amt)
+		if out.Amount == 0 {
+			return nil, fmt.Errorf("row %d: amount must be greater than 0", row)
+		}
+		if seen[out.Address] {
+			return nil, fmt.Errorf("row %d: %s is listed more than once", row, addr)
+		}
+		seen[out.Address] = true
+		outputs = append(outputs, out)
+	}
+	return outputs, nil
+}
+
+// packPayouts packs the outputs into as few batches as possible. Every batch
+// has a single transaction from the input, the input amount is set to the sum
+// of its outputs.
+func packPayouts(input fat2.TypedAddressAmountTuple, outputs []payoutOutput) ([]fat2.TransactionBatch, error) {
+	maxContent := factom.EntryMaxDataLen - payoutExtIDsLen
+
+	// The size of a batch with one output, with room for the largest amount
+	sized := input
+	sized.Amount = math.MaxUint64
+	base := func(out fat2.AddressAmountTuple) int {
+		out.Amount = math.MaxUint64
+		data, _ := json.Marshal(fat2.TransactionBatch{Version: 1, Transactions: []fat2.Transaction{{
+			Input: sized, Transfers: []fat2.AddressAmountTuple{out},
+		}}})
+		return len(data)
+	}
+	size := func(out fat2.AddressAmountTuple) int {
+		data, _ := json.Marshal(out)
+		return len(data) + 1 // the comma
+	}
+
+	var batches []fat2.TransactionBatch
+	var tx *fat2.Transaction
+	var used int
+	for _, out := range outputs {
+		if tx != nil && used+size(out.AddressAmountTuple) <= maxContent {
+			tx.Transfers = append(tx.Transfers, out.AddressAmountTuple)
+			tx.Input.Amount += out.Amount
+			used += size(out.AddressAmountTuple)
+			continue
+		}
+
+		used = base(out.AddressAmountTuple)
+		if used > maxContent {
+			return nil, fmt.Errorf("output to %s does not fit in an entry", out.Human)
+		}
+		in := input
+		in.Amount = out.Amount
+		batches = append(batches, fat2.TransactionBatch{Version: 1, Transactions: []fat2.Transaction{{
+			Input: in, Transfers: []fat2.AddressAmountTuple{out.AddressAmountTuple},
+		}}})
+		tx = &batches[len(batches)-1].Transactions[0]
+	}
+	return batches, nil
+}
+
+// confirm asks the user a yes/no question on stdin
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, _ := stdin.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
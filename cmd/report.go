@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pegnet/pegnetd/srv"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	reportPNL.Flags().String("method", "fifo", "The cost basis method, either 'fifo' or 'avg'")
+	reportPNL.Flags().String("currency", "pUSD", "The asset to value the report in")
+	reportPNL.Flags().Int("year", 0, "Only report gains realized in this year (UTC)")
+	reportPNL.Flags().String("lots", "", "Write the itemized lot list as csv to this file")
+	reportPNL.Flags().Bool("raw", false, "Print the full json data")
+
+	report.AddCommand(reportPNL)
+	rootCmd.AddCommand(report)
+}
+
+var report = &cobra.Command{
+	Use:   "report <subcommand>",
+	Short: "Generate reports from the pegnet data of the daemon.",
+}
+
+var reportPNL = &cobra.Command{
+	Use:   "pnl <address>",
+	Short: "Report the cost basis and profit and loss of an address",
+	Long: "Report the cost basis and realized and unrealized gains of every asset an address holds or held. " +
+		"Every acquisition (transfer in, conversion, reward) and disposal (transfer out, conversion) is valued " +
+		"at the rates of the height it was executed at. Assets without a rate at that height, like PEG before " +
+		"it was priced, are valued at 0. With --year, only the gains realized in that year are reported and " +
+		"the unrealized gains are valued at the end of the year.",
+	Example:          "pegnetd report pnl FA2CEc2JSkhuckEXy42K111MvM9bycUDkbrrHjd9bNkBfvPBSGKd --year 2020 --lots lots.csv",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             CombineCobraArgs(CustomArgOrderValidationBuilder(true, ArgValidatorAddress(ADD_FA|ADD_FE|ADD_Fe)), cobra.ExactArgs(1)),
+	Run: func(cmd *cobra.Command, args []string) {
+		var params srv.ParamsGetPNLReport
+		params.Address = args[0]
+		params.Method, _ = cmd.Flags().GetString("method")
+		params.Currency, _ = cmd.Flags().GetString("currency")
+		params.Currency = toP(params.Currency)
+		params.Year, _ = cmd.Flags().GetInt("year")
+
+		cl := pegnetdClient()
+		res, err := cl.GetPNLReport(context.Background(), params)
+		if err != nil {
+			exitErrorf(cmd, "failed to make RPC request: %s", err)
+		}
+
+		if raw, _ := cmd.Flags().GetBool("raw"); raw || structuredOutput(cmd) {
+			printOutput(cmd, res, func() {
+				data, _ := json.Marshal(res)
+				fmt.Println(string(data))
+			}, func() [][]string {
+				rows := [][]string{{"asset", "balance", "cost", "value", "realized", "unrealized"}}
+				for _, a := range res.Assets {
+					rows = append(rows, []string{a.Asset, strconv.FormatInt(a.Balance, 10), strconv.FormatInt(a.Cost, 10),
+						strconv.FormatInt(a.Value, 10), strconv.FormatInt(a.Realized, 10), strconv.FormatInt(a.Unrealized, 10)})
+				}
+				return rows
+			})
+			if path, _ := cmd.Flags().GetString("lots"); path != "" {
+				if err := writeLots(path, res); err != nil {
+					exitErrorf(cmd, "failed to write lots: %s", err)
+				}
+			}
+			return
+		}
+
+		fmt.Printf("Profit and loss in %s using %s, valued at height %d\n", res.Currency, res.Method, res.Height)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		_, _ = fmt.Fprintf(tw, "Asset\tBalance\tCost\tValue\tRealized\tUnrealized\t\n")
+		var realized, unrealized int64
+		for _, a := range res.Assets {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", a.Asset,
+				signedFactoshiToFactoid(a.Balance),
+				signedFactoshiToFactoid(a.Cost),
+				signedFactoshiToFactoid(a.Value),
+				signedFactoshiToFactoid(a.Realized),
+				signedFactoshiToFactoid(a.Unrealized))
+			realized += a.Realized
+			unrealized += a.Unrealized
+		}
+		_, _ = fmt.Fprintf(tw, "Total\t\t\t\t%s\t%s\t\n", signedFactoshiToFactoid(realized), signedFactoshiToFactoid(unrealized))
+		_ = tw.Flush()
+
+		path, _ := cmd.Flags().GetString("lots")
+		if path == "" {
+			return
+		}
+		if err := writeLots(path, res); err != nil {
+			exitErrorf(cmd, "failed to write lots: %s", err)
+		}
+		fmt.Printf("Wrote %d lots to %s\n", len(res.Lots), path)
+	},
+}
+
+func writeLots(path string, res srv.ResultGetPNLReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	optionalTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	optionalHeight := func(h int64) string {
+		if h == 0 {
+			return ""
+		}
+		return strconv.FormatInt(h, 10)
+	}
+
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"asset", "amount", "acquiredtxid", "acquiredheight", "acquiredtime",
+		"disposedtxid", "disposedheight", "disposedtime", "cost", "proceeds", "gain", "open"})
+	for _, l := range res.Lots {
+		_ = w.Write([]string{
+			l.Asset,
+			signedFactoshiToFactoid(l.Amount),
+			l.AcquiredTxID,
+			optionalHeight(l.AcquiredHeight),
+			optionalTime(l.AcquiredTime),
+			l.DisposedTxID,
+			optionalHeight(l.DisposedHeight),
+			optionalTime(l.DisposedTime),
+			signedFactoshiToFactoid(l.Cost),
+			signedFactoshiToFactoid(l.Proceeds),
+			signedFactoshiToFactoid(l.Gain),
+			strconv.FormatBool(l.Open),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -25,17 +25,25 @@ import (
 func init() {
 	rootCmd.PersistentFlags().String("log", "info", "Change the logging level. Can choose from 'trace', 'debug', 'info', 'warn', 'error', or 'fatal'")
 	rootCmd.PersistentFlags().StringP("network", "", "", "The network for PegNetD")
-	rootCmd.PersistentFlags().StringP("server", "s", "http://localhost:8088/v2", "The url to the factomd endpoint without a trailing slash")
+	rootCmd.PersistentFlags().StringP("server", "s", "http://localhost:8088/v2", "The url to the factomd endpoint without a trailing slash. Several comma separated endpoints fail over to each other")
 	rootCmd.PersistentFlags().StringP("wallet", "w", "http://localhost:8089/v2", "The url to the factomd-wallet endpoint without a trailing slash")
 	rootCmd.PersistentFlags().String("walletuser", "", "The username for Wallet RPC")
 	rootCmd.PersistentFlags().String("walletpassword", "", "The password for Wallet RPC")
 	rootCmd.PersistentFlags().StringP("pegnetd", "p", "http://localhost:8070", "The url to the pegnetd endpoint without a trailing slash")
+	rootCmd.PersistentFlags().String("pegnetduser", "", "The username for the pegnetd api")
+	rootCmd.PersistentFlags().String("pegnetdpassword", "", "The password for the pegnetd api")
+	rootCmd.PersistentFlags().String("pegnetdtoken", "", "The bearer token for the pegnetd api")
 	rootCmd.PersistentFlags().String("api", "8070", "Change the api listening port for the api")
 	rootCmd.PersistentFlags().String("config", "", "Optional file location of the config file")
+	rootCmd.PersistentFlags().StringP("output", "o", OutputTable, "The output format of the commands: 'table', 'json' or 'csv'. With json and csv, errors are printed as json objects")
 
 	rootCmd.Flags().String("dbmode", "", "Turn on custom sqlite modes")
 	rootCmd.Flags().Bool("wal", false, "Turn on WAL mode for sqlite")
 
+	rootCmd.PersistentFlags().String("keystore", "", "Sign transactions with the keys in this keystore directory instead of factom-walletd. Without a value the default keystore is used")
+	rootCmd.PersistentFlags().Lookup("keystore").NoOptDefVal = DefaultKeystore
+	rootCmd.PersistentFlags().String("ethkey", "", "Sign Fe/FE inputs with the hex secp256k1 private key in this file")
+	rootCmd.PersistentFlags().String("ethkeystore", "", "Sign Fe/FE inputs with the key in this ethereum V3 keystore json file")
 	rootCmd.PersistentFlags().BoolP("no-warn", "n", false, "Ignore all warnings/notices")
 	rootCmd.PersistentFlags().Bool("no-hf", false, "Disable the check that your node was updated before each hard fork. It will still print a warning")
 
@@ -46,13 +54,24 @@ func init() {
 	_ = rootCmd.PersistentFlags().MarkHidden("testingact")
 
 	rootCmd.AddCommand(properties)
+
+	// Cobra prints argument errors before any command runs, structured
+	// output prints them in Execute instead
+	cobra.OnInitialize(func() {
+		if structuredOutput(rootCmd) {
+			rootCmd.SilenceErrors = true
+			rootCmd.SilenceUsage = true
+		}
+	})
 }
 
 // Execute is cobra's entry point
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		//fmt.Println(err)
-		os.Exit(1)
+		if structuredOutput(rootCmd) {
+			exitError(rootCmd, usageError{err})
+		}
+		os.Exit(ExitUsage)
 	}
 }
 
@@ -76,6 +95,11 @@ var rootCmd = &cobra.Command{
 
 		apiserver := srv.NewAPIServer(conf, node)
 		go apiserver.Start(ctx.Done())
+		go node.SubmissionSync(ctx)
+		go node.EthAddressesBackfill(ctx)
+		if node.Pending != nil {
+			go node.PendingSync(ctx)
+		}
 
 		// Run
 		node.DBlockSync(ctx)
@@ -97,49 +121,53 @@ var properties = &cobra.Command{
 		conf := viper.GetViper()
 
 		sqliteVersion, _, _ := sqlite3.Version()
-		format := "\t%20s: %v\n"
-		fmt.Println("Pegnetd CLI Version and Properties")
-		fmt.Printf(format, "Build Version", config.CompiledInVersion)
-		fmt.Printf(format, "Build Commit", config.CompiledInBuild)
-		fmt.Printf(format, "SQLite Version", sqliteVersion)
-		fmt.Printf(format, "Golang Version", runtime.Version())
-
+		var res ResultProperties
+		res.CLI = srv.PegnetdProperties{
+			BuildVersion:  config.CompiledInVersion,
+			BuildCommit:   config.CompiledInBuild,
+			SQLiteVersion: sqliteVersion,
+			GolangVersion: runtime.Version(),
+		}
 		// Remote pegnetd properties. The cli and pegnetd daemon can differ
-		fmt.Println("\nRemote Pegnetd")
-		props := getProperties()
-		fmt.Printf(format, "Build Version", props.BuildVersion)
-		fmt.Printf(format, "Build Commit", props.BuildCommit)
-		fmt.Printf(format, "SQLite Version", props.SQLiteVersion)
-		fmt.Printf(format, "Golang Version", props.GolangVersion)
+		res.Pegnetd = getProperties()
 
 		// Factomd and walletd versions
-		fmt.Println()
 		cl := node.FactomClientFromConfig(conf)
-		factomdProperties := struct {
-			FactomdVersion    string `json:"factomdversion"`
-			FactomdAPIVersion string `json:"factomdapiversion"`
-		}{
-			FactomdVersion: "Unknown", FactomdAPIVersion: "Unknown",
-		}
-		_ = cl.FactomdRequest(nil, "properties", nil, &factomdProperties)
-		fmt.Printf(format, "Factomd Version", factomdProperties.FactomdVersion)
-		fmt.Printf(format, "Factomd API Version", factomdProperties.FactomdAPIVersion)
-
-		walletdProperties := struct {
-			WalletdVersion    string `json:"walletversion"`
-			WalletdAPIVersion string `json:"walletapiversion"`
-		}{
-			WalletdVersion: "Unknown", WalletdAPIVersion: "Unknown",
-		}
-		_ = cl.WalletdRequest(nil, "properties", nil, &walletdProperties)
-		fmt.Printf(format, "Walletd Version", walletdProperties.WalletdVersion)
-		fmt.Printf(format, "Walletd API Version", walletdProperties.WalletdAPIVersion)
+		res.Factomd.FactomdVersion, res.Factomd.FactomdAPIVersion = "Unknown", "Unknown"
+		_ = cl.FactomdRequest(nil, "properties", nil, &res.Factomd)
+		res.Walletd.WalletdVersion, res.Walletd.WalletdAPIVersion = "Unknown", "Unknown"
+		_ = cl.WalletdRequest(nil, "properties", nil, &res.Walletd)
+
+		printOutput(cmd, res, func() {
+			format := "\t%20s: %v\n"
+			fmt.Println("Pegnetd CLI Version and Properties")
+			fmt.Printf(format, "Build Version", res.CLI.BuildVersion)
+			fmt.Printf(format, "Build Commit", res.CLI.BuildCommit)
+			fmt.Printf(format, "SQLite Version", res.CLI.SQLiteVersion)
+			fmt.Printf(format, "Golang Version", res.CLI.GolangVersion)
+
+			fmt.Println("\nRemote Pegnetd")
+			fmt.Printf(format, "Build Version", res.Pegnetd.BuildVersion)
+			fmt.Printf(format, "Build Commit", res.Pegnetd.BuildCommit)
+			fmt.Printf(format, "SQLite Version", res.Pegnetd.SQLiteVersion)
+			fmt.Printf(format, "Golang Version", res.Pegnetd.GolangVersion)
+
+			fmt.Println()
+			fmt.Printf(format, "Factomd Version", res.Factomd.FactomdVersion)
+			fmt.Printf(format, "Factomd API Version", res.Factomd.FactomdAPIVersion)
+			fmt.Printf(format, "Walletd Version", res.Walletd.WalletdVersion)
+			fmt.Printf(format, "Walletd API Version", res.Walletd.WalletdAPIVersion)
+		}, nil)
 
 	},
 }
 
 // always is run before any command
 func always(cmd *cobra.Command, args []string) {
+	if err := validOutputFormat(outputFormat(cmd)); err != nil {
+		exitError(cmd, usageError{err})
+	}
+
 	// See if we are in testing mode
 	if ok, _ := cmd.Flags().GetBool("testing"); ok {
 		log.Infof("in testing mode, activation heights are 0")
@@ -181,14 +209,30 @@ func always(cmd *cobra.Command, args []string) {
 	_ = viper.BindPFlag(config.WalletUser, cmd.Flags().Lookup("walletuser"))
 	_ = viper.BindPFlag(config.WalletPass, cmd.Flags().Lookup("walletpassword"))
 	_ = viper.BindPFlag(config.Pegnetd, cmd.Flags().Lookup("pegnetd"))
+	_ = viper.BindPFlag(config.PegnetdUser, cmd.Flags().Lookup("pegnetduser"))
+	_ = viper.BindPFlag(config.PegnetdPass, cmd.Flags().Lookup("pegnetdpassword"))
+	_ = viper.BindPFlag(config.PegnetdToken, cmd.Flags().Lookup("pegnetdtoken"))
 	_ = viper.BindPFlag(config.APIListen, cmd.Flags().Lookup("api"))
 	_ = viper.BindPFlag(config.SQLDBWalMode, cmd.Flags().Lookup("wal"))
 	_ = viper.BindPFlag(config.CustomSQLDBMode, cmd.Flags().Lookup("dbmode"))
 	_ = viper.BindPFlag(config.DisableHardForkCheck, cmd.Flags().Lookup("no-hf"))
+	_ = viper.BindPFlag(config.Keystore, cmd.Flags().Lookup("keystore"))
+	_ = viper.BindPFlag(config.EthKey, cmd.Flags().Lookup("ethkey"))
+	_ = viper.BindPFlag(config.EthKeystore, cmd.Flags().Lookup("ethkeystore"))
 
 	// Also init some defaults
 	viper.SetDefault(config.DBlockSyncRetryPeriod, time.Second*5)
+	viper.SetDefault(config.FactomdHealthCheck, time.Second*30)
+	viper.SetDefault(config.PendingPollPeriod, time.Second*10)
+	viper.SetDefault(config.SubmissionsRetry, time.Second*10)
+	viper.SetDefault(config.SubmissionsResubmit, 3)
+	viper.SetDefault(config.SubmissionsAttempts, 5)
 	viper.SetDefault(config.SqliteDBPath, "$HOME/.pegnetd/mainnet/sql.db")
+	viper.SetDefault(config.APIRestrictedMethods, []string{"send-transaction", "get-submissions", "get-rate-limits"})
+	viper.SetDefault(config.APICORSOrigins, []string{"*"})
+	viper.SetDefault(config.APICacheMaxEntries, 1000)
+	viper.SetDefault(config.APICacheMaxBytes, 64<<20)
+	viper.SetDefault(config.APITimeout, time.Second*30)
 
 	// Catch ctl+c
 	signalChan := make(chan os.Signal, 1)
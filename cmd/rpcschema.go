@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/pegnet/pegnetd/srv"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rpcSchema.Flags().Bool("remote", false, "Fetch the document from the running pegnetd with rpc.discover, instead of the one built into this binary")
+	rootCmd.AddCommand(rpcSchema)
+}
+
+var rpcSchema = &cobra.Command{
+	Use:   "rpc-schema [FILE]",
+	Short: "Write the OpenRPC document of the api, for client generators",
+	Long: "Write the OpenRPC document of the JSON-RPC api to FILE, or to stdout if no file is given. " +
+		"It describes the params, results and errors of every method, and is the same document " +
+		"the rpc.discover method returns.",
+	Example:          "pegnetd rpc-schema openrpc.json",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		doc := srv.OpenRPC()
+		if remote, _ := cmd.Flags().GetBool("remote"); remote {
+			var err error
+			if doc, err = pegnetdClient().Discover(context.Background()); err != nil {
+				exitErrorf(cmd, "failed to fetch the document: %s", err)
+			}
+		}
+
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			exitError(cmd, err)
+		}
+		data = append(data, '\n')
+		if len(args) == 0 {
+			_, _ = os.Stdout.Write(data)
+			return
+		}
+		if err := ioutil.WriteFile(args[0], data, 0644); err != nil {
+			exitErrorf(cmd, "failed to write the document: %s", err)
+		}
+		printMessage(cmd, "Wrote the OpenRPC document of %d methods to %s", len(doc.Methods), args[0])
+	},
+}
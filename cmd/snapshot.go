@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/node/pegnet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	snapshotExport.Flags().Uint32("height", 0, "The height of the snapshot, must be the synced height of the database (default the synced height)")
+	snapshotExport.Flags().String("file", "", "The file to write (default pegnetd-snapshot-<height>.gz)")
+	snapshotImport.Flags().String("hash", "", "The state hash the snapshot must have, e.g. as published by a trusted node")
+
+	snapshot.AddCommand(snapshotExport)
+	snapshot.AddCommand(snapshotImport)
+	rootCmd.AddCommand(snapshot)
+}
+
+var snapshot = &cobra.Command{
+	Use:   "snapshot <subcommand>",
+	Short: "Export or import the consensus state of the node, to bootstrap a new node",
+}
+
+var snapshotExport = &cobra.Command{
+	Use:   "export",
+	Short: "Write the consensus state at the synced height to a compressed file",
+	Long: "Write the consensus state at the synced height to a compressed file: balances, the balance snapshots, " +
+		"the rates, grades and winners of the last 288 blocks, the transactions in holding, the bank entries " +
+		"and the hashes of executed transactions. The file embeds a state hash, which is printed, so it can be " +
+		"compared with the hash of a trusted node. The database is read directly, the daemon may keep running.",
+	Example:          "pegnetd snapshot export --height 250000 --file snapshot.gz",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		p := pegnet.New(viper.GetViper())
+		if err := p.Init(); err != nil {
+			exitErrorf(cmd, "failed to open the database: %s", err)
+		}
+		defer p.DB.Close()
+
+		height, _ := cmd.Flags().GetUint32("height")
+		if !cmd.Flags().Changed("height") {
+			bs, err := p.SelectSynced(context.Background(), p.DB)
+			if err == sql.ErrNoRows {
+				exitErrorf(cmd, "the database has not synced any blocks")
+			} else if err != nil {
+				exitErrorf(cmd, "failed to read the synced height: %s", err)
+			}
+			height = bs.Synced
+		}
+
+		path, _ := cmd.Flags().GetString("file")
+		if path == "" {
+			path = fmt.Sprintf("pegnetd-snapshot-%d.gz", height)
+		}
+
+		// Write to a temporary file, so a failed export leaves no partial file
+		tmp := path + ".tmp"
+		f, err := os.Create(tmp)
+		if err != nil {
+			exitErrorf(cmd, "failed to create the snapshot file: %s", err)
+		}
+		stateHash, err := p.ExportState(context.Background(), f, height)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp, path)
+		}
+		if err != nil {
+			_ = os.Remove(tmp)
+			exitErrorf(cmd, "failed to export the snapshot: %s", err)
+		}
+
+		res := ResultSnapshot{File: path, Height: height, StateHash: stateHash.String()}
+		printOutput(cmd, res, func() {
+			fmt.Printf("Wrote the state at height %d to %s\n", height, path)
+			fmt.Printf("State hash: %s\n", stateHash)
+		}, nil)
+	},
+}
+
+var snapshotImport = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Seed an empty database with a snapshot, the daemon then syncs from the height after it",
+	Long: "Seed an empty database with a snapshot written by 'pegnetd snapshot export'. The state hash " +
+		"embedded in the file is verified against its contents, and against --hash if given. Compare the " +
+		"printed state hash with a trusted node before relying on the imported state. The daemon must not " +
+		"be running, and the database must be empty, e.g. after 'pegnetd resetDB'.",
+	Example:          "pegnetd snapshot import snapshot.gz --hash <state hash>",
+	PersistentPreRun: always,
+	PreRun:           SoftReadConfig,
+	Args:             cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var expected *factom.Bytes32
+		if hash, _ := cmd.Flags().GetString("hash"); hash != "" {
+			expected = new(factom.Bytes32)
+			if err := expected.Set(hash); err != nil {
+				exitError(cmd, usageError{fmt.Errorf("--hash: %s", err)})
+			}
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			exitErrorf(cmd, "failed to open the snapshot: %s", err)
+		}
+		defer f.Close()
+
+		p := pegnet.New(viper.GetViper())
+		if err := p.Init(); err != nil {
+			exitErrorf(cmd, "failed to open the database: %s", err)
+		}
+		defer p.DB.Close()
+
+		header, stateHash, err := p.ImportState(context.Background(), f, expected)
+		if err != nil {
+			exitErrorf(cmd, "failed to import the snapshot: %s", err)
+		}
+
+		res := ResultSnapshot{File: args[0], Height: header.Height, StateHash: stateHash.String()}
+		printOutput(cmd, res, func() {
+			fmt.Printf("Imported the state at height %d, the node will sync from height %d\n", header.Height, header.Height+1)
+			fmt.Printf("State hash: %s\n", stateHash)
+		}, nil)
+	},
+}
+
+// ResultSnapshot is the output of exporting or importing a snapshot
+type ResultSnapshot struct {
+	File      string `json:"file"`
+	Height    uint32 `json:"height"`
+	StateHash string `json:"statehash"`
+}
@@ -41,33 +41,22 @@ func addressRules(input string, output string) error {
 }
 
 func signAndSend(source string, tx *fat2.Transaction, cl *factom.Client, payment string) (err error, commit *factom.Bytes32, reveal *factom.Bytes32) {
+	var txBatch fat2.TransactionBatch
+	txBatch.Version = 1
+	txBatch.Transactions = []fat2.Transaction{*tx}
+	return signAndSendBatch(source, &txBatch, cl, payment)
+}
+
+// signAndSendBatch signs a batch with the walletd key of the source, which must
+// be the only input of the batch, and submits it
+func signAndSendBatch(source string, txBatch *fat2.TransactionBatch, cl *factom.Client, payment string) (err error, commit *factom.Bytes32, reveal *factom.Bytes32) {
 	// Get out private key
 	// If the source is an Fe/FE address, we use the eth secret
-	var priv factom.RCDSigner
-
-	switch source[:2] {
-	case "FA":
-		priv, err = tx.Input.Address.GetFsAddress(nil, cl)
-		if err != nil {
-			return fmt.Errorf("[FA] unable to get private key: %s\n", err.Error()), nil, nil
-		}
-	case "Fe":
-		addr := factom.FeAddress(tx.Input.Address)
-		priv, err = addr.GetEthSecret(nil, cl)
-		if err != nil {
-			return fmt.Errorf("[Fe] unable to get private key: %s\n", err.Error()), nil, nil
-		}
-	case "FE":
-		addr := factom.FEGatewayAddress(tx.Input.Address)
-		priv, err = addr.GetEthSecret(nil, cl)
-		if err != nil {
-			return fmt.Errorf("[FE] unable to get private key: %s\n", err.Error()), nil, nil
-		}
+	priv, err := signerFor(source, cl)
+	if err != nil {
+		return err, nil, nil
 	}
 
-	var txBatch fat2.TransactionBatch
-	txBatch.Version = 1
-	txBatch.Transactions = []fat2.Transaction{*tx}
 	txBatch.Entry.ChainID = &config.TransactionChain //TODO consider not passing a pointer to config.TransactionChain
 
 	// Sign the tx and make an entry
@@ -95,9 +84,9 @@ func signAndSend(source string, tx *fat2.Transaction, cl *factom.Client, payment
 		return fmt.Errorf("not enough ec balance for the transaction"), nil, nil
 	}
 
-	es, err := ec.GetEsAddress(nil, cl)
+	es, err := esAddressFor(ec, cl)
 	if err != nil {
-		return fmt.Errorf("failed to parse input: %s\n", err.Error()), nil, nil
+		return err, nil, nil
 	}
 
 	txid, err := txBatch.Entry.ComposeCreate(nil, cl, es)
@@ -108,6 +97,83 @@ func signAndSend(source string, tx *fat2.Transaction, cl *factom.Client, payment
 	return nil, &txid, txBatch.Entry.Hash
 }
 
+// signerFor fetches the private key of the source address from --ethkey or
+// --ethkeystore, from the keystore if --keystore is set, or from
+// factom-walletd. Fe/FE addresses use the eth secret.
+func signerFor(source string, cl *factom.Client) (fat2.OfflineSigner, error) {
+	if priv, ok, err := ethSigner(source); err != nil {
+		return nil, err
+	} else if ok {
+		return priv, nil
+	}
+	if keystoreDir() != "" {
+		return keystoreSigner(source)
+	}
+
+	addr, err := underlyingFA(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse input: %s\n", err.Error())
+	}
+
+	switch source[:2] {
+	case "Fe":
+		priv, err := factom.FeAddress(addr).GetEthSecret(nil, cl)
+		if err != nil {
+			return nil, fmt.Errorf("[Fe] unable to get private key: %s\n", err.Error())
+		}
+		return priv, nil
+	case "FE":
+		priv, err := factom.FEGatewayAddress(addr).GetEthSecret(nil, cl)
+		if err != nil {
+			return nil, fmt.Errorf("[FE] unable to get private key: %s\n", err.Error())
+		}
+		return priv, nil
+	default:
+		priv, err := addr.GetFsAddress(nil, cl)
+		if err != nil {
+			return nil, fmt.Errorf("[FA] unable to get private key: %s\n", err.Error())
+		}
+		return priv, nil
+	}
+}
+
+// esAddressFor fetches the private key of the EC address from the keystore if
+// --keystore is set, or from factom-walletd
+func esAddressFor(ec factom.ECAddress, cl *factom.Client) (factom.EsAddress, error) {
+	if keystoreDir() != "" {
+		return keystoreEsAddress(ec.String())
+	}
+
+	es, err := ec.GetEsAddress(nil, cl)
+	if err != nil {
+		return es, fmt.Errorf("unable to get ec private key: %s\n", err.Error())
+	}
+	return es, nil
+}
+
+// conversionAllowed checks the one way conversion rules against the current
+// height of the daemon
+func conversionAllowed(destAsset string) error {
+	// Let's check the pXXX -> pFCT first
+	status, err := getStatus()
+	if err != nil {
+		return err
+	}
+	if (destAsset == "pFCT" || destAsset == "FCT") && uint32(status.Current) >= config.OneWaypFCTConversions {
+		return fmt.Errorf("pXXX -> pFCT conversions are not allowed since block height %d. If you need to acquire pFCT, you have to burn FCT -> pFCT", config.OneWaypFCTConversions)
+	}
+
+	// Let's check the pXXX -> pSmallAssets
+	// pSmallAssets means the 16 pAssets which have got small market cap.
+	if (destAsset == "PEG" || destAsset == "pDCR" || destAsset == "pDGB" || destAsset == "pDOGE" || destAsset == "pHBAR" ||
+		destAsset == "pONT" || destAsset == "pRVN" || destAsset == "pBAT" || destAsset == "pALGO" || destAsset == "pBIF" ||
+		destAsset == "pETB" || destAsset == "pKES" || destAsset == "pNGN" || destAsset == "pRWF" || destAsset == "pTZS" ||
+		destAsset == "pUGX") && uint32(status.Current) >= config.OneWaySmallAssetsConversions {
+		return fmt.Errorf("pXXX -> pSmallAssets conversions are not allowed since block height %d.", config.OneWaySmallAssetsConversions)
+	}
+	return nil
+}
+
 func setTransferOutput(tx *fat2.Transaction, cl *factom.Client, dest, amt string) error {
 	var err error
 	amount, err := FactoidToFactoshi(amt)
@@ -171,7 +237,7 @@ func ticker(asset string) (fat2.PTicker, error) {
 // custom message format
 // Return indicates if a warning was printed
 func printFeWarning(cmd *cobra.Command, addrs ...string) bool {
-	if q, _ := cmd.Flags().GetBool("no-warn"); q {
+	if q, _ := cmd.Flags().GetBool("no-warn"); q || structuredOutput(cmd) {
 		return false // Silenced
 	}
 
@@ -6,8 +6,47 @@ const (
 	SqliteDBPath = "app.dbpath"
 	APIListen    = "app.APIListen"
 
+	// API TLS, authentication and CORS
+	APITLSCert              = "api.tlscert"
+	APITLSKey               = "api.tlskey"
+	APITLSClientCA          = "api.tlsclientca"
+	APITLSRequireClientCert = "api.tlsrequireclientcert"
+	APIUser                 = "api.user"
+	APIPass                 = "api.pass"
+	APITokens               = "api.tokens"
+	APIRestrictedMethods    = "api.restricted"
+	APICORSOrigins          = "api.corsorigins"
+
+	// API rate limits, per IP address and per authenticated caller
+	APIRateLimitRate       = "api.ratelimit.rate"
+	APIRateLimitBurst      = "api.ratelimit.burst"
+	APIRateLimitKeyRate    = "api.ratelimit.keyrate"
+	APIRateLimitKeyBurst   = "api.ratelimit.keyburst"
+	APIRateLimitWeights    = "api.ratelimit.weights"
+	APIRateLimitTrustProxy = "api.ratelimit.trustproxy"
+
+	// API response cache of the expensive methods
+	APICacheMaxEntries = "api.cache.maxentries"
+	APICacheMaxBytes   = "api.cache.maxbytes"
+
+	// API deadlines, for all methods and per method
+	APITimeout  = "api.timeout"
+	APITimeouts = "api.timeouts"
+
 	// DBlockSync Stuff
 	DBlockSyncRetryPeriod = "dblocksync.retry"
+	// How often the factomd endpoints are health checked
+	FactomdHealthCheck = "dblocksync.healthcheck"
+	// How often factomd's pending entries are polled for unconfirmed
+	// transactions, 0 disables tracking them
+	PendingPollPeriod = "dblocksync.pending"
+
+	// The submission queue of send-transaction: how often it is processed,
+	// the blocks a reveal has to make it into one before it is revealed again,
+	// and the reveals before it fails
+	SubmissionsRetry    = "submissions.retry"
+	SubmissionsResubmit = "submissions.resubmit"
+	SubmissionsAttempts = "submissions.attempts"
 
 	CustomSQLDBMode = "db.mode"
 	SQLDBWalMode    = "db.wal"
@@ -18,6 +57,15 @@ const (
 	WalletUser           = "app.WalletUser"
 	WalletPass           = "app.WalletPass"
 	Pegnetd              = "app.Pegnetd"
+	PegnetdUser          = "app.PegnetdUser"
+	PegnetdPass          = "app.PegnetdPass"
+	PegnetdToken         = "app.PegnetdToken"
+	PegnetdTLSCA         = "app.PegnetdTLSCA"
+	PegnetdTLSCert       = "app.PegnetdTLSCert"
+	PegnetdTLSKey        = "app.PegnetdTLSKey"
 	ECPrivateKey         = "app.ECPrivateKey"
 	DisableHardForkCheck = "app.DisableHardForkCheck"
+	Keystore             = "app.Keystore"
+	EthKey               = "app.EthKey"
+	EthKeystore          = "app.EthKeystore"
 )
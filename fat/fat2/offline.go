@@ -0,0 +1,270 @@
+package fat2
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+)
+
+// OfflineBatchVersion is the current version of the OfflineBatch file format
+const OfflineBatchVersion = 1
+
+// OfflineBatch is a transaction batch that is built, signed, and submitted in
+// separate steps, possibly on different machines. The keys never have to be on
+// the machine that is connected to the network.
+//
+// The JSON encoding is the file format passed between the steps:
+//
+//	{
+//	  "version": 1,
+//	  "chainid": "<hex transaction chain id>",
+//	  "timestampsalt": "<unix seconds, ExtIDs[0] of the entry>",
+//	  "batch": {<the entry content, a fat2 transaction batch>},
+//	  "signatures": [
+//	    {"address": "<input address>", "rcd": "<hex>", "signature": "<hex>"}
+//	  ]
+//	}
+//
+// There is one signature per unique input address of the batch, in the order
+// the inputs first appear in the batch. The "rcd" and "signature" fields are
+// empty until the input is signed. Any change to the batch invalidates the
+// signatures.
+//
+// The timestamp salt is set when the batch is built. Factomd only accepts the
+// entry if it is submitted within 12 hours of the salt.
+type OfflineBatch struct {
+	Version       uint               `json:"version"`
+	ChainID       *factom.Bytes32    `json:"chainid"`
+	TimestampSalt string             `json:"timestampsalt"`
+	Batch         json.RawMessage    `json:"batch"`
+	Signatures    []OfflineSignature `json:"signatures"`
+}
+
+// OfflineSignature is the RCD/signature pair of an input address. The address
+// is kept in the form the user gave it, so the signer knows which key to use.
+type OfflineSignature struct {
+	Address   string       `json:"address"`
+	RCD       factom.Bytes `json:"rcd,omitempty"`
+	Signature factom.Bytes `json:"signature,omitempty"`
+}
+
+// OfflineSigner is a key that can sign an OfflineBatch, like factom.FsAddress
+// or factom.EthSecret
+type OfflineSigner interface {
+	factom.RCDSigner
+	FAAddress() factom.FAAddress
+}
+
+// NewOfflineBatch returns an unsigned OfflineBatch for the batch. The
+// addresses are the human readable input addresses of the batch, in order.
+func NewOfflineBatch(chainID *factom.Bytes32, batch TransactionBatch, addresses []string) (*OfflineBatch, error) {
+	content, err := json.Marshal(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &OfflineBatch{
+		Version:       OfflineBatchVersion,
+		ChainID:       chainID,
+		TimestampSalt: newTimestampSalt(),
+		Batch:         content,
+	}
+	for _, addr := range addresses {
+		o.Signatures = append(o.Signatures, OfflineSignature{Address: addr})
+	}
+
+	if _, err := o.TransactionBatch(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// AddTransaction appends a transaction to the batch. The address is the human
+// readable input address of the transaction. Any existing signatures are
+// dropped and the timestamp salt is renewed, as the content changed.
+//
+// The batch may be empty, which is how it is used to build up a batch one
+// transaction at a time.
+func (o *OfflineBatch) AddTransaction(tx Transaction, address string) error {
+	var batch TransactionBatch
+	batch.Version = 1
+	if len(o.Transactions()) > 0 {
+		b, err := o.TransactionBatch()
+		if err != nil {
+			return err
+		}
+		batch = *b
+	}
+
+	names := make(map[factom.FAAddress]string)
+	for i, input := range batch.Inputs() {
+		names[input] = o.Signatures[i].Address
+	}
+	if _, ok := names[tx.Input.Address]; !ok {
+		names[tx.Input.Address] = address
+	}
+
+	batch.Transactions = append(batch.Transactions, tx)
+	if err := batch.ValidData(); err != nil {
+		return err
+	}
+	content, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	o.Batch = content
+	o.TimestampSalt = newTimestampSalt()
+	o.Signatures = nil
+	for _, input := range batch.Inputs() {
+		o.Signatures = append(o.Signatures, OfflineSignature{Address: names[input]})
+	}
+	return nil
+}
+
+// Transactions returns the transactions of the batch without validating it,
+// or nil if the batch is empty or can not be decoded
+func (o OfflineBatch) Transactions() []Transaction {
+	var batch struct {
+		Transactions []Transaction `json:"transactions"`
+	}
+	if len(o.Batch) == 0 || json.Unmarshal(o.Batch, &batch) != nil {
+		return nil
+	}
+	return batch.Transactions
+}
+
+// Content returns the entry content that is signed
+func (o OfflineBatch) Content() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, o.Batch); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// TransactionBatch returns the decoded batch after checking that the file is
+// consistent
+func (o OfflineBatch) TransactionBatch() (*TransactionBatch, error) {
+	if o.Version != OfflineBatchVersion {
+		return nil, fmt.Errorf("unsupported version %d", o.Version)
+	}
+	if o.ChainID == nil {
+		return nil, fmt.Errorf("missing chainid")
+	}
+	if _, err := strconv.ParseInt(o.TimestampSalt, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid timestampsalt: %v", err)
+	}
+
+	content, err := o.Content()
+	if err != nil {
+		return nil, err
+	}
+	var batch TransactionBatch
+	if err := batch.UnmarshalJSON(content); err != nil {
+		return nil, err
+	}
+	if err := batch.ValidData(); err != nil {
+		return nil, err
+	}
+
+	inputs := batch.Inputs()
+	if len(inputs) != len(o.Signatures) {
+		return nil, fmt.Errorf("expected %d signatures, found %d", len(inputs), len(o.Signatures))
+	}
+	return &batch, nil
+}
+
+// Sign adds the signature of the signer. It returns false if the signer is
+// not an input of the batch.
+func (o *OfflineBatch) Sign(signer OfflineSigner) (bool, error) {
+	batch, err := o.TransactionBatch()
+	if err != nil {
+		return false, err
+	}
+	content, _ := o.Content() // checked by TransactionBatch
+
+	for i, input := range batch.Inputs() {
+		if input != signer.FAAddress() {
+			continue
+		}
+		hash := SignatureHash(o.ChainID, []byte(o.TimestampSalt), content, i)
+		o.Signatures[i].RCD = signer.RCD()
+		o.Signatures[i].Signature = signer.Sign(hash)
+		return true, nil
+	}
+	return false, nil
+}
+
+// Unsigned returns the addresses that still need to sign
+func (o OfflineBatch) Unsigned() []string {
+	var addrs []string
+	for _, sig := range o.Signatures {
+		if len(sig.RCD) == 0 || len(sig.Signature) == 0 {
+			addrs = append(addrs, sig.Address)
+		}
+	}
+	return addrs
+}
+
+// Entry returns the signed entry, ready to be submitted. The entry is
+// validated as if it was submitted now.
+func (o OfflineBatch) Entry() (factom.Entry, error) {
+	var e factom.Entry
+	if _, err := o.TransactionBatch(); err != nil {
+		return e, err
+	}
+	if missing := o.Unsigned(); len(missing) > 0 {
+		return e, fmt.Errorf("missing signatures for %v", missing)
+	}
+
+	e.ChainID = o.ChainID
+	e.Content, _ = o.Content() // checked by TransactionBatch
+	e.Timestamp = time.Now()
+	e.ExtIDs = []factom.Bytes{factom.Bytes(o.TimestampSalt)}
+	for _, sig := range o.Signatures {
+		e.ExtIDs = append(e.ExtIDs, sig.RCD, sig.Signature)
+	}
+
+	if _, err := NewTransactionBatch(e, -1); err != nil {
+		return e, err
+	}
+	return e, nil
+}
+
+// newTimestampSalt returns the salt for a new batch. Same as fat103, the
+// random offset avoids duplicate entries.
+func newTimestampSalt() string {
+	return strconv.FormatInt(time.Now().Unix()+rand.Int63n(1000), 10)
+}
+
+// Inputs returns the unique input addresses of the batch, in the order they
+// first appear. This is the order of the RCD/signature pairs in the ExtIDs.
+func (t TransactionBatch) Inputs() []factom.FAAddress {
+	var inputs []factom.FAAddress
+	seen := make(map[factom.FAAddress]bool)
+	for _, tx := range t.Transactions {
+		if !seen[tx.Input.Address] {
+			seen[tx.Input.Address] = true
+			inputs = append(inputs, tx.Input.Address)
+		}
+	}
+	return inputs
+}
+
+// SignatureHash returns the message hash that the RCD/signature pair at the
+// given index of the ExtIDs signs, as defined by FATIP-103.
+func SignatureHash(chainID *factom.Bytes32, timestampSalt, content []byte, rcdSigID int) []byte {
+	msg := []byte(strconv.Itoa(rcdSigID))
+	msg = append(msg, timestampSalt...)
+	msg = append(msg, chainID[:]...)
+	msg = append(msg, content...)
+	hash := sha512.Sum512(msg)
+	return hash[:]
+}
@@ -0,0 +1,99 @@
+package fat2_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+
+	. "github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOfflineBatch tests that a batch that is built, written to a file, and
+// signed in separate steps results in a valid entry.
+func TestOfflineBatch(t *testing.T) {
+	var batch TransactionBatch
+	require.NoError(t, json.Unmarshal([]byte(validTransactionBatchJSON), &batch))
+	c := factom.NewBytes32("00000000000000000000000000000000")
+
+	o, err := NewOfflineBatch(&c, batch, []string{"FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"}, o.Unsigned())
+	_, err = o.Entry()
+	assert.Error(t, err, "unsigned batch")
+
+	// Indented files must sign the same content
+	data, err := json.MarshalIndent(o, "", "  ")
+	require.NoError(t, err)
+	var file OfflineBatch
+	require.NoError(t, json.Unmarshal(data, &file))
+
+	zeros, err := factom.NewFsAddress("Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj")
+	require.NoError(t, err)
+	ok, err := file.Sign(zeros)
+	require.NoError(t, err)
+	assert.False(t, ok, "not an input")
+
+	sands, err := factom.NewFsAddress("Fs3E9gV6DXsYzf7Fqx1fVBQPQXV695eP3k5XbmHEZVRLkMdD9qCK")
+	require.NoError(t, err)
+	ok, err = file.Sign(sands)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, file.Unsigned())
+
+	e, err := file.Entry()
+	require.NoError(t, err)
+	assert.Equal(t, o.TimestampSalt, string(e.ExtIDs[0]))
+
+	_, err = NewTransactionBatch(e, -1)
+	assert.NoError(t, err)
+
+	// Changing the content invalidates the signature
+	file.Batch = json.RawMessage(`{"version":1,"transactions":[{"input":{"address":"FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q","type":"PEG","amount":51},"transfers":[{"address":"FA1zT4aFpEvcnPqPCigB3fvGu4Q4mTXY22iiuV69DqE1pNhdF2MC","amount":51}]}]}`)
+	_, err = file.Entry()
+	assert.Error(t, err)
+}
+
+func TestOfflineBatch_AddTransaction(t *testing.T) {
+	c := factom.NewBytes32("00000000000000000000000000000000")
+	o := OfflineBatch{Version: OfflineBatchVersion, ChainID: &c}
+	assert.Empty(t, o.Transactions())
+
+	var tx Transaction
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"input": {"address": "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", "type": "PEG", "amount": 50},
+		"transfers": [{"address": "FA1zT4aFpEvcnPqPCigB3fvGu4Q4mTXY22iiuV69DqE1pNhdF2MC", "amount": 50}]
+	}`), &tx))
+	require.NoError(t, o.AddTransaction(tx, "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"))
+
+	sands, err := factom.NewFsAddress("Fs3E9gV6DXsYzf7Fqx1fVBQPQXV695eP3k5XbmHEZVRLkMdD9qCK")
+	require.NoError(t, err)
+	_, err = o.Sign(sands)
+	require.NoError(t, err)
+
+	// Adding a transaction drops the signature
+	var cvt Transaction
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"input": {"address": "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", "type": "PEG", "amount": 10},
+		"conversion": "pUSD"
+	}`), &cvt))
+	require.NoError(t, o.AddTransaction(cvt, "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"))
+	assert.Len(t, o.Transactions(), 2)
+	assert.Equal(t, []string{"FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"}, o.Unsigned())
+
+	_, err = o.Sign(sands)
+	require.NoError(t, err)
+	_, err = o.Entry()
+	assert.NoError(t, err)
+
+	// A second input address is not allowed by the protocol
+	var other Transaction
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"input": {"address": "FA32xV6SoPBSbAZAVyuiHWwyoMYhnSyMmAHZfK29H8dx7bJXFLja", "type": "PEG", "amount": 10},
+		"conversion": "pUSD"
+	}`), &other))
+	assert.EqualError(t, o.AddTransaction(other, "FA32xV6SoPBSbAZAVyuiHWwyoMYhnSyMmAHZfK29H8dx7bJXFLja"), "only one input address allowed")
+	assert.Len(t, o.Transactions(), 2)
+}
@@ -0,0 +1,51 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EthAddresses are the addresses of an ethereum key, which all belong to the
+// same key. The pegnet uses the FA address internally.
+type EthAddresses struct {
+	EthAddress string `json:"ethaddress"`
+	FeAddress  string `json:"feaddress"`
+	FAAddress  string `json:"faaddress"`
+}
+
+// Addresses returns the addresses of an ethereum key
+func Addresses(secret factom.EthSecret) EthAddresses {
+	return EthAddresses{
+		EthAddress: secret.EthAddress(),
+		FeAddress:  secret.FeAddress().String(),
+		FAAddress:  secret.FAAddress().String(),
+	}
+}
+
+// ParseEthKey parses a hex encoded secp256k1 private key, with or without the
+// 0x prefix, as exported by most ethereum wallets
+func ParseEthKey(key string) (factom.EthSecret, error) {
+	key = strings.TrimSpace(key)
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "0x"), "0X")
+	if _, err := hex.DecodeString(key); err != nil || len(key) != 64 {
+		return factom.EthSecret{}, fmt.Errorf("expected a private key of 64 hex characters")
+	}
+	if _, err := crypto.HexToECDSA(key); err != nil {
+		return factom.EthSecret{}, err
+	}
+	return factom.NewEthSecret("0x" + key)
+}
+
+// DecryptEthKeystore decrypts a standard ethereum V3 keystore json file
+func DecryptEthKeystore(data []byte, password string) (factom.EthSecret, error) {
+	key, err := ethkeystore.DecryptKey(data, password)
+	if err != nil {
+		return factom.EthSecret{}, err
+	}
+	return factom.NewEthSecret("0x" + hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)))
+}
@@ -0,0 +1,60 @@
+package keystore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The scrypt test vector of the ethereum wiki
+const testEthKeystore = `{
+	"crypto": {
+		"cipher": "aes-128-ctr",
+		"cipherparams": {"iv": "83dbcc02d8ccb40e466191a123791e0e"},
+		"ciphertext": "d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c",
+		"kdf": "scrypt",
+		"kdfparams": {
+			"dklen": 32,
+			"n": 262144,
+			"r": 1,
+			"p": 8,
+			"salt": "ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"
+		},
+		"mac": "2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"
+	},
+	"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+	"version": 3
+}`
+
+const testEthKey = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+
+func TestParseEthKey(t *testing.T) {
+	secret, err := ParseEthKey(testEthKey)
+	require.NoError(t, err)
+	prefixed, err := ParseEthKey("0x" + testEthKey + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, secret, prefixed)
+	assert.Equal(t, "0x"+testEthKey, secret.String())
+
+	_, err = ParseEthKey(testEthKey[2:])
+	assert.Error(t, err, "short")
+	_, err = ParseEthKey("zz" + testEthKey[2:])
+	assert.Error(t, err, "not hex")
+	_, err = ParseEthKey("0000000000000000000000000000000000000000000000000000000000000000")
+	assert.Error(t, err, "invalid curve point")
+}
+
+func TestDecryptEthKeystore(t *testing.T) {
+	secret, err := DecryptEthKeystore([]byte(testEthKeystore), "testpassword")
+	require.NoError(t, err)
+	assert.Equal(t, "0x"+testEthKey, secret.String())
+
+	addrs := Addresses(secret)
+	assert.Equal(t, secret.EthAddress(), addrs.EthAddress)
+	assert.Equal(t, secret.FeAddress().String(), addrs.FeAddress)
+	assert.Equal(t, secret.FAAddress().String(), addrs.FAAddress)
+
+	_, err = DecryptEthKeystore([]byte(testEthKeystore), "wrong")
+	assert.Error(t, err)
+}
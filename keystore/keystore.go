@@ -0,0 +1,334 @@
+// Package keystore is a local store of encrypted private keys, so transactions
+// can be signed without factom-walletd.
+//
+// Every key is a json file in the store directory, named after its public
+// address. The secret is encrypted with AES-256-GCM, using a key derived from
+// the password with scrypt. The public address is authenticated along with
+// the secret, so a key file can not be renamed to another address.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The kinds of keys in the store
+const (
+	// KindFactoid is an Fs key for an RCD-1 FA address
+	KindFactoid = "fs"
+	// KindEntryCredit is an Es key for an EC address
+	KindEntryCredit = "es"
+	// KindEthereum is an ethereum secret for an RCD-e Fe address
+	KindEthereum = "eth"
+)
+
+const keyFileVersion = 1
+
+// The scrypt parameters of new keys. Existing keys store their own.
+var (
+	scryptN = 1 << 18
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrNotFound is returned if the store has no key for an address
+var ErrNotFound = fmt.Errorf("key not found")
+
+// ErrInvalidPassword is returned if a key can not be decrypted
+var ErrInvalidPassword = fmt.Errorf("invalid password")
+
+// Key is the public information of a stored key
+type Key struct {
+	Kind string `json:"kind"`
+	// The FA, EC or Fe address of the key
+	Address string `json:"address"`
+	// The FA address of fs and eth keys, which is what the pegnet uses
+	FAAddress string `json:"faaddress,omitempty"`
+	// The 0x address of eth keys
+	EthAddress string `json:"ethaddress,omitempty"`
+}
+
+// keyFile is the file format of a stored key
+type keyFile struct {
+	Version int `json:"version"`
+	Key
+
+	KDF        string       `json:"kdf"`
+	N          int          `json:"n"`
+	R          int          `json:"r"`
+	P          int          `json:"p"`
+	Salt       factom.Bytes `json:"salt"`
+	Nonce      factom.Bytes `json:"nonce"`
+	Ciphertext factom.Bytes `json:"ciphertext"`
+}
+
+// Store is a directory of encrypted keys
+type Store struct {
+	dir string
+}
+
+// Open returns the store in the directory, creating the directory if needed
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir is the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Import encrypts and stores a secret, which is an Fs address, an Es address,
+// or a 0x prefixed ethereum secret
+func (s *Store) Import(secret string, password []byte) (Key, error) {
+	key, err := publicKey(secret)
+	if err != nil {
+		return Key{}, err
+	}
+	if _, err := os.Stat(s.path(key.Address)); err == nil {
+		return Key{}, fmt.Errorf("%s is already in the keystore", key.Address)
+	}
+
+	f := keyFile{Version: keyFileVersion, Key: key, KDF: "scrypt", N: scryptN, R: scryptR, P: scryptP}
+	f.Salt = make(factom.Bytes, 32)
+	if _, err := rand.Read(f.Salt); err != nil {
+		return Key{}, err
+	}
+	gcm, err := f.cipher(password)
+	if err != nil {
+		return Key{}, err
+	}
+	f.Nonce = make(factom.Bytes, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return Key{}, err
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, []byte(secret), []byte(key.Address))
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return Key{}, err
+	}
+	if err := ioutil.WriteFile(s.path(key.Address), data, 0600); err != nil {
+		return Key{}, err
+	}
+	return key, nil
+}
+
+// Generate creates and stores a new key of the given kind
+func (s *Store) Generate(kind string, password []byte) (Key, error) {
+	var secret string
+	switch kind {
+	case KindFactoid:
+		fs, err := factom.GenerateFsAddress()
+		if err != nil {
+			return Key{}, err
+		}
+		secret = fs.String()
+	case KindEntryCredit:
+		es, err := factom.GenerateEsAddress()
+		if err != nil {
+			return Key{}, err
+		}
+		secret = es.String()
+	case KindEthereum:
+		eth, err := factom.GenerateEthSecret()
+		if err != nil {
+			return Key{}, err
+		}
+		secret = eth.String()
+	default:
+		return Key{}, fmt.Errorf("unknown kind '%s', must be one of %s, %s, %s", kind, KindFactoid, KindEntryCredit, KindEthereum)
+	}
+	return s.Import(secret, password)
+}
+
+// List returns all the keys in the store, sorted by kind and address
+func (s *Store) List() ([]Key, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, 0, len(files))
+	for _, file := range files {
+		f, err := readKeyFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filepath.Base(file), err)
+		}
+		keys = append(keys, f.Key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Kind != keys[j].Kind {
+			return keys[i].Kind < keys[j].Kind
+		}
+		return keys[i].Address < keys[j].Address
+	})
+	return keys, nil
+}
+
+// Find returns the key of an address. FA, Fe and FE addresses match the fs or
+// eth key with the same underlying FA address, EC addresses match es keys.
+func (s *Store) Find(address string) (Key, error) {
+	fa := underlyingFA(address)
+	keys, err := s.List()
+	if err != nil {
+		return Key{}, err
+	}
+	for _, key := range keys {
+		if key.Address == address || (fa != "" && key.FAAddress == fa) {
+			return key, nil
+		}
+	}
+	return Key{}, ErrNotFound
+}
+
+// Export returns the decrypted secret of an address
+func (s *Store) Export(address string, password []byte) (string, error) {
+	key, err := s.Find(address)
+	if err != nil {
+		return "", err
+	}
+	f, err := readKeyFile(s.path(key.Address))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := f.cipher(password)
+	if err != nil {
+		return "", err
+	}
+	secret, err := gcm.Open(nil, f.Nonce, f.Ciphertext, []byte(f.Address))
+	if err != nil {
+		return "", ErrInvalidPassword
+	}
+	return string(secret), nil
+}
+
+// Delete removes the key of an address
+func (s *Store) Delete(address string) error {
+	key, err := s.Find(address)
+	if err != nil {
+		return err
+	}
+	return os.Remove(s.path(key.Address))
+}
+
+// Signer returns the key of an FA, Fe or FE address for signing transactions
+func (s *Store) Signer(address string, password []byte) (fat2.OfflineSigner, error) {
+	secret, err := s.Export(address, password)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(secret, "0x") {
+		return factom.NewEthSecret(secret)
+	}
+	if strings.HasPrefix(secret, "Fs") {
+		return factom.NewFsAddress(secret)
+	}
+	return nil, fmt.Errorf("%s is not a factoid address", address)
+}
+
+// EsAddress returns the key of an EC address
+func (s *Store) EsAddress(address string, password []byte) (factom.EsAddress, error) {
+	secret, err := s.Export(address, password)
+	if err != nil {
+		return factom.EsAddress{}, err
+	}
+	return factom.NewEsAddress(secret)
+}
+
+func (s *Store) path(address string) string {
+	return filepath.Join(s.dir, address+".json")
+}
+
+func (f keyFile) cipher(password []byte) (cipher.AEAD, error) {
+	if f.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported kdf '%s'", f.KDF)
+	}
+	key, err := scrypt.Key(password, f.Salt, f.N, f.R, f.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func readKeyFile(path string) (*keyFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var f keyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f.Version != keyFileVersion {
+		return nil, fmt.Errorf("unsupported version %d", f.Version)
+	}
+	return &f, nil
+}
+
+// publicKey returns the public information of a secret
+func publicKey(secret string) (Key, error) {
+	switch {
+	case strings.HasPrefix(secret, "0x"):
+		eth, err := factom.NewEthSecret(secret)
+		if err != nil {
+			return Key{}, err
+		}
+		return Key{
+			Kind:       KindEthereum,
+			Address:    eth.FeAddress().String(),
+			FAAddress:  eth.FAAddress().String(),
+			EthAddress: eth.EthAddress(),
+		}, nil
+	case strings.HasPrefix(secret, "Fs"):
+		fs, err := factom.NewFsAddress(secret)
+		if err != nil {
+			return Key{}, err
+		}
+		fa := fs.FAAddress().String()
+		return Key{Kind: KindFactoid, Address: fa, FAAddress: fa}, nil
+	case strings.HasPrefix(secret, "Es"):
+		es, err := factom.NewEsAddress(secret)
+		if err != nil {
+			return Key{}, err
+		}
+		return Key{Kind: KindEntryCredit, Address: es.ECAddress().String()}, nil
+	}
+	return Key{}, fmt.Errorf("not an Fs address, Es address, or 0x prefixed ethereum secret")
+}
+
+// underlyingFA returns the FA address of an FA, Fe or FE address, or "" for
+// other addresses
+func underlyingFA(address string) string {
+	if fa, err := factom.NewFAAddress(address); err == nil {
+		return fa.String()
+	}
+	if fe, err := factom.NewFeAddress(address); err == nil {
+		return factom.FAAddress(fe).String()
+	}
+	if fe, err := factom.NewFEGatewayAddress(address); err == nil {
+		return factom.FAAddress(fe).String()
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStore(t *testing.T) (*Store, func()) {
+	scryptN = 1 << 4 // fast tests
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	s, err := Open(dir)
+	require.NoError(t, err)
+	return s, func() { _ = os.RemoveAll(dir) }
+}
+
+func TestStore_Import(t *testing.T) {
+	s, cleanup := testStore(t)
+	defer cleanup()
+	password := []byte("password")
+
+	key, err := s.Import("Fs3E9gV6DXsYzf7Fqx1fVBQPQXV695eP3k5XbmHEZVRLkMdD9qCK", password)
+	require.NoError(t, err)
+	assert.Equal(t, Key{Kind: KindFactoid, Address: "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", FAAddress: "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"}, key)
+
+	_, err = s.Import("Fs3E9gV6DXsYzf7Fqx1fVBQPQXV695eP3k5XbmHEZVRLkMdD9qCK", password)
+	assert.Error(t, err, "duplicate")
+	_, err = s.Import("FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", password)
+	assert.Error(t, err, "public address")
+
+	secret, err := s.Export("FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", password)
+	require.NoError(t, err)
+	assert.Equal(t, "Fs3E9gV6DXsYzf7Fqx1fVBQPQXV695eP3k5XbmHEZVRLkMdD9qCK", secret)
+	_, err = s.Export("FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", []byte("wrong"))
+	assert.Equal(t, ErrInvalidPassword, err)
+
+	signer, err := s.Signer("FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", password)
+	require.NoError(t, err)
+	assert.Equal(t, "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", signer.FAAddress().String())
+
+	require.NoError(t, s.Delete("FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"))
+	_, err = s.Find("FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestStore_Generate(t *testing.T) {
+	s, cleanup := testStore(t)
+	defer cleanup()
+	password := []byte("password")
+
+	eth, err := s.Generate(KindEthereum, password)
+	require.NoError(t, err)
+	es, err := s.Generate(KindEntryCredit, password)
+	require.NoError(t, err)
+	_, err = s.Generate("btc", password)
+	assert.Error(t, err)
+
+	keys, err := s.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Key{es, eth}, keys)
+
+	// Eth keys are found by their Fe, FE, and FA address
+	fe, err := factom.NewFeAddress(eth.Address)
+	require.NoError(t, err)
+	for _, addr := range []string{eth.Address, eth.FAAddress, factom.FEGatewayAddress(fe).String()} {
+		key, err := s.Find(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, eth, key)
+	}
+	signer, err := s.Signer(eth.FAAddress, password)
+	require.NoError(t, err)
+	assert.Equal(t, eth.FAAddress, signer.FAAddress().String())
+
+	ec, err := s.EsAddress(es.Address, password)
+	require.NoError(t, err)
+	assert.Equal(t, es.Address, ec.ECAddress().String())
+	_, err = s.Signer(es.Address, password)
+	assert.Error(t, err)
+}
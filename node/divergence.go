@@ -0,0 +1,99 @@
+package node
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/node/pegnet"
+)
+
+// DivergenceError is returned when factomd no longer agrees with the blocks
+// pegnetd has synced. Syncing on would build on a different history, so the
+// node has to halt until an operator looks at it.
+type DivergenceError struct {
+	Height  uint32
+	Block   string // The block that differs, e.g. "DBlock" or "OPR EBlock"
+	Synced  string // The KeyMR pegnetd synced
+	Factomd string // The KeyMR factomd returns now
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("factomd diverged from the synced chain at height %d: "+
+		"pegnetd synced %s %s, but factomd returns %s. "+
+		"The factomd node is on a different network or fork, or its database was rebuilt from a different source. "+
+		"Point pegnetd at a factomd on the same network, or resync pegnetd from an empty database",
+		e.Height, e.Block, e.Synced, e.Factomd)
+}
+
+// VerifyTip checks that factomd still returns the KeyMRs stored for the synced
+// height. Heights synced before the KeyMRs were recorded, or that factomd does
+// not have yet, are not checked.
+func (d *Pegnetd) VerifyTip(ctx context.Context, factomHeight uint32) error {
+	if d.Sync.Synced == 0 || factomHeight < d.Sync.Synced {
+		return nil
+	}
+	stored, err := d.Pegnet.SelectDBlock(ctx, d.Pegnet.DB, d.Sync.Synced)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	dblock := new(factom.DBlock)
+	dblock.Height = d.Sync.Synced
+	if err := dblock.Get(ctx, d.FactomClient); err != nil {
+		return err
+	}
+	return compareDBlock(stored, dblock)
+}
+
+// compareDBlock returns a DivergenceError if the dblock differs from the
+// stored KeyMRs
+func compareDBlock(stored pegnet.DBlockKeyMRs, dblock *factom.DBlock) error {
+	if *dblock.KeyMR != stored.KeyMR {
+		return &DivergenceError{Height: stored.Height, Block: "DBlock", Synced: stored.KeyMR.String(), Factomd: dblock.KeyMR.String()}
+	}
+	for _, chain := range []struct {
+		name    string
+		chainID factom.Bytes32
+		stored  *factom.Bytes32
+	}{
+		{"OPR EBlock", config.OPRChain, stored.OPREBlock},
+		{"SPR EBlock", config.SPRChain, stored.SPREBlock},
+		{"transaction EBlock", config.TransactionChain, stored.TransEBlock},
+	} {
+		var keyMR *factom.Bytes32
+		if eblock := dblock.EBlock(chain.chainID); eblock != nil {
+			keyMR = eblock.KeyMR
+		}
+		if keyMRString(keyMR) != keyMRString(chain.stored) {
+			return &DivergenceError{Height: stored.Height, Block: chain.name, Synced: keyMRString(chain.stored), Factomd: keyMRString(keyMR)}
+		}
+	}
+	return nil
+}
+
+// dblockKeyMRs are the KeyMRs of a dblock to store
+func dblockKeyMRs(dblock *factom.DBlock, opr, spr, trans *factom.EBlock) pegnet.DBlockKeyMRs {
+	keyMRs := pegnet.DBlockKeyMRs{Height: dblock.Height, KeyMR: *dblock.KeyMR}
+	if opr != nil {
+		keyMRs.OPREBlock = opr.KeyMR
+	}
+	if spr != nil {
+		keyMRs.SPREBlock = spr.KeyMR
+	}
+	if trans != nil {
+		keyMRs.TransEBlock = trans.KeyMR
+	}
+	return keyMRs
+}
+
+func keyMRString(keyMR *factom.Bytes32) string {
+	if keyMR == nil {
+		return "none"
+	}
+	return keyMR.String()
+}
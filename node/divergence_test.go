@@ -0,0 +1,35 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+)
+
+func TestCompareDBlock(t *testing.T) {
+	keyMR := factom.NewBytes32("cffce0f409ebba4ed236d49d89c70e4bd1f1367d86402a3363366683265a242d")
+	oprKeyMR := factom.NewBytes32("a642a8674f46696cc47fdb6b65f9c87b2a19c5ea8123b3d2f0c13b6f33a9d5ef")
+	dblock := &factom.DBlock{
+		Height: 10,
+		KeyMR:  &keyMR,
+		EBlocks: []factom.EBlock{
+			{ChainID: &config.OPRChain, KeyMR: &oprKeyMR},
+		},
+	}
+	stored := dblockKeyMRs(dblock, dblock.EBlock(config.OPRChain), nil, nil)
+	if err := compareDBlock(stored, dblock); err != nil {
+		t.Errorf("expected no divergence: %s", err)
+	}
+
+	other := factom.NewBytes32("d5e395125335a21cef0ceca528168e87fe929fdac1f156870c1b1be6502448b4")
+	dblock.EBlocks[0].KeyMR = &other
+	if err, ok := compareDBlock(stored, dblock).(*DivergenceError); !ok || err.Block != "OPR EBlock" {
+		t.Errorf("expected an OPR EBlock divergence, got %v", err)
+	}
+
+	dblock.KeyMR = &other
+	if err, ok := compareDBlock(stored, dblock).(*DivergenceError); !ok || err.Block != "DBlock" || err.Height != 10 {
+		t.Errorf("expected a DBlock divergence, got %v", err)
+	}
+}
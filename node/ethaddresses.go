@@ -0,0 +1,85 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/pegnet/pegnetd/fat/fat2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ethAddressesBackfillChunk is how many batches are backfilled at a time
+const ethAddressesBackfillChunk = 100
+
+// EthAddressesBackfill links the ethereum addresses of the transaction batches
+// that were synced before the links were recorded, by fetching their entries
+// from factomd. It runs until every batch is backfilled, or the context is
+// done. A chunk that fails is retried.
+func (d *Pegnetd) EthAddressesBackfill(ctx context.Context) {
+	for {
+		done, err := d.backfillEthAddresses(ctx)
+		if done || ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			continue
+		}
+		log.WithError(err).Warn("failed to backfill the ethereum addresses")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// backfillEthAddresses backfills the next chunk of batches, and returns true
+// once there are none left
+func (d *Pegnetd) backfillEthAddresses(ctx context.Context) (bool, error) {
+	progress, err := d.Pegnet.SelectEthAddressesBackfill(ctx, d.Pegnet.DB)
+	if err != nil || progress.Done() {
+		return err == nil, err
+	}
+	batches, err := d.Pegnet.SelectEthAddressesBackfillBatches(ctx, d.Pegnet.DB, progress, ethAddressesBackfillChunk)
+	if err != nil {
+		return false, err
+	}
+	if progress.After == 0 {
+		log.WithField("batches", progress.Last).Info("backfilling the ethereum addresses of the synced transactions")
+	}
+
+	txBatches := make([]fat2.TransactionBatch, len(batches))
+	for i := range batches {
+		txBatches[i].Entry.Hash = &batches[i].EntryHash
+		if err := txBatches[i].Entry.Get(ctx, d.FactomClient); err != nil {
+			return false, err
+		}
+	}
+
+	tx, err := d.Pegnet.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	for i, batch := range batches {
+		// The batch was validated when it was synced
+		if err := d.Pegnet.InsertEthAddresses(ctx, tx, &txBatches[i], batch.Height); err != nil {
+			return false, err
+		}
+	}
+	progress.After = progress.Last
+	if len(batches) > 0 {
+		progress.After = batches[len(batches)-1].ID
+	}
+	if err := d.Pegnet.UpdateEthAddressesBackfill(ctx, tx, progress); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	if progress.Done() {
+		log.Info("backfilled the ethereum addresses")
+	}
+	return progress.Done(), nil
+}
@@ -0,0 +1,133 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/node/pegnet"
+	"github.com/spf13/viper"
+)
+
+// fakeEntryFactomd serves the raw data of its entries
+type fakeEntryFactomd map[factom.Bytes32]factom.Bytes
+
+func (f fakeEntryFactomd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int    `json:"id"`
+		Method string `json:"method"`
+		Params struct {
+			Hash factom.Bytes32 `json:"hash"`
+		} `json:"params"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	data, ok := f[req.Params.Hash]
+	if req.Method != "raw-data" || !ok {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID,
+			"error": map[string]interface{}{"code": -32009, "message": "Missing Chain Head"}})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": map[string]interface{}{"data": data}})
+}
+
+func TestEthAddressesBackfill(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "ethaddresses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	eth, err := factom.NewEthSecret("0x7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, err := factom.GenerateFsAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := make(fakeEntryFactomd)
+	var hashes []factom.Bytes32
+	for _, extIDs := range [][]factom.Bytes{
+		{factom.Bytes("1"), fs.RCD(), fs.Sign(nil)},
+		{factom.Bytes("2"), fs.RCD(), fs.Sign(nil), eth.RCD(), eth.Sign(nil)},
+	} {
+		entry := factom.Entry{ChainID: &config.TransactionChain, ExtIDs: extIDs, Content: factom.Bytes("{}")}
+		data, err := entry.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := factom.ComputeEntryHash(data)
+		fake[hash] = data
+		hashes = append(hashes, hash)
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	conf := viper.New()
+	conf.Set(config.SqliteDBPath, filepath.Join(dir, "sql.db"))
+	d := &Pegnetd{Config: conf, FactomClient: factom.NewClient()}
+	d.FactomClient.FactomdServer = server.URL + "/v2"
+	d.Pegnet = pegnet.New(conf)
+	if err := d.Pegnet.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if progress, err := d.Pegnet.SelectEthAddressesBackfill(ctx, d.Pegnet.DB); err != nil || !progress.Done() {
+		t.Fatalf("expected nothing to backfill on a new database, got %+v, %v", progress, err)
+	}
+
+	// A database that synced the batches before the links were recorded,
+	// with burns and coinbases, whose entry hashes factomd does not serve as
+	// entries
+	history := []struct {
+		hash   factom.Bytes32
+		action pegnet.HistoryAction
+	}{
+		{factom.NewBytes32("a2f3e2ab5b5c3ec8c2d4a2fa8e8bd36c4fb9d9a1c8d6d0cd9a0d2b1b6d4a1c01"), pegnet.FCTBurn},
+		{hashes[0], pegnet.Transfer},
+		{factom.NewBytes32("a2f3e2ab5b5c3ec8c2d4a2fa8e8bd36c4fb9d9a1c8d6d0cd9a0d2b1b6d4a1c02"), pegnet.Coinbase},
+		{hashes[1], pegnet.Conversion},
+	}
+	for i, batch := range history {
+		_, err = d.Pegnet.DB.Exec(`INSERT INTO "pn_history_txbatch" (entry_hash, height, blockorder, timestamp, executed) VALUES (?, ?, ?, ?, ?)`,
+			batch.hash[:], 100+i, 0, 0, 100+i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = d.Pegnet.DB.Exec(`INSERT INTO "pn_history_transaction" (entry_hash, tx_index, action_type, from_address, from_asset, from_amount, to_asset, to_amount, outputs) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			batch.hash[:], 0, batch.action, []byte{}, "PEG", 1, "", 0, []byte{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = d.Pegnet.DB.Exec(`DELETE FROM "pn_metadata" WHERE "name" = 'eth_addresses_backfill'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Pegnet.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Pegnet.SelectEthAddressFA(ctx, eth.EthAddress()); err != pegnet.ErrUnknownEthAddress {
+		t.Fatalf("expected ErrUnknownEthAddress before the backfill, got %v", err)
+	}
+
+	d.EthAddressesBackfill(ctx)
+
+	if progress, err := d.Pegnet.SelectEthAddressesBackfill(ctx, d.Pegnet.DB); err != nil || !progress.Done() || progress.Last != 4 {
+		t.Errorf("unexpected progress %+v, %v", progress, err)
+	}
+	fa, err := d.Pegnet.SelectEthAddressFA(ctx, eth.EthAddress())
+	if err != nil || fa != eth.FAAddress() {
+		t.Errorf("expected %s, got %s, %v", eth.FAAddress(), fa, err)
+	}
+	var height uint32
+	if err := d.Pegnet.DB.QueryRow(`SELECT "height" FROM "pn_eth_addresses"`).Scan(&height); err != nil || height != 103 {
+		t.Errorf("expected the link at height 103, got %d, %v", height, err)
+	}
+}
@@ -0,0 +1,352 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	log "github.com/sirupsen/logrus"
+)
+
+// FactomdEndpoints parses a list of factomd endpoints. Each item may itself be
+// a comma separated list, so the --server flag can take several endpoints.
+func FactomdEndpoints(list []string) []string {
+	var endpoints []string
+	for _, item := range list {
+		for _, endpoint := range strings.Split(item, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+	return endpoints
+}
+
+// FactomdStatus is the health of a factomd endpoint
+type FactomdStatus struct {
+	URL     string `json:"url"`
+	Active  bool   `json:"active"`  // requests are routed to this endpoint
+	Healthy bool   `json:"healthy"` // responsive, and agrees with the others
+	Height  uint32 `json:"height"`  // the directory block height
+	// Latency of the last health check in milliseconds
+	Latency   int64     `json:"latency"`
+	LastCheck time.Time `json:"lastcheck,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type factomdEndpoint struct {
+	url    *url.URL
+	status FactomdStatus
+}
+
+// FactomdPool routes the factomd requests of a factom.Client to the best of
+// several factomd endpoints. It is installed as the http.RoundTripper of the
+// client, so a request that fails on one endpoint is retried on the others.
+// Check ranks the endpoints by their health.
+type FactomdPool struct {
+	mu        sync.RWMutex
+	endpoints []*factomdEndpoint
+	active    int
+
+	// Transport makes the actual requests, http.DefaultTransport if nil
+	Transport http.RoundTripper
+	// Timeout limits the requests of a health check
+	Timeout time.Duration
+}
+
+// NewFactomdPool returns a pool of the endpoints, which are all assumed to be
+// healthy until checked. The first endpoint is active.
+func NewFactomdPool(endpoints []string) (*FactomdPool, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no factomd endpoint given")
+	}
+	p := &FactomdPool{Timeout: 10 * time.Second}
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid factomd endpoint %q", endpoint)
+		}
+		p.endpoints = append(p.endpoints, &factomdEndpoint{url: u, status: FactomdStatus{URL: endpoint, Healthy: true}})
+	}
+	return p, nil
+}
+
+// URL is the url of the active endpoint
+func (p *FactomdPool) URL() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.endpoints[p.active].status.URL
+}
+
+// Status returns the status of every endpoint, in the configured order
+func (p *FactomdPool) Status() []FactomdStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	status := make([]FactomdStatus, len(p.endpoints))
+	for i, e := range p.endpoints {
+		status[i] = e.status
+		status[i].Active = i == p.active
+	}
+	return status
+}
+
+func (p *FactomdPool) transport() http.RoundTripper {
+	if p.Transport != nil {
+		return p.Transport
+	}
+	return http.DefaultTransport
+}
+
+// order returns the endpoints to try, the active one first, then the healthy
+// ones, then the rest
+func (p *FactomdPool) order() []int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	order := []int{p.active}
+	for _, healthy := range []bool{true, false} {
+		for i, e := range p.endpoints {
+			if i != p.active && e.status.Healthy == healthy {
+				order = append(order, i)
+			}
+		}
+	}
+	return order
+}
+
+// RoundTrip sends the request to the active endpoint, and fails over to the
+// other endpoints if it can not be reached or answers with a server error
+func (p *FactomdPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var lastErr error
+	for _, i := range p.order() {
+		r := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		p.mu.RLock()
+		u := *p.endpoints[i].url
+		p.mu.RUnlock()
+		r.URL = &u
+		r.Host = u.Host
+
+		res, err := p.transport().RoundTrip(r)
+		if err == nil && res.StatusCode < http.StatusInternalServerError {
+			p.succeeded(i)
+			return res, nil
+		}
+		if err == nil {
+			res.Body.Close()
+			err = fmt.Errorf("http: %s", res.Status)
+		}
+		lastErr = err
+		p.failed(i, err)
+		if req.Context().Err() != nil || req.GetBody == nil && req.Body != nil {
+			break // Canceled, or the body can not be sent again
+		}
+	}
+	return nil, lastErr
+}
+
+// succeeded makes the endpoint active if the active one failed
+func (p *FactomdPool) succeeded(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i != p.active && !p.endpoints[p.active].status.Healthy {
+		log.WithFields(log.Fields{"from": p.endpoints[p.active].status.URL, "to": p.endpoints[i].status.URL}).Warn("failing over to another factomd")
+		p.active = i
+	}
+}
+
+func (p *FactomdPool) failed(i int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.endpoints[i]
+	if e.status.Healthy && len(p.endpoints) > 1 {
+		log.WithError(err).WithField("factomd", e.status.URL).Warn("factomd request failed")
+	}
+	e.status.Healthy = false
+	e.status.Error = err.Error()
+}
+
+// Monitor checks the endpoints every period until the context is done
+func (p *FactomdPool) Monitor(ctx context.Context, period time.Duration) {
+	for {
+		p.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(period):
+		}
+	}
+}
+
+// Check queries the heights of every endpoint, and the DBlock KeyMR at the
+// lowest height they all have. Endpoints that do not respond, or disagree with
+// the majority on the KeyMR, are unhealthy. The active endpoint is kept unless
+// it is unhealthy or behind the highest healthy endpoint.
+func (p *FactomdPool) Check(ctx context.Context) {
+	n := len(p.endpoints)
+	status := make([]FactomdStatus, n)
+	var wg sync.WaitGroup
+	for i := range p.endpoints {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			status[i] = p.checkHeight(ctx, p.endpoints[i].status.URL)
+		}(i)
+	}
+	wg.Wait()
+
+	// Agreement is only checked at a height every responsive endpoint has
+	var common uint32
+	var responsive []int
+	for i := range status {
+		if status[i].Healthy {
+			if len(responsive) == 0 || status[i].Height < common {
+				common = status[i].Height
+			}
+			responsive = append(responsive, i)
+		}
+	}
+	if len(responsive) > 1 && common > 0 {
+		keyMRs := make([]string, n)
+		for _, i := range responsive {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				keyMR, err := p.dblockKeyMR(ctx, status[i].URL, common)
+				if err != nil {
+					status[i].Healthy = false
+					status[i].Error = err.Error()
+					return
+				}
+				keyMRs[i] = keyMR
+			}(i)
+		}
+		wg.Wait()
+
+		p.mu.RLock()
+		agreed := majorityKeyMR(keyMRs, keyMRs[p.active])
+		p.mu.RUnlock()
+		for _, i := range responsive {
+			if status[i].Healthy && keyMRs[i] != agreed {
+				status[i].Healthy = false
+				status[i].Error = fmt.Sprintf("disagrees on the DBlock KeyMR at height %d: %s, expected %s", common, keyMRs[i], agreed)
+			}
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range status {
+		p.endpoints[i].status = status[i]
+	}
+	best := p.best()
+	if best != p.active {
+		log.WithFields(log.Fields{"from": p.endpoints[p.active].status.URL, "to": p.endpoints[best].status.URL}).Info("switching factomd endpoint")
+		p.active = best
+	}
+}
+
+// best returns the endpoint that should be active. Must be called with the
+// lock held.
+func (p *FactomdPool) best() int {
+	var healthy []int
+	var top uint32
+	for i, e := range p.endpoints {
+		if e.status.Healthy {
+			healthy = append(healthy, i)
+			if e.status.Height > top {
+				top = e.status.Height
+			}
+		}
+	}
+	if len(healthy) == 0 {
+		return p.active
+	}
+
+	// A healthy active endpoint a block behind is not worth a switch, as
+	// factomd nodes finish blocks at slightly different times
+	if active := p.endpoints[p.active].status; active.Healthy && active.Height+1 >= top {
+		return p.active
+	}
+	sort.SliceStable(healthy, func(a, b int) bool {
+		ea, eb := p.endpoints[healthy[a]].status, p.endpoints[healthy[b]].status
+		if ea.Height != eb.Height {
+			return ea.Height > eb.Height
+		}
+		return ea.Latency < eb.Latency
+	})
+	return healthy[0]
+}
+
+// majorityKeyMR returns the KeyMR most endpoints agree on. On a tie, the KeyMR
+// of the active endpoint wins.
+func majorityKeyMR(keyMRs []string, active string) string {
+	votes := make(map[string]int)
+	for _, keyMR := range keyMRs {
+		if keyMR != "" {
+			votes[keyMR]++
+		}
+	}
+	candidates := make([]string, 0, len(votes))
+	for keyMR := range votes {
+		candidates = append(candidates, keyMR)
+	}
+	sort.Strings(candidates)
+
+	agreed := active
+	for _, keyMR := range candidates {
+		if votes[keyMR] > votes[agreed] || agreed == "" {
+			agreed = keyMR
+		}
+	}
+	return agreed
+}
+
+// client returns a factom client of a single endpoint, bypassing the pool
+func (p *FactomdPool) client(endpoint string) *factom.Client {
+	cl := factom.NewClient()
+	cl.FactomdServer = endpoint
+	cl.Factomd.Transport = p.transport()
+	cl.Factomd.Timeout = p.Timeout
+	return cl
+}
+
+func (p *FactomdPool) checkHeight(ctx context.Context, endpoint string) FactomdStatus {
+	status := FactomdStatus{URL: endpoint, LastCheck: time.Now()}
+	heights := new(factom.Heights)
+	err := heights.Get(ctx, p.client(endpoint))
+	status.Latency = time.Since(status.LastCheck).Milliseconds()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Healthy = true
+	status.Height = heights.DirectoryBlock
+	return status
+}
+
+func (p *FactomdPool) dblockKeyMR(ctx context.Context, endpoint string, height uint32) (string, error) {
+	params := struct {
+		Height uint32 `json:"height"`
+	}{height}
+	var res struct {
+		DBlock struct {
+			KeyMR string `json:"keymr"`
+		} `json:"dblock"`
+	}
+	if err := p.client(endpoint).FactomdRequest(ctx, "dblock-by-height", params, &res); err != nil {
+		return "", err
+	}
+	return res.DBlock.KeyMR, nil
+}
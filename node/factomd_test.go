@@ -0,0 +1,125 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+)
+
+// fakeFactomd answers heights and dblock-by-height
+type fakeFactomd struct {
+	height uint32
+	keyMR  string
+	down   bool
+}
+
+func (f *fakeFactomd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		ID     int    `json:"id"`
+		Method string `json:"method"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	var result interface{}
+	switch req.Method {
+	case "heights":
+		result = factom.Heights{DirectoryBlock: f.height, Leader: f.height + 1, EntryBlock: f.height, Entry: f.height}
+	case "dblock-by-height":
+		result = map[string]interface{}{"dblock": map[string]string{"keymr": f.keyMR}}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func newFakeFactomds(t *testing.T, fakes ...*fakeFactomd) (*FactomdPool, func()) {
+	var urls []string
+	var servers []*httptest.Server
+	for _, f := range fakes {
+		s := httptest.NewServer(f)
+		servers = append(servers, s)
+		urls = append(urls, s.URL+"/v2")
+	}
+	pool, err := NewFactomdPool(urls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool, func() {
+		for _, s := range servers {
+			s.Close()
+		}
+	}
+}
+
+func TestFactomdEndpoints(t *testing.T) {
+	endpoints := FactomdEndpoints([]string{"http://a/v2, http://b/v2", "", "http://c/v2"})
+	if len(endpoints) != 3 || endpoints[0] != "http://a/v2" || endpoints[1] != "http://b/v2" || endpoints[2] != "http://c/v2" {
+		t.Errorf("unexpected endpoints %v", endpoints)
+	}
+}
+
+func TestFactomdPool_Failover(t *testing.T) {
+	a, b := &fakeFactomd{height: 10, down: true}, &fakeFactomd{height: 10}
+	pool, done := newFakeFactomds(t, a, b)
+	defer done()
+
+	cl := factom.NewClient()
+	cl.FactomdServer = pool.URL()
+	cl.Factomd.Transport = pool
+
+	heights := new(factom.Heights)
+	if err := heights.Get(nil, cl); err != nil {
+		t.Fatalf("request did not fail over: %s", err)
+	}
+	if heights.DirectoryBlock != 10 {
+		t.Errorf("expected height 10, got %d", heights.DirectoryBlock)
+	}
+	status := pool.Status()
+	if status[0].Healthy || status[0].Active || !status[1].Active {
+		t.Errorf("expected the second endpoint to be active: %+v", status)
+	}
+
+	b.down = true
+	if err := heights.Get(nil, cl); err == nil {
+		t.Errorf("expected an error with every endpoint down")
+	}
+}
+
+func TestFactomdPool_Check(t *testing.T) {
+	a := &fakeFactomd{height: 10, keyMR: "aa"}
+	b := &fakeFactomd{height: 12, keyMR: "aa"}
+	c := &fakeFactomd{height: 12, keyMR: "bb"}
+	pool, done := newFakeFactomds(t, a, b, c)
+	defer done()
+
+	pool.Check(context.Background())
+	status := pool.Status()
+	if !status[0].Healthy || !status[1].Healthy {
+		t.Errorf("expected the agreeing endpoints to be healthy: %+v", status)
+	}
+	if status[2].Healthy || status[2].Error == "" {
+		t.Errorf("expected the disagreeing endpoint to be unhealthy: %+v", status[2])
+	}
+	if !status[1].Active {
+		t.Errorf("expected the highest healthy endpoint to be active: %+v", status)
+	}
+
+	// A block behind is not worth a switch
+	a.height, b.height = 13, 12
+	pool.Check(context.Background())
+	if status := pool.Status(); !status[1].Active {
+		t.Errorf("expected the active endpoint to be kept: %+v", status)
+	}
+
+	b.down = true
+	pool.Check(context.Background())
+	if status := pool.Status(); !status[0].Active || status[1].Healthy {
+		t.Errorf("expected a switch away from the unresponsive endpoint: %+v", status)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 
 	"github.com/Factom-Asset-Tokens/factom"
 	_ "github.com/mattn/go-sqlite3"
@@ -17,14 +18,44 @@ import (
 
 type Pegnetd struct {
 	FactomClient *factom.Client
+	FactomdPool  *FactomdPool
 	Config       *viper.Viper
 
 	Sync   *pegnet.BlockSync
 	Pegnet *pegnet.Pegnet
 
+	// Pending are the unconfirmed transactions, nil if they are not tracked
+	Pending *PendingPool
+	// Submissions are the transaction entries of send-transaction
+	Submissions *SubmissionQueue
+
 	LastAveragesData   map[fat2.PTicker][]uint64 // The last set of data used to create averages
 	LastAverages       map[fat2.PTicker]uint64   // Cache for averages when requested for the same height
 	LastAveragesHeight uint32                    // Height of the current cache
+
+	// summary is the summary of the block being synced
+	summary *blockSummary
+
+	syncedMu sync.Mutex
+	onSynced []func(summary pegnet.BlockSummary)
+}
+
+// OnBlockSynced registers f to be called with the summary of every block, once
+// it is committed to the database. f is called from the sync loop, and should
+// return quickly.
+func (d *Pegnetd) OnBlockSynced(f func(summary pegnet.BlockSummary)) {
+	d.syncedMu.Lock()
+	defer d.syncedMu.Unlock()
+	d.onSynced = append(d.onSynced, f)
+}
+
+func (d *Pegnetd) blockSynced(summary pegnet.BlockSummary) {
+	d.syncedMu.Lock()
+	fs := d.onSynced
+	d.syncedMu.Unlock()
+	for _, f := range fs {
+		f(summary)
+	}
 }
 
 func NewPegnetd(ctx context.Context, conf *viper.Viper) (*Pegnetd, error) {
@@ -33,8 +64,11 @@ func NewPegnetd(ctx context.Context, conf *viper.Viper) (*Pegnetd, error) {
 
 	// TODO : Update emyrk's factom library
 	n := new(Pegnetd)
-	n.FactomClient = FactomClientFromConfig(conf)
+	n.FactomClient, n.FactomdPool = factomClientFromConfig(conf)
 	n.Config = conf
+	if n.FactomdPool != nil {
+		go n.FactomdPool.Monitor(ctx, conf.GetDuration(config.FactomdHealthCheck))
+	}
 
 	n.Pegnet = pegnet.New(conf)
 	if err := n.Pegnet.Init(); err != nil {
@@ -53,7 +87,7 @@ func NewPegnetd(ctx context.Context, conf *viper.Viper) (*Pegnetd, error) {
 		n.Sync = sync
 	}
 
-	err := n.Pegnet.CheckHardForks(n.Pegnet.DB)
+	err := n.Pegnet.CheckHardForks(ctx, n.Pegnet.DB)
 	if err != nil {
 		err = fmt.Errorf("pegnetd database hardfork check failed: %s", err.Error())
 		if conf.GetBool(config.DisableHardForkCheck) {
@@ -63,13 +97,36 @@ func NewPegnetd(ctx context.Context, conf *viper.Viper) (*Pegnetd, error) {
 		}
 	}
 
+	n.Submissions = newSubmissionQueue(n)
+	n.OnBlockSynced(func(pegnet.BlockSummary) { n.Submissions.notify() })
+
+	if conf.GetDuration(config.PendingPollPeriod) > 0 {
+		n.Pending = NewPendingPool()
+		n.OnBlockSynced(func(pegnet.BlockSummary) { n.Pending.reset() })
+	}
+
 	grader.InitLX()
 	return n, nil
 }
 
 func FactomClientFromConfig(conf *viper.Viper) *factom.Client {
+	cl, _ := factomClientFromConfig(conf)
+	return cl
+}
+
+// factomClientFromConfig also returns the pool of the factomd endpoints, which
+// routes the requests of the client. The pool is nil if the endpoints are
+// invalid, then the client only uses the configured string.
+func factomClientFromConfig(conf *viper.Viper) (*factom.Client, *FactomdPool) {
 	cl := factom.NewClient()
 	cl.FactomdServer = conf.GetString(config.Server)
+	pool, err := NewFactomdPool(FactomdEndpoints(conf.GetStringSlice(config.Server)))
+	if err != nil {
+		log.WithError(err).Warn("invalid factomd endpoints")
+	} else {
+		cl.FactomdServer = pool.URL()
+		cl.Factomd.Transport = pool
+	}
 	cl.WalletdServer = conf.GetString(config.Wallet)
 	if config.WalletUser != "" {
 		cl.Walletd.BasicAuth = true
@@ -77,7 +134,7 @@ func FactomClientFromConfig(conf *viper.Viper) *factom.Client {
 		cl.Walletd.Password = conf.GetString(config.WalletPass)
 	}
 
-	return cl
+	return cl, pool
 }
 
 func InitChainsFromConfig(conf *viper.Viper) {
@@ -2,6 +2,7 @@ package pegnet
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -333,16 +334,16 @@ func (p *Pegnet) CreateTableAddresses() error {
 
 // AddToBalance adds value to the typed balance of adr, creating a new row in
 // "pn_addresses" if it does not exist. If successful, the row id is returned.
-func (p *Pegnet) AddToBalance(tx *sql.Tx, adr *factom.FAAddress, ticker fat2.PTicker, value uint64) (int64, error) {
+func (p *Pegnet) AddToBalance(ctx context.Context, tx *sql.Tx, adr *factom.FAAddress, ticker fat2.PTicker, value uint64) (int64, error) {
 	stmtStringFmt := `INSERT INTO "pn_addresses"
                 ("address", "%[1]s_balance") VALUES (?, ?)
                 ON CONFLICT("address") DO
                 UPDATE SET "%[1]s_balance" = "%[1]s_balance" + "excluded"."%[1]s_balance";`
-	stmt, err := tx.Prepare(fmt.Sprintf(stmtStringFmt, strings.ToLower(ticker.String())))
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(stmtStringFmt, strings.ToLower(ticker.String())))
 	if err != nil {
 		return 0, err
 	}
-	res, err := stmt.Exec(adr[:], value)
+	res, err := stmt.ExecContext(ctx, adr[:], value)
 	if err != nil {
 		return 0, err
 	}
@@ -357,13 +358,13 @@ func (p *Pegnet) AddToBalance(tx *sql.Tx, adr *factom.FAAddress, ticker fat2.PTi
 // "pn_addresses" if it does not exist and value is 0. If successful, the row id is returned,
 // otherwise 0. If subtracting sub would result in a negative balance, txErr is not nil
 // and starts with "insufficient balance".
-func (p *Pegnet) SubFromBalance(tx *sql.Tx, adr *factom.FAAddress, ticker fat2.PTicker, value uint64) (id int64, txError, err error) {
+func (p *Pegnet) SubFromBalance(ctx context.Context, tx *sql.Tx, adr *factom.FAAddress, ticker fat2.PTicker, value uint64) (id int64, txError, err error) {
 	if value == 0 {
 		// Allow tx's with zeros to result in an INSERT.
-		id, err = p.AddToBalance(tx, adr, ticker, 0)
+		id, err = p.AddToBalance(ctx, tx, adr, ticker, 0)
 		return id, nil, err
 	}
-	balance, err := p.SelectPendingBalance(tx, adr, ticker)
+	balance, err := p.SelectPendingBalance(ctx, tx, adr, ticker)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return 0, InsufficientBalanceErr, nil
@@ -376,11 +377,11 @@ func (p *Pegnet) SubFromBalance(tx *sql.Tx, adr *factom.FAAddress, ticker fat2.P
 
 	stmtStringFmt := `UPDATE pn_addresses SET %[1]s_balance = %[1]s_balance - ? WHERE address = ?;`
 	tickerLower := strings.ToLower(ticker.String())
-	stmt, err := tx.Prepare(fmt.Sprintf(stmtStringFmt, tickerLower))
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(stmtStringFmt, tickerLower))
 	if err != nil {
 		return 0, nil, err
 	}
-	res, err := stmt.Exec(value, adr[:])
+	res, err := stmt.ExecContext(ctx, value, adr[:])
 	if err != nil {
 		return 0, nil, err
 	}
@@ -394,14 +395,14 @@ func (p *Pegnet) SubFromBalance(tx *sql.Tx, adr *factom.FAAddress, ticker fat2.P
 // SelectPendingBalance returns the balance of an individual token type for the given
 // address, in the context of a given sql transaction. If the address is not in the
 // database or will not be in the database after the tx is committed, 0 will be returned.
-func (p *Pegnet) SelectPendingBalance(tx *sql.Tx, adr *factom.FAAddress, ticker fat2.PTicker) (uint64, error) {
+func (p *Pegnet) SelectPendingBalance(ctx context.Context, tx *sql.Tx, adr *factom.FAAddress, ticker fat2.PTicker) (uint64, error) {
 	if ticker <= fat2.PTickerInvalid || fat2.PTickerMax <= ticker {
 		return 0, fmt.Errorf("invalid token type")
 	}
 	var balance uint64
 	stmtStringFmt := `SELECT %s_balance FROM pn_addresses WHERE address = ?;`
 	stmt := fmt.Sprintf(stmtStringFmt, strings.ToLower(ticker.String()))
-	err := tx.QueryRow(stmt, adr[:]).Scan(&balance)
+	err := tx.QueryRowContext(ctx, stmt, adr[:]).Scan(&balance)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return 0, nil
@@ -413,14 +414,14 @@ func (p *Pegnet) SelectPendingBalance(tx *sql.Tx, adr *factom.FAAddress, ticker
 
 // SelectBalance returns the balance of an individual token type for the given
 // address. If the address is not in the database, 0 will be returned.
-func (p *Pegnet) SelectBalance(adr *factom.FAAddress, ticker fat2.PTicker) (uint64, error) {
+func (p *Pegnet) SelectBalance(ctx context.Context, adr *factom.FAAddress, ticker fat2.PTicker) (uint64, error) {
 	if ticker <= fat2.PTickerInvalid || fat2.PTickerMax <= ticker {
 		return 0, fmt.Errorf("invalid token type")
 	}
 	var balance uint64
 	stmtStringFmt := `SELECT %s_balance FROM pn_addresses WHERE address = ?;`
 	stmt := fmt.Sprintf(stmtStringFmt, strings.ToLower(ticker.String()))
-	err := p.DB.QueryRow(stmt, adr[:]).Scan(&balance)
+	err := p.DB.QueryRowContext(ctx, stmt, adr[:]).Scan(&balance)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return 0, nil
@@ -439,11 +440,11 @@ type BalancesPair struct {
 	Balances []uint64
 }
 
-func (p *Pegnet) IsIncludedTopPEGAddress(address []byte) bool {
+func (p *Pegnet) IsIncludedTopPEGAddress(ctx context.Context, address []byte) bool {
 	count := 100 // Top 100 addresses
 	stmtStringFmt := `SELECT address, %[1]s_balance FROM pn_addresses WHERE %[1]s_balance > 0 ORDER BY %[1]s_balance DESC LIMIT ?;`
 	stmt := fmt.Sprintf(stmtStringFmt, strings.ToLower("PEG"))
-	rows, err := p.DB.Query(stmt, count)
+	rows, err := p.DB.QueryContext(ctx, stmt, count)
 	if err != nil {
 		return false
 	}
@@ -463,7 +464,7 @@ func (p *Pegnet) IsIncludedTopPEGAddress(address []byte) bool {
 }
 
 // SelectRichList returns the balance of all addresses for a given ticker
-func (p *Pegnet) SelectRichList(ticker fat2.PTicker, count int) ([]BalancePair, error) {
+func (p *Pegnet) SelectRichList(ctx context.Context, ticker fat2.PTicker, count int) ([]BalancePair, error) {
 	if ticker <= fat2.PTickerInvalid || fat2.PTickerMax <= ticker {
 		return nil, fmt.Errorf("invalid token type")
 	}
@@ -473,7 +474,7 @@ func (p *Pegnet) SelectRichList(ticker fat2.PTicker, count int) ([]BalancePair,
 	var res []BalancePair
 	stmtStringFmt := `SELECT address, %[1]s_balance FROM pn_addresses WHERE %[1]s_balance > 0 ORDER BY %[1]s_balance DESC LIMIT ?;`
 	stmt := fmt.Sprintf(stmtStringFmt, strings.ToLower(ticker.String()))
-	rows, err := p.DB.Query(stmt, count)
+	rows, err := p.DB.QueryContext(ctx, stmt, count)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -503,21 +504,21 @@ func (p *Pegnet) SelectRichList(ticker fat2.PTicker, count int) ([]BalancePair,
 // SelectPendingBalances returns a map of all valid PTickers and their associated
 // balances for the given address. If the address is not in the database,
 // the map will contain 0 for all valid PTickers. This works on the pending tx
-func (p *Pegnet) SelectPendingBalances(tx *sql.Tx, adr *factom.FAAddress) (map[fat2.PTicker]uint64, error) {
-	return p.selectBalances(tx, adr)
+func (p *Pegnet) SelectPendingBalances(ctx context.Context, tx *sql.Tx, adr *factom.FAAddress) (map[fat2.PTicker]uint64, error) {
+	return p.selectBalances(ctx, tx, adr)
 }
 
 // SelectBalances returns a map of all valid PTickers and their associated
 // balances for the given address. If the address is not in the database,
 // the map will contain 0 for all valid PTickers.
-func (p *Pegnet) SelectBalances(adr *factom.FAAddress) (map[fat2.PTicker]uint64, error) {
-	return p.selectBalances(p.DB, adr)
+func (p *Pegnet) SelectBalances(ctx context.Context, adr *factom.FAAddress) (map[fat2.PTicker]uint64, error) {
+	return p.selectBalances(ctx, p.DB, adr)
 }
 
 // SelectPendingBalances returns a map of all valid PTickers and their associated
 // balances for the given address. If the address is not in the database,
 // the map will contain 0 for all valid PTickers. This works on the pending tx
-func (Pegnet) selectBalances(q QueryAble, adr *factom.FAAddress) (map[fat2.PTicker]uint64, error) {
+func (Pegnet) selectBalances(ctx context.Context, q QueryAble, adr *factom.FAAddress) (map[fat2.PTicker]uint64, error) {
 	balanceMap := make(map[fat2.PTicker]uint64, int(fat2.PTickerMax))
 	for i := fat2.PTickerInvalid + 1; i < fat2.PTickerMax; i++ {
 		balanceMap[i] = 0
@@ -527,7 +528,7 @@ func (Pegnet) selectBalances(q QueryAble, adr *factom.FAAddress) (map[fat2.PTick
 	var id int
 	var address []byte
 	query := fmt.Sprintf(`SELECT %s FROM pn_addresses WHERE address = ?;`, addressSelectCols)
-	err := q.QueryRow(query, adr[:]).Scan(
+	err := q.QueryRowContext(ctx, query, adr[:]).Scan(
 		&id,
 		&address,
 		&balances[fat2.PTickerPEG],
@@ -610,9 +611,9 @@ func (Pegnet) selectBalances(q QueryAble, adr *factom.FAAddress) (map[fat2.PTick
 // SelectPendingBalances returns a map of all valid PTickers and their associated
 // balances for the given address. If the address is not in the database,
 // the map will contain 0 for all valid PTickers. This works on the pending tx
-func (p *Pegnet) SelectAllBalances() ([]BalancesPair, error) {
+func (p *Pegnet) SelectAllBalances(ctx context.Context) ([]BalancesPair, error) {
 	query := fmt.Sprintf(`SELECT %s FROM pn_addresses;`, addressSelectCols)
-	rows, err := p.DB.Query(query)
+	rows, err := p.DB.QueryContext(ctx, query)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -709,7 +710,7 @@ func (p *Pegnet) SelectAllBalances() ([]BalancesPair, error) {
 	return res, nil
 }
 
-func (p *Pegnet) SelectIssuances() (map[fat2.PTicker]uint64, error) {
+func (p *Pegnet) SelectIssuances(ctx context.Context) (map[fat2.PTicker]uint64, error) {
 	issuanceMap := make(map[fat2.PTicker]uint64, int(fat2.PTickerMax))
 	for i := fat2.PTickerInvalid + 1; i < fat2.PTickerMax; i++ {
 		issuanceMap[i] = 0
@@ -724,7 +725,7 @@ func (p *Pegnet) SelectIssuances() (map[fat2.PTicker]uint64, error) {
 	}
 	tickerLower := strings.ToLower((fat2.PTickerMax - 1).String())
 	sb.WriteString(fmt.Sprintf("IFNULL(SUM(%s_balance), 0) ", tickerLower))
-	err := p.DB.QueryRow(fmt.Sprintf(queryFmt, sb.String())).Scan(
+	err := p.DB.QueryRowContext(ctx, fmt.Sprintf(queryFmt, sb.String())).Scan(
 		&issuances[fat2.PTickerPEG],
 		&issuances[fat2.PTickerUSD],
 		&issuances[fat2.PTickerEUR],
@@ -38,39 +38,42 @@ func tearDownPegnet(p *Pegnet) {
 }
 
 func TestPegnet_SelectBalance_Empty(t *testing.T) {
+	ctx := context.Background()
 	p, err := setupPegnet()
 	require.NoError(t, err)
 	defer tearDownPegnet(p)
 
 	var adr factom.FAAddress
-	balance, err := p.SelectBalance(&adr, fat2.PTickerPEG)
+	balance, err := p.SelectBalance(ctx, &adr, fat2.PTickerPEG)
 	require.NoError(t, err)
 	assert.Equal(t, uint64(0), balance)
 }
 
 func TestPegnet_SelectBalance_InvalidTicker(t *testing.T) {
+	ctx := context.Background()
 	p, err := setupPegnet()
 	require.NoError(t, err)
 	defer tearDownPegnet(p)
 
 	var adr factom.FAAddress
-	_, err = p.SelectBalance(&adr, fat2.PTicker(-1))
+	_, err = p.SelectBalance(ctx, &adr, fat2.PTicker(-1))
 	assert.EqualError(t, err, "invalid token type")
-	_, err = p.SelectBalance(&adr, fat2.PTickerInvalid)
+	_, err = p.SelectBalance(ctx, &adr, fat2.PTickerInvalid)
 	assert.EqualError(t, err, "invalid token type")
-	_, err = p.SelectBalance(&adr, fat2.PTickerMax)
+	_, err = p.SelectBalance(ctx, &adr, fat2.PTickerMax)
 	assert.EqualError(t, err, "invalid token type")
-	_, err = p.SelectBalance(&adr, fat2.PTicker(1000000000))
+	_, err = p.SelectBalance(ctx, &adr, fat2.PTicker(1000000000))
 	assert.EqualError(t, err, "invalid token type")
 }
 
 func TestPegnet_SelectBalances_Empty(t *testing.T) {
+	ctx := context.Background()
 	p, err := setupPegnet()
 	require.NoError(t, err)
 	defer tearDownPegnet(p)
 
 	var adr factom.FAAddress
-	balances, err := p.SelectBalances(&adr)
+	balances, err := p.SelectBalances(ctx, &adr)
 	require.NoError(t, err)
 	require.Equal(t, int(fat2.PTickerMax)-1, len(balances), "Unexpected number of balances returned")
 	for i := fat2.PTickerInvalid + 1; i < fat2.PTickerMax; i++ {
@@ -78,7 +81,20 @@ func TestPegnet_SelectBalances_Empty(t *testing.T) {
 	}
 }
 
+func TestPegnet_SelectBalances_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p, err := setupPegnet()
+	require.NoError(t, err)
+	defer tearDownPegnet(p)
+
+	var adr factom.FAAddress
+	_, err = p.SelectBalances(ctx, &adr)
+	assert.Equal(t, context.Canceled, err)
+}
+
 func TestPegnet_AddToBalance(t *testing.T) {
+	ctx := context.Background()
 	p, err := setupPegnet()
 	require.NoError(t, err)
 	defer tearDownPegnet(p)
@@ -87,26 +103,27 @@ func TestPegnet_AddToBalance(t *testing.T) {
 	require.NoError(t, err)
 
 	var adr factom.FAAddress
-	_, err = p.AddToBalance(tx, &adr, fat2.PTickerPEG, 100)
+	_, err = p.AddToBalance(ctx, tx, &adr, fat2.PTickerPEG, 100)
 	require.NoError(t, err)
 
-	balance, err := p.SelectPendingBalance(tx, &adr, fat2.PTickerPEG)
+	balance, err := p.SelectPendingBalance(ctx, tx, &adr, fat2.PTickerPEG)
 	require.NoError(t, err)
 	assert.Equal(t, uint64(100), balance, "Incorrect pending balance before tx.Commit()")
 
-	balance, err = p.SelectBalance(&adr, fat2.PTickerPEG)
+	balance, err = p.SelectBalance(ctx, &adr, fat2.PTickerPEG)
 	require.NoError(t, err)
 	assert.Equal(t, uint64(0), balance, "Incorrect finalized balance before tx.Commit()")
 
 	err = tx.Commit()
 	require.NoError(t, err)
 
-	balance, err = p.SelectBalance(&adr, fat2.PTickerPEG)
+	balance, err = p.SelectBalance(ctx, &adr, fat2.PTickerPEG)
 	require.NoError(t, err)
 	assert.Equal(t, uint64(100), balance, "Incorrect finalized balance after tx.Commit()")
 }
 
 func TestPegnet_SubFromBalance(t *testing.T) {
+	ctx := context.Background()
 	p, err := setupPegnet()
 	require.NoError(t, err)
 	defer tearDownPegnet(p)
@@ -115,28 +132,28 @@ func TestPegnet_SubFromBalance(t *testing.T) {
 	require.NoError(t, err)
 
 	var adr factom.FAAddress
-	_, txErr, err := p.SubFromBalance(tx, &adr, fat2.PTickerPEG, 100)
+	_, txErr, err := p.SubFromBalance(ctx, tx, &adr, fat2.PTickerPEG, 100)
 	require.NoError(t, err)
 	assert.EqualError(t, txErr, InsufficientBalanceErr.Error())
 
-	_, err = p.AddToBalance(tx, &adr, fat2.PTickerPEG, 100)
+	_, err = p.AddToBalance(ctx, tx, &adr, fat2.PTickerPEG, 100)
 	require.NoError(t, err)
-	_, txErr, err = p.SubFromBalance(tx, &adr, fat2.PTickerPEG, 50)
+	_, txErr, err = p.SubFromBalance(ctx, tx, &adr, fat2.PTickerPEG, 50)
 	require.NoError(t, err)
 	require.NoError(t, txErr)
 
-	balance, err := p.SelectPendingBalance(tx, &adr, fat2.PTickerPEG)
+	balance, err := p.SelectPendingBalance(ctx, tx, &adr, fat2.PTickerPEG)
 	require.NoError(t, err)
 	assert.Equal(t, uint64(50), balance, "Incorrect pending balance before tx.Commit()")
 
-	balance, err = p.SelectBalance(&adr, fat2.PTickerPEG)
+	balance, err = p.SelectBalance(ctx, &adr, fat2.PTickerPEG)
 	require.NoError(t, err)
 	assert.Equal(t, uint64(0), balance, "Incorrect finalized balance before tx.Commit()")
 
 	err = tx.Commit()
 	require.NoError(t, err)
 
-	balance, err = p.SelectBalance(&adr, fat2.PTickerPEG)
+	balance, err = p.SelectBalance(ctx, &adr, fat2.PTickerPEG)
 	require.NoError(t, err)
 	assert.Equal(t, uint64(50), balance, "Incorrect finalized balance after tx.Commit()")
 }
@@ -65,38 +65,38 @@ func (p *Pegnet) CreateTableSyncVersion() error {
 	return nil
 }
 
-func (p Pegnet) MarkHeightSynced(tx QueryAble, height uint32) error {
-	return p.markHeightSyncedVersion(tx, height, PegnetdSyncVersion)
+func (p Pegnet) MarkHeightSynced(ctx context.Context, tx QueryAble, height uint32) error {
+	return p.markHeightSyncedVersion(ctx, tx, height, PegnetdSyncVersion)
 }
 
-func (Pegnet) markHeightSyncedVersion(tx QueryAble, height uint32, version int) error {
+func (Pegnet) markHeightSyncedVersion(ctx context.Context, tx QueryAble, height uint32, version int) error {
 	stmtStringFmt := `INSERT INTO "pn_sync_version" 
 			("height", "version", "unix_timestamp")
 			VALUES (?, ?, ?);`
 
-	stmt, err := tx.Prepare(stmtStringFmt)
+	stmt, err := tx.PrepareContext(ctx, stmtStringFmt)
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(height, version, time.Now().Unix())
+	_, err = stmt.ExecContext(ctx, height, version, time.Now().Unix())
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (p Pegnet) HighestSynced(tx QueryAble) (uint32, error) {
-	return p.synced("max", tx)
+func (p Pegnet) HighestSynced(ctx context.Context, tx QueryAble) (uint32, error) {
+	return p.synced(ctx, "max", tx)
 }
 
-func (p Pegnet) LowestSynced(tx QueryAble) (uint32, error) {
-	return p.synced("min", tx)
+func (p Pegnet) LowestSynced(ctx context.Context, tx QueryAble) (uint32, error) {
+	return p.synced(ctx, "min", tx)
 }
 
-func (Pegnet) synced(adj string, tx QueryAble) (uint32, error) {
+func (Pegnet) synced(ctx context.Context, adj string, tx QueryAble) (uint32, error) {
 	var height uint32
-	err := tx.QueryRow(fmt.Sprintf(`SELECT COALESCE(%s(height), 0) FROM pn_sync_version;`, adj)).Scan(&height)
+	err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT COALESCE(%s(height), 0) FROM pn_sync_version;`, adj)).Scan(&height)
 	if err == sql.ErrNoRows {
 		return 0, nil
 	}
@@ -104,9 +104,9 @@ func (Pegnet) synced(adj string, tx QueryAble) (uint32, error) {
 }
 
 // FetchMinSyncedVersion returns -1, nil if the height was not found
-func (Pegnet) FetchMinSyncedVersion(tx QueryAble, height uint32) (int, error) {
+func (Pegnet) FetchMinSyncedVersion(ctx context.Context, tx QueryAble, height uint32) (int, error) {
 	var version int
-	err := tx.QueryRow(`SELECT COALESCE(MIN(version), -1) FROM pn_sync_version WHERE height >= ?;`, height).Scan(&version)
+	err := tx.QueryRowContext(ctx, `SELECT COALESCE(MIN(version), -1) FROM pn_sync_version WHERE height >= ?;`, height).Scan(&version)
 	if err != nil {
 		return -1, err
 	}
@@ -114,9 +114,9 @@ func (Pegnet) FetchMinSyncedVersion(tx QueryAble, height uint32) (int, error) {
 }
 
 // FetchMaxSyncedVersion returns -1, nil if the height was not found
-func (Pegnet) FetchMaxSyncedVersion(tx QueryAble, height uint32) (int, error) {
+func (Pegnet) FetchMaxSyncedVersion(ctx context.Context, tx QueryAble, height uint32) (int, error) {
 	var version int
-	err := tx.QueryRow(`SELECT COALESCE(MAX(version), -1) FROM pn_sync_version WHERE height >= ?;`, height).Scan(&version)
+	err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), -1) FROM pn_sync_version WHERE height >= ?;`, height).Scan(&version)
 	if err != nil {
 		return -1, err
 	}
@@ -125,10 +125,10 @@ func (Pegnet) FetchMaxSyncedVersion(tx QueryAble, height uint32) (int, error) {
 
 // CheckHardForks will iterate over all the hardforks post the version_lock
 // update, and verify the version that was used to sync was appropriate.
-func (p Pegnet) CheckHardForks(tx QueryAble) error {
+func (p Pegnet) CheckHardForks(ctx context.Context, tx QueryAble) error {
 	// The lowest synced height is the lowest synced height after
 	// version_lock feature is included.
-	minSynced, err := p.LowestSynced(tx)
+	minSynced, err := p.LowestSynced(ctx, tx)
 	if err != nil {
 		return err
 	}
@@ -143,12 +143,12 @@ func (p Pegnet) CheckHardForks(tx QueryAble) error {
 		for _, event := range Hardforks {
 			// If we are past the hardfork, put in a -1
 			if bs.Synced > event.ActivationHeight {
-				_ = p.markHeightSyncedVersion(tx, event.ActivationHeight, -1)
+				_ = p.markHeightSyncedVersion(ctx, tx, event.ActivationHeight, -1)
 			}
 		}
 	}
 
-	top, err := p.HighestSynced(tx)
+	top, err := p.HighestSynced(ctx, tx)
 	if err != nil {
 		return err
 	}
@@ -156,7 +156,7 @@ func (p Pegnet) CheckHardForks(tx QueryAble) error {
 	for _, event := range Hardforks {
 		// If the event is not synced past, then we do not need to check
 		if event.ActivationHeight <= top {
-			version, err := p.FetchMinSyncedVersion(tx, event.ActivationHeight)
+			version, err := p.FetchMinSyncedVersion(ctx, tx, event.ActivationHeight)
 			if err != nil {
 				return err
 			}
@@ -169,7 +169,7 @@ func (p Pegnet) CheckHardForks(tx QueryAble) error {
 	// Catch downgrade with the hardfork check code in it
 	// If our PegnetdSyncVersion is less than the highest version we have in
 	// our db, then we downgraded
-	max, err := p.FetchMaxSyncedVersion(tx, 0)
+	max, err := p.FetchMaxSyncedVersion(ctx, tx, 0)
 	if err != nil {
 		return err
 	}
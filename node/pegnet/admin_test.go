@@ -1,6 +1,7 @@
 package pegnet_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -8,6 +9,7 @@ import (
 )
 
 func TestPegnet_CheckHardForks(t *testing.T) {
+	ctx := context.Background()
 	db, err := sql.Open("sqlite3", ":memory:")
 	if err != nil {
 		t.Error(err)
@@ -20,7 +22,7 @@ func TestPegnet_CheckHardForks(t *testing.T) {
 	}
 
 	t.Run("test blank db", func(t *testing.T) {
-		if err := p.CheckHardForks(p.DB); err != nil {
+		if err := p.CheckHardForks(ctx, p.DB); err != nil {
 			t.Errorf("blank db error: %v", err)
 		}
 	})
@@ -42,10 +44,10 @@ func TestPegnet_CheckHardForks(t *testing.T) {
 	t.Run("test all versions updated", func(t *testing.T) {
 		for i := 0; i < 10; i++ {
 			pegnet.PegnetdSyncVersion = i
-			_ = p.MarkHeightSynced(p.DB, uint32(i))
+			_ = p.MarkHeightSynced(ctx, p.DB, uint32(i))
 		}
 
-		if err := p.CheckHardForks(p.DB); err != nil {
+		if err := p.CheckHardForks(ctx, p.DB); err != nil {
 			t.Errorf("correct db error: %v", err)
 		}
 	})
@@ -53,9 +55,9 @@ func TestPegnet_CheckHardForks(t *testing.T) {
 	// Height 15
 	t.Run("test version high above", func(t *testing.T) {
 		pegnet.PegnetdSyncVersion = 500
-		_ = p.MarkHeightSynced(p.DB, uint32(15))
+		_ = p.MarkHeightSynced(ctx, p.DB, uint32(15))
 
-		if err := p.CheckHardForks(p.DB); err != nil {
+		if err := p.CheckHardForks(ctx, p.DB); err != nil {
 			t.Errorf("correct db error: %v", err)
 		}
 	})
@@ -63,9 +65,9 @@ func TestPegnet_CheckHardForks(t *testing.T) {
 	// Height 20
 	t.Run("test version 1 behind", func(t *testing.T) {
 		pegnet.PegnetdSyncVersion = 19
-		_ = p.MarkHeightSynced(p.DB, uint32(20))
+		_ = p.MarkHeightSynced(ctx, p.DB, uint32(20))
 
-		if err := p.CheckHardForks(p.DB); err == nil {
+		if err := p.CheckHardForks(ctx, p.DB); err == nil {
 			t.Errorf("expected error, found none")
 		}
 	})
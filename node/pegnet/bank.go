@@ -1,6 +1,7 @@
 package pegnet
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -58,13 +59,13 @@ func (p *Pegnet) CreateTableBank() error {
 	return nil
 }
 
-func (p Pegnet) SelectBankEntry(q QueryAble, height int32) (entry BankEntry, err error) {
+func (p Pegnet) SelectBankEntry(ctx context.Context, q QueryAble, height int32) (entry BankEntry, err error) {
 	if q == nil {
 		q = p.DB // nil defaults to db
 	}
 
 	query := fmt.Sprintf(`SELECT "height", "bank_amount", "bank_used", "total_requested" FROM pn_bank WHERE height = ?;`)
-	err = q.QueryRow(query, height).Scan(&entry.Height, &entry.BankAmount, &entry.BankUsed, &entry.PEGRequested)
+	err = q.QueryRowContext(ctx, query, height).Scan(&entry.Height, &entry.BankAmount, &entry.BankUsed, &entry.PEGRequested)
 	if err == sql.ErrNoRows {
 		entry = BankEntry{Height: -1, BankAmount: -1}
 		err = nil
@@ -74,13 +75,13 @@ func (p Pegnet) SelectBankEntry(q QueryAble, height int32) (entry BankEntry, err
 
 // InsertBankAmount does not fill in the bank_used and total_requested with
 // legit values. It leaves a -1 to indicate that needs to be filled.
-func (p Pegnet) InsertBankAmount(q QueryAble, height int32, bankAmount int64) error {
+func (p Pegnet) InsertBankAmount(ctx context.Context, q QueryAble, height int32, bankAmount int64) error {
 	if q == nil {
 		q = p.DB // nil defaults to db
 	}
 
 	query := fmt.Sprintf(`INSERT INTO pn_bank("height", "bank_amount", "bank_used", "total_requested") VALUES(?, ?, -1, -1);`)
-	res, err := q.Exec(query, height, bankAmount)
+	res, err := q.ExecContext(ctx, query, height, bankAmount)
 	if err != nil {
 		return err
 	}
@@ -93,7 +94,7 @@ func (p Pegnet) InsertBankAmount(q QueryAble, height int32, bankAmount int64) er
 }
 
 // UpdateBankEntry updates the bank_used and total_requested values
-func (p Pegnet) UpdateBankEntry(q QueryAble, height int32, bankUsed, pegRequested int64) error {
+func (p Pegnet) UpdateBankEntry(ctx context.Context, q QueryAble, height int32, bankUsed, pegRequested int64) error {
 	if q == nil {
 		q = p.DB // nil defaults to db
 	}
@@ -105,7 +106,7 @@ func (p Pegnet) UpdateBankEntry(q QueryAble, height int32, bankUsed, pegRequeste
 		WHERE height = ?;
 `)
 
-	res, err := q.Exec(query, bankUsed, pegRequested, height)
+	res, err := q.ExecContext(ctx, query, bankUsed, pegRequested, height)
 	if err != nil {
 		return err
 	}
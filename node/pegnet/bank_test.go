@@ -1,6 +1,7 @@
 package pegnet_test
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -8,6 +9,7 @@ import (
 )
 
 func TestPegnet_BankTable(t *testing.T) {
+	ctx := context.Background()
 	db, err := sql.Open("sqlite3", ":memory:")
 	if err != nil {
 		t.Error(err)
@@ -20,7 +22,7 @@ func TestPegnet_BankTable(t *testing.T) {
 	}
 
 	t.Run("no row", func(t *testing.T) {
-		entry, err := p.SelectBankEntry(nil, 10)
+		entry, err := p.SelectBankEntry(ctx, nil, 10)
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
@@ -30,29 +32,29 @@ func TestPegnet_BankTable(t *testing.T) {
 	})
 
 	t.Run("duplicate entry", func(t *testing.T) {
-		err := p.InsertBankAmount(nil, 10, 5000)
+		err := p.InsertBankAmount(ctx, nil, 10, 5000)
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
 
-		err = p.InsertBankAmount(nil, 10, 5000)
+		err = p.InsertBankAmount(ctx, nil, 10, 5000)
 		if err == nil {
 			t.Errorf("expected error, got none")
 		}
 	})
 
 	t.Run("update not exists entry", func(t *testing.T) {
-		err := p.UpdateBankEntry(nil, 9, 0, 0)
+		err := p.UpdateBankEntry(ctx, nil, 9, 0, 0)
 		if err == nil {
 			t.Errorf("expected error, got none")
 		}
 
-		err = p.InsertBankAmount(nil, 9, 5000)
+		err = p.InsertBankAmount(ctx, nil, 9, 5000)
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
 
-		err = p.UpdateBankEntry(nil, 9, 0, 0)
+		err = p.UpdateBankEntry(ctx, nil, 9, 0, 0)
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
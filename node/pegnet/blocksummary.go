@@ -0,0 +1,159 @@
+package pegnet
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+)
+
+// pn_block_summary
+
+// What syncing each height did, so it can be answered without replaying the
+// grading, the conversions and the payouts. Heights synced before the table
+// existed have no row.
+const createTableBlockSummary = `CREATE TABLE IF NOT EXISTS "pn_block_summary" (
+        "height"        INTEGER NOT NULL PRIMARY KEY,
+        "keymr"         BLOB NOT NULL,    -- the DBlock KeyMR
+        "timestamp"     INTEGER NOT NULL,
+        "opr_entries"   INTEGER NOT NULL,
+        "spr_entries"   INTEGER NOT NULL,
+        "opr_winners"   BLOB NOT NULL,    -- json of the winners, best first
+        "spr_winners"   BLOB NOT NULL,
+        "rates"         INTEGER NOT NULL, -- 1 if the height has rates
+        "held"          INTEGER NOT NULL,
+        "executed"      INTEGER NOT NULL,
+        "rejected"      INTEGER NOT NULL,
+        "bank"          INTEGER NOT NULL, -- 0 for the heights without a bank
+        "peg_requested" INTEGER NOT NULL,
+        "peg_allocated" INTEGER NOT NULL,
+        "payouts"       BLOB NOT NULL     -- json of the PEG paid out by category
+);
+`
+
+// The categories of the payouts of a block
+const (
+	PayoutMiners     = "miners"     // The OPR winners
+	PayoutStakers    = "stakers"    // The SPR winners
+	PayoutHolders    = "holders"    // The snapshot staking of the balances
+	PayoutDevelopers = "developers" // The developer rewards
+)
+
+// BlockSummary is what syncing a height did. PEG amounts are in PEGtoshi.
+type BlockSummary struct {
+	Height    uint32         `json:"height"`
+	KeyMR     factom.Bytes32 `json:"keymr"`
+	Timestamp time.Time      `json:"timestamp"`
+
+	// The entries in the OPR and SPR chains, and the graded winners
+	OPREntries int           `json:"oprentries"`
+	SPREntries int           `json:"sprentries"`
+	OPRWinners []BlockWinner `json:"oprwinners"`
+	SPRWinners []BlockWinner `json:"sprwinners"`
+
+	// Rates is true if the height has rates, which executes the conversions
+	// in holding
+	Rates bool `json:"rates"`
+
+	// The transaction batches of the height put into holding for the next
+	// height with rates, and the batches executed and rejected at the height
+	Held     int `json:"held"`
+	Executed int `json:"executed"`
+	Rejected int `json:"rejected"`
+
+	// The PEG bank of the height, and the PEG conversions requested from it
+	// and allocated by it
+	Bank         uint64 `json:"bank"`
+	PEGRequested uint64 `json:"pegrequested"`
+	PEGAllocated uint64 `json:"pegallocated"`
+
+	// Payouts are the PEG paid out at the height, by category
+	Payouts map[string]uint64 `json:"payouts"`
+}
+
+// BlockWinner is a winning OPR or SPR of a height
+type BlockWinner struct {
+	EntryHash factom.Bytes `json:"entryhash"`
+	Address   string       `json:"address"`
+	Payout    int64        `json:"payout"`
+}
+
+// InsertBlockSummary records the summary of a synced height
+func (Pegnet) InsertBlockSummary(ctx context.Context, tx *sql.Tx, summary BlockSummary) error {
+	oprWinners, err := json.Marshal(summary.OPRWinners)
+	if err != nil {
+		return err
+	}
+	sprWinners, err := json.Marshal(summary.SPRWinners)
+	if err != nil {
+		return err
+	}
+	payouts, err := json.Marshal(summary.Payouts)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `REPLACE INTO "pn_block_summary" ("height", "keymr", "timestamp", "opr_entries", "spr_entries",
+		"opr_winners", "spr_winners", "rates", "held", "executed", "rejected", "bank", "peg_requested", "peg_allocated", "payouts")
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		summary.Height, summary.KeyMR[:], summary.Timestamp.Unix(), summary.OPREntries, summary.SPREntries,
+		oprWinners, sprWinners, summary.Rates, summary.Held, summary.Executed, summary.Rejected,
+		int64(summary.Bank), int64(summary.PEGRequested), int64(summary.PEGAllocated), payouts)
+	return err
+}
+
+const selectBlockSummary = `SELECT "height", "keymr", "timestamp", "opr_entries", "spr_entries", "opr_winners",
+	"spr_winners", "rates", "held", "executed", "rejected", "bank", "peg_requested", "peg_allocated", "payouts"
+	FROM "pn_block_summary"`
+
+// SelectBlockSummary returns the summary of a height. sql.ErrNoRows is
+// returned if the height was synced before summaries were recorded.
+func (Pegnet) SelectBlockSummary(ctx context.Context, q QueryAble, height uint32) (BlockSummary, error) {
+	return scanBlockSummary(q.QueryRowContext(ctx, selectBlockSummary+` WHERE "height" = ?;`, height))
+}
+
+// SelectBlockSummaries returns the summaries of the heights in (after, to],
+// ascending, at most limit. Heights without a summary are left out.
+func (Pegnet) SelectBlockSummaries(ctx context.Context, q QueryAble, after, to uint32, limit int) ([]BlockSummary, error) {
+	rows, err := q.QueryContext(ctx, selectBlockSummary+` WHERE "height" > ? AND "height" <= ? ORDER BY "height" LIMIT ?;`,
+		after, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []BlockSummary
+	for rows.Next() {
+		summary, err := scanBlockSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+func scanBlockSummary(row interface{ Scan(...interface{}) error }) (BlockSummary, error) {
+	var summary BlockSummary
+	var keyMR, oprWinners, sprWinners, payouts []byte
+	var timestamp, bank, requested, allocated int64
+	err := row.Scan(&summary.Height, &keyMR, &timestamp, &summary.OPREntries, &summary.SPREntries, &oprWinners, &sprWinners,
+		&summary.Rates, &summary.Held, &summary.Executed, &summary.Rejected, &bank, &requested, &allocated, &payouts)
+	if err != nil {
+		return summary, err
+	}
+	copy(summary.KeyMR[:], keyMR)
+	summary.Timestamp = time.Unix(timestamp, 0)
+	summary.Bank, summary.PEGRequested, summary.PEGAllocated = uint64(bank), uint64(requested), uint64(allocated)
+	if err := json.Unmarshal(oprWinners, &summary.OPRWinners); err != nil {
+		return summary, err
+	}
+	if err := json.Unmarshal(sprWinners, &summary.SPRWinners); err != nil {
+		return summary, err
+	}
+	if err := json.Unmarshal(payouts, &summary.Payouts); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,80 @@
+package pegnet
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPegnet_BlockSummary(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "blocksummary")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "sql.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(createTableBlockSummary)
+	require.NoError(t, err)
+	p := &Pegnet{DB: db}
+
+	summary := BlockSummary{
+		Height:     10,
+		KeyMR:      factom.NewBytes32("cffce0f409ebba4ed236d49d89c70e4bd1f1367d86402a3363366683265a242d"),
+		Timestamp:  time.Unix(1589276400, 0),
+		OPREntries: 30,
+		OPRWinners: []BlockWinner{{
+			EntryHash: factom.Bytes{0x01, 0x02},
+			Address:   "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q",
+			Payout:    180000000000,
+		}},
+		SPRWinners:   []BlockWinner{},
+		Rates:        true,
+		Held:         2,
+		Executed:     3,
+		Rejected:     1,
+		Bank:         500000000000,
+		PEGRequested: 700000000000,
+		PEGAllocated: 500000000000,
+		Payouts:      map[string]uint64{PayoutMiners: 180000000000},
+	}
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, p.InsertBlockSummary(ctx, tx, summary))
+	require.NoError(t, tx.Commit())
+
+	stored, err := p.SelectBlockSummary(ctx, db, 10)
+	require.NoError(t, err)
+	assert.Equal(t, summary, stored)
+
+	_, err = p.SelectBlockSummary(ctx, db, 9)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	for _, height := range []uint32{12, 13} {
+		next := summary
+		next.Height = height
+		require.NoError(t, p.InsertBlockSummary(ctx, tx, next))
+	}
+	require.NoError(t, tx.Commit())
+
+	summaries, err := p.SelectBlockSummaries(ctx, db, 9, 13, 2)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, summary, summaries[0])
+	assert.Equal(t, uint32(12), summaries[1].Height, "heights without a summary are left out")
+	summaries, err = p.SelectBlockSummaries(ctx, db, 12, 12, 10)
+	require.NoError(t, err)
+	assert.Empty(t, summaries)
+}
@@ -0,0 +1,71 @@
+package pegnet
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Factom-Asset-Tokens/factom"
+)
+
+// pn_dblocks
+
+// The KeyMRs of every synced height, so the history pegnetd synced on can be
+// checked against factomd. The EBlock KeyMRs are null for heights without an
+// entry in the chain.
+const createTableDBlocks = `CREATE TABLE IF NOT EXISTS "pn_dblocks" (
+        "height"        INTEGER NOT NULL PRIMARY KEY,
+        "keymr"         BLOB NOT NULL,  -- the DBlock KeyMR
+        "opr_eblock"    BLOB,           -- the EBlock KeyMRs of the pegnet chains
+        "spr_eblock"    BLOB,
+        "tx_eblock"     BLOB
+);
+`
+
+// DBlockKeyMRs are the KeyMRs recorded for a height
+type DBlockKeyMRs struct {
+	Height      uint32
+	KeyMR       factom.Bytes32
+	OPREBlock   *factom.Bytes32
+	SPREBlock   *factom.Bytes32
+	TransEBlock *factom.Bytes32
+}
+
+// InsertDBlock records the KeyMRs of a synced height
+func (Pegnet) InsertDBlock(ctx context.Context, tx *sql.Tx, keyMRs DBlockKeyMRs) error {
+	_, err := tx.ExecContext(ctx, `REPLACE INTO "pn_dblocks" ("height", "keymr", "opr_eblock", "spr_eblock", "tx_eblock") VALUES (?, ?, ?, ?, ?);`,
+		keyMRs.Height, keyMRs.KeyMR[:], optionalBytes32(keyMRs.OPREBlock), optionalBytes32(keyMRs.SPREBlock), optionalBytes32(keyMRs.TransEBlock))
+	return err
+}
+
+// SelectDBlock returns the KeyMRs recorded for a height. sql.ErrNoRows is
+// returned if the height was synced before the KeyMRs were recorded.
+func (Pegnet) SelectDBlock(ctx context.Context, q QueryAble, height uint32) (DBlockKeyMRs, error) {
+	keyMRs := DBlockKeyMRs{Height: height}
+	var keyMR, opr, spr, trans []byte
+	err := q.QueryRowContext(ctx, `SELECT "keymr", "opr_eblock", "spr_eblock", "tx_eblock" FROM "pn_dblocks" WHERE "height" = ?;`, height).
+		Scan(&keyMR, &opr, &spr, &trans)
+	if err != nil {
+		return keyMRs, err
+	}
+	copy(keyMRs.KeyMR[:], keyMR)
+	keyMRs.OPREBlock = scanBytes32(opr)
+	keyMRs.SPREBlock = scanBytes32(spr)
+	keyMRs.TransEBlock = scanBytes32(trans)
+	return keyMRs, nil
+}
+
+func optionalBytes32(b *factom.Bytes32) interface{} {
+	if b == nil {
+		return nil
+	}
+	return b[:]
+}
+
+func scanBytes32(data []byte) *factom.Bytes32 {
+	if data == nil {
+		return nil
+	}
+	var b factom.Bytes32
+	copy(b[:], data)
+	return &b
+}
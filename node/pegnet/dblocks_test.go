@@ -0,0 +1,48 @@
+package pegnet
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPegnet_DBlocks(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "dblocks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "sql.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(createTableDBlocks)
+	require.NoError(t, err)
+	p := &Pegnet{DB: db}
+
+	opr := factom.NewBytes32("a642a8674f46696cc47fdb6b65f9c87b2a19c5ea8123b3d2f0c13b6f33a9d5ef")
+	keyMRs := DBlockKeyMRs{
+		Height:    10,
+		KeyMR:     factom.NewBytes32("cffce0f409ebba4ed236d49d89c70e4bd1f1367d86402a3363366683265a242d"),
+		OPREBlock: &opr,
+	}
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, p.InsertDBlock(ctx, tx, keyMRs))
+	require.NoError(t, tx.Commit())
+
+	stored, err := p.SelectDBlock(ctx, db, 10)
+	require.NoError(t, err)
+	assert.Equal(t, keyMRs, stored)
+	assert.Nil(t, stored.SPREBlock, "chains without an eblock are null")
+
+	_, err = p.SelectDBlock(ctx, db, 9)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
@@ -0,0 +1,191 @@
+package pegnet
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pegnet/pegnetd/fat/fat2"
+)
+
+// pn_eth_addresses
+
+// An 0x ethereum address is a hash of the public key, as is the FA address of
+// an RCD-e, so one can not be derived from the other. The public key is only
+// known once the address signs a transaction, so the link is recorded for
+// every RCD-e seen on the transaction chain.
+const createTableEthAddresses = `CREATE TABLE IF NOT EXISTS "pn_eth_addresses" (
+        "eth_address"   BLOB NOT NULL PRIMARY KEY, -- the 20 byte 0x address
+        "address"       BLOB NOT NULL UNIQUE,      -- the FA address of the RCD-e
+        "height"        INTEGER NOT NULL           -- the height the link was first seen
+);
+`
+
+// ErrUnknownEthAddress is returned for 0x addresses that never signed a
+// transaction
+var ErrUnknownEthAddress = fmt.Errorf("unknown ethereum address, it has not signed a pegnet transaction yet")
+
+// IsEthAddress returns true if the address is in the 0x ethereum form
+func IsEthAddress(address string) bool {
+	return strings.HasPrefix(address, "0x") && common.IsHexAddress(address)
+}
+
+// InsertEthAddresses records the ethereum address of every RCD-e that signed
+// the transaction batch. The batch is expected to be validated. A link that is
+// already known keeps the lowest height.
+func (p *Pegnet) InsertEthAddresses(ctx context.Context, tx *sql.Tx, txBatch *fat2.TransactionBatch, height uint32) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_eth_addresses" ("eth_address", "address", "height") VALUES (?, ?, ?)
+		ON CONFLICT("eth_address") DO UPDATE SET "height" = MIN("height", excluded."height");`)
+	if err != nil {
+		return err
+	}
+
+	// ExtIDs are [timestamp salt, rcd, signature, rcd, signature, ...]
+	for i := 1; i+1 < len(txBatch.Entry.ExtIDs); i += 2 {
+		rcd := txBatch.Entry.ExtIDs[i]
+		if len(rcd) != factom.RCDType0eSize || rcd[0] != factom.RCDType0e {
+			continue
+		}
+		eth := crypto.Keccak256(rcd[1:])[12:]
+		hash := sha256.Sum256(rcd)
+		fa := factom.FAAddress(sha256.Sum256(hash[:]))
+		if _, err := stmt.ExecContext(ctx, eth, fa[:], height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SelectEthAddressFA returns the FA address linked to the 0x ethereum address.
+// ErrUnknownEthAddress is returned if the address is not known.
+func (p *Pegnet) SelectEthAddressFA(ctx context.Context, ethAddress string) (factom.FAAddress, error) {
+	var fa factom.FAAddress
+	if !IsEthAddress(ethAddress) {
+		return fa, fmt.Errorf("invalid ethereum address")
+	}
+
+	var data []byte
+	eth := common.HexToAddress(ethAddress)
+	err := p.DB.QueryRowContext(ctx, `SELECT "address" FROM "pn_eth_addresses" WHERE "eth_address" = ?;`, eth[:]).Scan(&data)
+	if err == sql.ErrNoRows {
+		return fa, ErrUnknownEthAddress
+	}
+	if err != nil {
+		return fa, err
+	}
+	copy(fa[:], data)
+	return fa, nil
+}
+
+// SelectEthAddress returns the checksummed 0x ethereum address linked to the
+// FA address, or "" if the address is not a known RCD-e
+func (p *Pegnet) SelectEthAddress(ctx context.Context, fa *factom.FAAddress) (string, error) {
+	var data []byte
+	err := p.DB.QueryRowContext(ctx, `SELECT "eth_address" FROM "pn_eth_addresses" WHERE "address" = ?;`, fa[:]).Scan(&data)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return common.BytesToAddress(data).Hex(), nil
+}
+
+// The links of the transaction batches synced before pn_eth_addresses existed
+// are backfilled from the entries of the batches in the history, which
+// factomd still has. The progress is kept in pn_metadata.
+const ethAddressesBackfillName = "eth_addresses_backfill"
+
+// EthAddressesBackfill is the progress of the backfill of pn_eth_addresses,
+// the batches of pn_history_txbatch with a history_id in (After, Last] are
+// left to backfill
+type EthAddressesBackfill struct {
+	After int64
+	Last  int64
+}
+
+// Done returns true if there are no batches left to backfill
+func (b EthAddressesBackfill) Done() bool {
+	return b.After >= b.Last
+}
+
+// BackfillBatch is a transaction batch of the history to backfill
+type BackfillBatch struct {
+	ID        int64
+	EntryHash factom.Bytes32
+	Height    uint32
+}
+
+// ethAddressesMigrateBackfill schedules the backfill of the batches in the
+// history, once. Batches synced since are linked by the sync, and those
+// linked already are linked again at no harm.
+func ethAddressesMigrateBackfill(p *Pegnet) error {
+	var scheduled int
+	err := p.DB.QueryRow(`SELECT COUNT(*) FROM "pn_metadata" WHERE "name" = ?;`, ethAddressesBackfillName).Scan(&scheduled)
+	if err != nil || scheduled > 0 {
+		return err
+	}
+	var last int64
+	if err := p.DB.QueryRow(`SELECT COALESCE(MAX("history_id"), 0) FROM "pn_history_txbatch";`).Scan(&last); err != nil {
+		return err
+	}
+	return p.UpdateEthAddressesBackfill(context.Background(), p.DB, EthAddressesBackfill{Last: last})
+}
+
+// SelectEthAddressesBackfill returns the progress of the backfill, which is
+// done if it was never scheduled
+func (Pegnet) SelectEthAddressesBackfill(ctx context.Context, q QueryAble) (EthAddressesBackfill, error) {
+	var b EthAddressesBackfill
+	var data []byte
+	err := q.QueryRowContext(ctx, `SELECT "value" FROM "pn_metadata" WHERE "name" = ?;`, ethAddressesBackfillName).Scan(&data)
+	if err == sql.ErrNoRows {
+		return b, nil
+	}
+	if err != nil {
+		return b, err
+	}
+	return b, json.Unmarshal(data, &b)
+}
+
+// UpdateEthAddressesBackfill records the progress of the backfill
+func (Pegnet) UpdateEthAddressesBackfill(ctx context.Context, q QueryAble, b EthAddressesBackfill) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `REPLACE INTO "pn_metadata" ("name", "value") VALUES (?, ?);`, ethAddressesBackfillName, data)
+	return err
+}
+
+// SelectEthAddressesBackfillBatches returns the next batches to backfill, at
+// most limit. Only batches with transfers or conversions are entries of
+// transactions, the entry hashes of coinbases and burns are not, or have no
+// ethereum addresses.
+func (Pegnet) SelectEthAddressesBackfillBatches(ctx context.Context, q QueryAble, b EthAddressesBackfill, limit int) ([]BackfillBatch, error) {
+	rows, err := q.QueryContext(ctx, `SELECT "history_id", "entry_hash", "height" FROM "pn_history_txbatch" AS "batch"
+		WHERE "history_id" > ? AND "history_id" <= ? AND EXISTS (SELECT 1 FROM "pn_history_transaction" AS "tx"
+			WHERE "tx"."entry_hash" = "batch"."entry_hash" AND "tx"."action_type" IN (?, ?))
+		ORDER BY "history_id" LIMIT ?;`, b.After, b.Last, Transfer, Conversion, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []BackfillBatch
+	for rows.Next() {
+		var batch BackfillBatch
+		var hash []byte
+		if err := rows.Scan(&batch.ID, &hash, &batch.Height); err != nil {
+			return nil, err
+		}
+		copy(batch.EntryHash[:], hash)
+		batches = append(batches, batch)
+	}
+	return batches, rows.Err()
+}
@@ -0,0 +1,70 @@
+package pegnet
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPegnet_EthAddresses(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "ethaddresses")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "sql.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(createTableEthAddresses)
+	require.NoError(t, err)
+	p := &Pegnet{DB: db}
+
+	eth, err := factom.NewEthSecret("0x7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+	require.NoError(t, err)
+	fs, err := factom.GenerateFsAddress()
+	require.NoError(t, err)
+
+	var batch fat2.TransactionBatch
+	batch.Entry.ExtIDs = []factom.Bytes{factom.Bytes("1234"), fs.RCD(), fs.Sign(nil), eth.RCD(), eth.Sign(nil)}
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, p.InsertEthAddresses(ctx, tx, &batch, 10))
+	require.NoError(t, p.InsertEthAddresses(ctx, tx, &batch, 11), "duplicates are ignored")
+	require.NoError(t, p.InsertEthAddresses(ctx, tx, &batch, 9), "backfilled links are earlier")
+	require.NoError(t, tx.Commit())
+	var height uint32
+	require.NoError(t, db.QueryRow(`SELECT "height" FROM "pn_eth_addresses";`).Scan(&height))
+	assert.Equal(t, uint32(9), height, "the lowest height is kept")
+
+	fa, err := p.SelectEthAddressFA(ctx, eth.EthAddress())
+	require.NoError(t, err)
+	assert.Equal(t, eth.FAAddress(), fa)
+	fa, err = p.SelectEthAddressFA(ctx, strings.ToLower(eth.EthAddress()))
+	require.NoError(t, err, "not checksummed")
+	assert.Equal(t, eth.FAAddress(), fa)
+
+	ethFA := eth.FAAddress()
+	addr, err := p.SelectEthAddress(ctx, &ethFA)
+	require.NoError(t, err)
+	assert.Equal(t, eth.EthAddress(), addr)
+
+	fsFA := fs.FAAddress()
+	addr, err = p.SelectEthAddress(ctx, &fsFA)
+	require.NoError(t, err)
+	assert.Equal(t, "", addr, "RCD-1 addresses have no ethereum address")
+
+	_, err = p.SelectEthAddressFA(ctx, "0x0000000000000000000000000000000000000001")
+	assert.Equal(t, ErrUnknownEthAddress, err)
+	_, err = p.SelectEthAddressFA(ctx, fsFA.String())
+	assert.Error(t, err)
+}
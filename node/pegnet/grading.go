@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
 	"strings"
 
@@ -52,8 +53,8 @@ const createTableRate = `CREATE TABLE IF NOT EXISTS "pn_rate" (
 );
 `
 
-func (p *Pegnet) insertRate(tx *sql.Tx, height uint32, tickerString string, rate uint64) error {
-	_, err := tx.Exec("INSERT INTO pn_rate (height, token, value) VALUES ($1, $2, $3)", height, tickerString, rate)
+func (p *Pegnet) insertRate(ctx context.Context, tx *sql.Tx, height uint32, tickerString string, rate uint64) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO pn_rate (height, token, value) VALUES ($1, $2, $3)", height, tickerString, rate)
 	if err != nil {
 		return err
 	}
@@ -75,7 +76,7 @@ const (
 )
 
 // InsertRates adds all asset rates as rows, computing the rate for PEG if necessary
-func (p *Pegnet) InsertRates(tx *sql.Tx, height uint32, rates []opr.AssetUint, phase PEGPricingPhase) error {
+func (p *Pegnet) InsertRates(ctx context.Context, tx *sql.Tx, height uint32, rates []opr.AssetUint, phase PEGPricingPhase) error {
 	if phase == 0 {
 		return fmt.Errorf("undefined PEG phase")
 	}
@@ -90,7 +91,7 @@ func (p *Pegnet) InsertRates(tx *sql.Tx, height uint32, rates []opr.AssetUint, p
 		// Correct rates to use `pAsset`
 		rates[i].Name = "p" + rates[i].Name
 
-		err := p.insertRate(tx, height, rates[i].Name, rates[i].Value)
+		err := p.insertRate(ctx, tx, height, rates[i].Name, rates[i].Value)
 		if err != nil {
 			return err
 		}
@@ -104,7 +105,7 @@ func (p *Pegnet) InsertRates(tx *sql.Tx, height uint32, rates []opr.AssetUint, p
 		ratePEG.SetUint64(0)
 	case PEGPriceIsEquation: // Market Cap Equation
 		// PEG price = (total capitalization of all other assets) / (total supply of all other assets at height - 1)
-		issuance, err := p.SelectIssuances()
+		issuance, err := p.SelectIssuances(ctx)
 		if err != nil {
 			return err
 		}
@@ -127,19 +128,19 @@ func (p *Pegnet) InsertRates(tx *sql.Tx, height uint32, rates []opr.AssetUint, p
 	case PEGPriceIsFloating: // Rate in opr is the rate
 	}
 
-	err := p.insertRate(tx, height, fat2.PTickerPEG.String(), ratePEG.Uint64())
+	err := p.insertRate(ctx, tx, height, fat2.PTickerPEG.String(), ratePEG.Uint64())
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (p *Pegnet) InsertGradeBlock(tx *sql.Tx, eblock *factom.EBlock, graded grader.GradedBlock) error {
+func (p *Pegnet) InsertGradeBlock(ctx context.Context, tx *sql.Tx, eblock *factom.EBlock, graded grader.GradedBlock) error {
 	data, err := json.Marshal(graded.WinnersShortHashes())
 	if err != nil {
 		return err
 	}
-	_, err = tx.Exec("INSERT INTO pn_grade (height, keymr, prevkeymr, eb_seq, shorthashes, version, cutoff, count) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+	_, err = tx.ExecContext(ctx, "INSERT INTO pn_grade (height, keymr, prevkeymr, eb_seq, shorthashes, version, cutoff, count) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
 		eblock.Height, eblock.KeyMR[:], eblock.PrevKeyMR[:], eblock.Sequence, data, graded.Version(), graded.Cutoff(), graded.Count())
 	if err != nil {
 		return err
@@ -150,7 +151,7 @@ func (p *Pegnet) InsertGradeBlock(tx *sql.Tx, eblock *factom.EBlock, graded grad
 		for _, o := range graded.Graded() {
 			diff := make([]byte, 8)
 			binary.BigEndian.PutUint64(diff, o.SelfReportedDifficulty)
-			_, err = tx.Exec(`INSERT INTO pn_winners (height, entryhash, oprhash, payout, grade, nonce, difficulty, position, minerid, address) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			_, err = tx.ExecContext(ctx, `INSERT INTO pn_winners (height, entryhash, oprhash, payout, grade, nonce, difficulty, position, minerid, address) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
 				eblock.Height, o.EntryHash, o.OPRHash, o.Payout(), o.Grade, o.Nonce, diff, o.Position(), o.OPR.GetID(), o.OPR.GetAddress())
 			if err != nil {
 				return fmt.Errorf("ht %d, pos %d :%s", eblock.Height, o.Position(), err)
@@ -186,7 +187,7 @@ func (p *Pegnet) SelectReferenceRates(ctx context.Context, tx QueryAble, height
 	if tx == nil {
 		tx = p.DB
 	}
-	rows, err := tx.Query("SELECT token, value FROM pn_rate WHERE height = $1", height)
+	rows, err := tx.QueryContext(ctx, "SELECT token, value FROM pn_rate WHERE height = $1", height)
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +195,7 @@ func (p *Pegnet) SelectReferenceRates(ctx context.Context, tx QueryAble, height
 }
 
 func (p *Pegnet) SelectPendingRates(ctx context.Context, tx *sql.Tx, height uint32) (map[fat2.PTicker]uint64, error) {
-	rows, err := tx.Query("SELECT token, value FROM pn_rate WHERE height = $1", height)
+	rows, err := tx.QueryContext(ctx, "SELECT token, value FROM pn_rate WHERE height = $1", height)
 	if err != nil {
 		return nil, err
 	}
@@ -202,7 +203,7 @@ func (p *Pegnet) SelectPendingRates(ctx context.Context, tx *sql.Tx, height uint
 }
 
 func (p *Pegnet) SelectRates(ctx context.Context, height uint32) (map[fat2.PTicker]uint64, error) {
-	rows, err := p.DB.Query("SELECT token, value FROM pn_rate WHERE height = $1", height)
+	rows, err := p.DB.QueryContext(ctx, "SELECT token, value FROM pn_rate WHERE height = $1", height)
 	if err != nil {
 		return nil, err
 	}
@@ -210,7 +211,7 @@ func (p *Pegnet) SelectRates(ctx context.Context, height uint32) (map[fat2.PTick
 }
 
 func (p *Pegnet) SelectRatesByKeyMR(ctx context.Context, keymr *factom.Bytes32) (map[fat2.PTicker]uint64, error) {
-	rows, err := p.DB.Query("SELECT token, value FROM pn_rate WHERE height = (SELECT height FROM pn_grade WHERE keymr = $1)", keymr)
+	rows, err := p.DB.QueryContext(ctx, "SELECT token, value FROM pn_rate WHERE height = (SELECT height FROM pn_grade WHERE keymr = $1)", keymr)
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +226,7 @@ func (p *Pegnet) SelectMostRecentRatesBeforeHeight(ctx context.Context, tx Query
                         SELECT MAX("height")
                         FROM "pn_rate" WHERE "height" < ?
                     );`
-	rows, err := tx.Query(queryString, height)
+	rows, err := tx.QueryContext(ctx, queryString, height)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -244,6 +245,117 @@ func (p *Pegnet) SelectMostRecentRatesBeforeHeight(ctx context.Context, tx Query
 	return assets, rateHeight, nil
 }
 
+// RatesAtHeight are the rates of all assets at a single height
+type RatesAtHeight struct {
+	Height uint32
+	Rates  map[fat2.PTicker]uint64
+}
+
+// SelectRatesRange returns the rates of every height in [from, to] that has
+// rates, in ascending order
+func (p *Pegnet) SelectRatesRange(ctx context.Context, from, to uint32) ([]RatesAtHeight, error) {
+	rows, err := p.DB.QueryContext(ctx, `SELECT height, token, value FROM pn_rate WHERE height >= ? AND height <= ? ORDER BY height`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []RatesAtHeight
+	for rows.Next() {
+		var height uint32
+		var tickerName string
+		var rateValue uint64
+		if err := rows.Scan(&height, &tickerName, &rateValue); err != nil {
+			return nil, err
+		}
+		ticker := fat2.StringToTicker(tickerName)
+		if ticker == fat2.PTickerInvalid { // reference rates
+			continue
+		}
+		if len(res) == 0 || res[len(res)-1].Height != height {
+			res = append(res, RatesAtHeight{Height: height, Rates: make(map[fat2.PTicker]uint64)})
+		}
+		res[len(res)-1].Rates[ticker] = rateValue
+	}
+	return res, rows.Err()
+}
+
+// RateOHLC is the open, high, low, close, and average rate of an asset over
+// a range of heights
+type RateOHLC struct {
+	Open  uint64 `json:"open"`
+	High  uint64 `json:"high"`
+	Low   uint64 `json:"low"`
+	Close uint64 `json:"close"`
+	Avg   uint64 `json:"avg"`
+}
+
+// RateBucket are the aggregated rates of the heights [FromHeight, ToHeight]
+type RateBucket struct {
+	FromHeight uint32
+	ToHeight   uint32
+	Rates      map[fat2.PTicker]RateOHLC
+}
+
+// BucketRates aggregates ascending rates into buckets of `size` heights,
+// starting at `from`. Only the heights that have rates are used, buckets
+// without any rates are left out.
+func BucketRates(rates []RatesAtHeight, from, size uint32) []RateBucket {
+	var buckets []RateBucket
+	var count map[fat2.PTicker]uint64
+	var sum map[fat2.PTicker]*big.Int
+	finish := func() {
+		if len(buckets) == 0 {
+			return
+		}
+		b := buckets[len(buckets)-1]
+		for ticker, ohlc := range b.Rates {
+			ohlc.Avg = new(big.Int).Div(sum[ticker], new(big.Int).SetUint64(count[ticker])).Uint64()
+			b.Rates[ticker] = ohlc
+		}
+	}
+
+	for _, r := range rates {
+		if r.Height < from || size == 0 {
+			continue
+		}
+		start := from + (r.Height-from)/size*size
+		if len(buckets) == 0 || buckets[len(buckets)-1].FromHeight != start {
+			finish()
+			// The bucket ends at the highest height, if it would go past it
+			end := uint64(start) + uint64(size) - 1
+			if end > math.MaxUint32 {
+				end = math.MaxUint32
+			}
+			buckets = append(buckets, RateBucket{FromHeight: start, ToHeight: uint32(end), Rates: make(map[fat2.PTicker]RateOHLC)})
+			count = make(map[fat2.PTicker]uint64)
+			sum = make(map[fat2.PTicker]*big.Int)
+		}
+
+		b := buckets[len(buckets)-1]
+		for ticker, rate := range r.Rates {
+			ohlc, ok := b.Rates[ticker]
+			if !ok {
+				ohlc = RateOHLC{Open: rate, High: rate, Low: rate}
+				sum[ticker] = new(big.Int)
+			}
+			if rate > ohlc.High {
+				ohlc.High = rate
+			}
+			if rate < ohlc.Low {
+				ohlc.Low = rate
+			}
+			ohlc.Close = rate
+			b.Rates[ticker] = ohlc
+			sum[ticker].Add(sum[ticker], new(big.Int).SetUint64(rate))
+			count[ticker]++
+		}
+	}
+	finish()
+
+	return buckets
+}
+
 func _extractAssets(rows *sql.Rows) (map[fat2.PTicker]uint64, error) {
 	return _extractAssetsWithPrefix(rows, "")
 }
@@ -0,0 +1,74 @@
+package pegnet
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/pegnet/pegnetd/fat/fat2"
+)
+
+func TestPegnet_SelectRatesRange(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := new(Pegnet)
+	p.DB = db
+	if _, err := db.Exec(createTableRate); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []struct {
+		height uint32
+		token  string
+		value  uint64
+	}{{9, "PEG", 1}, {10, "PEG", 2}, {10, "pUSD", 100}, {10, PAssetExchangePrefix + "pUSD", 99}, {12, "PEG", 3}, {13, "PEG", 4}} {
+		if _, err := db.Exec("INSERT INTO pn_rate (height, token, value) VALUES (?, ?, ?)", r.height, r.token, r.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := p.SelectRatesRange(context.Background(), 10, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RatesAtHeight{
+		{10, map[fat2.PTicker]uint64{fat2.PTickerPEG: 2, fat2.PTickerUSD: 100}},
+		{12, map[fat2.PTicker]uint64{fat2.PTickerPEG: 3}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SelectRatesRange() = %v, want %v", got, want)
+	}
+}
+
+func TestBucketRates(t *testing.T) {
+	rates := []RatesAtHeight{
+		{10, map[fat2.PTicker]uint64{fat2.PTickerPEG: 5}},
+		{11, map[fat2.PTicker]uint64{fat2.PTickerPEG: 9}},
+		{12, map[fat2.PTicker]uint64{fat2.PTickerPEG: 1}},
+		{13, map[fat2.PTicker]uint64{fat2.PTickerPEG: 4, fat2.PTickerUSD: 100}},
+		// 14 - 17 has no rates
+		{18, map[fat2.PTicker]uint64{fat2.PTickerPEG: 7}},
+	}
+
+	got := BucketRates(rates, 10, 4)
+	want := []RateBucket{
+		{10, 13, map[fat2.PTicker]RateOHLC{
+			fat2.PTickerPEG: {Open: 5, High: 9, Low: 1, Close: 4, Avg: 4},
+			fat2.PTickerUSD: {Open: 100, High: 100, Low: 100, Close: 100, Avg: 100},
+		}},
+		{18, 21, map[fat2.PTicker]RateOHLC{fat2.PTickerPEG: {Open: 7, High: 7, Low: 7, Close: 7, Avg: 7}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BucketRates() = %v, want %v", got, want)
+	}
+
+	// A bucket that would end past the highest height ends at it
+	got = BucketRates(rates[:1], 10, math.MaxUint32-9)
+	if len(got) != 1 || got[0].ToHeight != math.MaxUint32 {
+		t.Errorf("BucketRates() = %v, want a bucket of [10, %d]", got, uint32(math.MaxUint32))
+	}
+}
@@ -18,7 +18,7 @@ type BlockSync struct {
 	Synced uint32
 }
 
-func (p *Pegnet) InsertSynced(tx *sql.Tx, bs *BlockSync) error {
+func (p *Pegnet) InsertSynced(ctx context.Context, tx *sql.Tx, bs *BlockSync) error {
 	data, err := json.Marshal(bs)
 	if err != nil {
 		return err
@@ -26,12 +26,12 @@ func (p *Pegnet) InsertSynced(tx *sql.Tx, bs *BlockSync) error {
 
 	// Since this is called for every height, we also can mark the height
 	// synced for version checking
-	err = p.MarkHeightSynced(tx, bs.Synced)
+	err = p.MarkHeightSynced(ctx, tx, bs.Synced)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec("REPLACE INTO pn_metadata (name, value) VALUES ($1, $2)", "synced", data)
+	_, err = tx.ExecContext(ctx, "REPLACE INTO pn_metadata (name, value) VALUES ($1, $2)", "synced", data)
 	if err != nil {
 		return err
 	}
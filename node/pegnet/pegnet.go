@@ -85,6 +85,10 @@ func (p *Pegnet) createTables() error {
 		createTableTxHistoryLookup,
 		createTableSyncVersion,
 		createTableBank,
+		createTableEthAddresses,
+		createTableDBlocks,
+		createTableBlockSummary,
+		createTableSubmissions,
 	} {
 		if _, err := p.DB.Exec(sql); err != nil {
 			return fmt.Errorf("createTables: %v", err)
@@ -104,6 +108,11 @@ func (p *Pegnet) migrations() error {
 	// migrate pn_history_lookup alter column
 	txhistoryMigrateLookup1(p)
 
+	// backfill pn_eth_addresses for the batches synced before it existed
+	if err := ethAddressesMigrateBackfill(p); err != nil {
+		return err
+	}
+
 	v4Migrate, err := p.v4MigrationNeeded()
 	if err != nil {
 		return err
@@ -143,4 +152,7 @@ type QueryAble interface {
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
 	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
@@ -1,6 +1,7 @@
 package pegnet
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -18,25 +19,25 @@ const (
 // 2. Save snapshot_current to past
 // 3. Delete snapshot_current
 // 4. Save current balances to snapshot_current
-func (p *Pegnet) SnapshotCurrent(tx QueryAble) error {
+func (p *Pegnet) SnapshotCurrent(ctx context.Context, tx QueryAble) error {
 	// Move the current snapshot to snapshot_past
 	//	We do a WHERE select otherwise SQLlite gives an error that we will prune the whole table
-	_, err := tx.Exec(`DELETE FROM snapshot_past WHERE peg_balance >= 0`)
+	_, err := tx.ExecContext(ctx, `DELETE FROM snapshot_past WHERE peg_balance >= 0`)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec(`INSERT INTO snapshot_past SELECT * FROM snapshot_current`)
+	_, err = tx.ExecContext(ctx, `INSERT INTO snapshot_past SELECT * FROM snapshot_current`)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec(`DELETE FROM snapshot_current WHERE peg_balance >= 0`)
+	_, err = tx.ExecContext(ctx, `DELETE FROM snapshot_current WHERE peg_balance >= 0`)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec(`INSERT INTO snapshot_current SELECT * FROM pn_addresses`)
+	_, err = tx.ExecContext(ctx, `INSERT INTO snapshot_current SELECT * FROM pn_addresses`)
 	if err != nil {
 		return err
 	}
@@ -48,7 +49,7 @@ func (p *Pegnet) SnapshotCurrent(tx QueryAble) error {
 // snapshot balances for the given address. The snapshot balance is the minimum of the past, and current
 // snapshot.
 // You must provide the table to query on
-func (Pegnet) SelectSnapshotBalances(tx QueryAble) ([]BalancesPair, error) {
+func (Pegnet) SelectSnapshotBalances(ctx context.Context, tx QueryAble) ([]BalancesPair, error) {
 	// This query merges all addresses that exist in both snapshots. The balance
 	// in the column is the minimum balance of the 2 snapshots. If the
 	// address does not exist in either column, it will not be present in the
@@ -57,7 +58,7 @@ func (Pegnet) SelectSnapshotBalances(tx QueryAble) ([]BalancesPair, error) {
 		FROM snapshot_past as sn_past
        		INNER JOIN snapshot_current as sn_current
 		WHERE sn_past.address = sn_current.address;`, snapshotMinSelectCols)
-	rows, err := tx.Query(query)
+	rows, err := tx.QueryContext(ctx, query)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
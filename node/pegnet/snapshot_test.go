@@ -1,6 +1,7 @@
 package pegnet_test
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"testing"
@@ -14,6 +15,7 @@ import (
 )
 
 func TestPegnet_SnapshotBalances(t *testing.T) {
+	ctx := context.Background()
 	assert := assert.New(t)
 	addresses := make([]factom.FAAddress, 10)
 	for i := range addresses {
@@ -55,43 +57,43 @@ func TestPegnet_SnapshotBalances(t *testing.T) {
 		assert.NoError(err)
 
 		if amt > 0 {
-			_, err = p.AddToBalance(tx, &addresses[i], fat2.PTickerPEG, uint64(amt))
+			_, err = p.AddToBalance(ctx, tx, &addresses[i], fat2.PTickerPEG, uint64(amt))
 			assert.NoError(err)
 		}
 		if amt < 0 {
 			amt = amt * -1
-			_, _, err = p.SubFromBalance(tx, &addresses[i], fat2.PTickerPEG, uint64(amt))
+			_, _, err = p.SubFromBalance(ctx, tx, &addresses[i], fat2.PTickerPEG, uint64(amt))
 			assert.NoError(err)
 		}
 		_ = tx.Commit()
 	}
 
-	assert.NoError(p.SnapshotCurrent(p.DB))
+	assert.NoError(p.SnapshotCurrent(ctx, p.DB))
 	t.Run("no balances", func(t *testing.T) {
 		// should be no balances since there is no snapshots currently
 
-		bals, err := p.SelectSnapshotBalances(p.DB)
+		bals, err := p.SelectSnapshotBalances(ctx, p.DB)
 		assert.NoError(err)
 		assert.Equal(0, len(bals))
 	})
 
 	// Balances all 0
 	addBalance(0, 1e8)
-	assert.NoError(p.SnapshotCurrent(p.DB))
+	assert.NoError(p.SnapshotCurrent(ctx, p.DB))
 	t.Run("no balances since past snapshot is empty", func(t *testing.T) {
 		// Past snapshot is empty, current snapshot has 1.
 		// Since the balance is the minimum of the sets, the balances should be 0
-		bals, err := p.SelectSnapshotBalances(p.DB)
+		bals, err := p.SelectSnapshotBalances(ctx, p.DB)
 		assert.NoError(err)
 		assert.Equal(0, len(bals))
 	})
 
 	// Past has Add[0] at 1e8 PEG
-	assert.NoError(p.SnapshotCurrent(p.DB))
+	assert.NoError(p.SnapshotCurrent(ctx, p.DB))
 
 	t.Run("Add[0] balance should be 1 PEG (p&c match)", func(t *testing.T) {
 		// Both snapshots have 1 peg in address[0]
-		bals, err := p.SelectSnapshotBalances(p.DB)
+		bals, err := p.SelectSnapshotBalances(ctx, p.DB)
 		assert.NoError(err)
 		assert.Equal(1, len(bals))
 		if *bals[0].Address != addresses[0] {
@@ -105,10 +107,10 @@ func TestPegnet_SnapshotBalances(t *testing.T) {
 	// Past and Current Add[0] at 1e8 PEG
 	addBalance(0, 1e8)
 	addBalance(1, 5e8)
-	assert.NoError(p.SnapshotCurrent(p.DB))
+	assert.NoError(p.SnapshotCurrent(ctx, p.DB))
 	t.Run("Add[0] balance should be 1 PEG (p&c mismatch)", func(t *testing.T) {
 		// Both snapshots have 1 peg in address[0]
-		bals, err := p.SelectSnapshotBalances(p.DB)
+		bals, err := p.SelectSnapshotBalances(ctx, p.DB)
 		assert.NoError(err)
 		assert.Equal(1, len(bals))
 		if *bals[0].Address != addresses[0] {
@@ -124,12 +126,12 @@ func TestPegnet_SnapshotBalances(t *testing.T) {
 	// Current Add[1] at 5e8 PEG
 
 	addBalance(0, -2e8)
-	assert.NoError(p.SnapshotCurrent(p.DB))
+	assert.NoError(p.SnapshotCurrent(ctx, p.DB))
 	t.Run("Add[0] at 5 PEG, Add[1] at 0 PEG (p&c mismatch)", func(t *testing.T) {
 		// Both snapshots have 2 peg in address[0]
 		// Past Add[1] has 5 PEG
 		// Current Add[1] has 0 PEG
-		bals, err := p.SelectSnapshotBalances(p.DB)
+		bals, err := p.SelectSnapshotBalances(ctx, p.DB)
 		assert.NoError(err)
 		assert.Equal(2, len(bals))
 
@@ -140,12 +142,12 @@ func TestPegnet_SnapshotBalances(t *testing.T) {
 	// Past & Cur Add[1] at 5e8 PEG
 
 	addBalance(1, -2e8)
-	assert.NoError(p.SnapshotCurrent(p.DB))
+	assert.NoError(p.SnapshotCurrent(ctx, p.DB))
 	t.Run("Add[0] at 3 PEG (p&c mismatch), Add[1] at 0 PEG", func(t *testing.T) {
 		// Both snapshots have 2 peg in address[0]
 		// Past Add[1] has 5 PEG
 		// Current Add[1] has 0 PEG
-		bals, err := p.SelectSnapshotBalances(p.DB)
+		bals, err := p.SelectSnapshotBalances(ctx, p.DB)
 		assert.NoError(err)
 		assert.Equal(2, len(bals))
 
@@ -0,0 +1,424 @@
+package pegnet
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/fat/fat2"
+)
+
+// State snapshots hold the consensus state at a height, so a new node can be
+// seeded with them instead of syncing from the activation height. Not to be
+// confused with the balance snapshots in snapshot.go.
+//
+// The file is a gzip compressed gob stream: a StateSnapshotHeader, then the
+// rows of every table in stateSnapshotTables in chunks. The last chunk carries
+// the state hash, the sha256 of the height and every exported value of the
+// hashed tables.
+
+const (
+	// StateSnapshotVersion is bumped when the contents of the file change
+	StateSnapshotVersion = 2
+
+	// StateSnapshotWindow is how many blocks of rates, grades and winners are
+	// exported, enough to compute the averages of the next block
+	StateSnapshotWindow = 288
+
+	stateSnapshotChunkSize = 1000
+)
+
+// StateSnapshotHeader is the start of a state snapshot file
+type StateSnapshotHeader struct {
+	Version int
+	Network string
+	Height  uint32
+	// The DBlock KeyMR at the height, if it was recorded
+	DBlockKeyMR *factom.Bytes32
+	Created     time.Time
+}
+
+type stateSnapshotChunk struct {
+	Table   string
+	Columns []string
+	Rows    [][]interface{}
+
+	// Set on the last chunk only
+	StateHash *factom.Bytes32
+}
+
+// stateSnapshotTable is a table, or the part of it, that is in a snapshot.
+// In the filter, ?1 is the first height of the window and ?2 the height of the
+// snapshot. The order is part of the state hash, so it has to be the same on
+// every node. Surrogate ids are left out, as they are assigned on insert.
+type stateSnapshotTable struct {
+	name    string
+	columns []string
+	filter  string
+	order   string
+}
+
+var stateSnapshotTables []stateSnapshotTable
+
+// stateSnapshotUnhashed are the tables that are exported, but left out of the
+// state hash, as not every node builds them the same. pn_eth_addresses is only
+// complete once the links of the batches synced before it existed are
+// backfilled, so it is not part of the consensus state.
+var stateSnapshotUnhashed = map[string]bool{"pn_eth_addresses": true}
+
+func init() {
+	balances := []string{"address"}
+	for i := 1; i < int(fat2.PTickerMax); i++ {
+		balances = append(balances, strings.ToLower(fat2.PTicker(i).String())+"_balance")
+	}
+	const window = `"height" >= ?1 AND "height" <= ?2`
+	const windowHistory = `"entry_hash" IN (SELECT "entry_hash" FROM "pn_history_txbatch" WHERE "height" >= ?1 AND "height" <= ?2)`
+
+	stateSnapshotTables = []stateSnapshotTable{
+		{"pn_addresses", balances, "", `"address"`},
+		{"snapshot_past", balances, "", `"address"`},
+		{"snapshot_current", balances, "", `"address"`},
+		{"pn_rate", []string{"height", "token", "value"}, window, `"height", "token"`},
+		{"pn_grade", []string{"height", "keymr", "prevkeymr", "eb_seq", "shorthashes", "version", "cutoff", "count"}, window, `"height"`},
+		{"pn_winners", []string{"height", "entryhash", "oprhash", "payout", "grade", "nonce", "difficulty", "position", "minerid", "address"}, window, `"height", "position"`},
+		{"pn_bank", []string{"height", "bank_amount", "bank_used", "total_requested"}, `"height" <= ?2`, `"height"`},
+		// Batches are applied in the order they were put into holding
+		{"pn_transaction_batch_holding", []string{"entry_hash", "entry_data", "height", "eblock_keymr", "unix_timestamp"}, window, `"id"`},
+		// Every executed transaction, for the replay protection
+		{"pn_address_transactions", []string{"entry_hash", "address", "tx_index", "to", "conversion"}, "", `"entry_hash", "address"`},
+		{"pn_history_txbatch", []string{"entry_hash", "height", "blockorder", "timestamp", "executed"}, window, `"history_id"`},
+		{"pn_history_transaction", []string{"entry_hash", "tx_index", "action_type", "from_address", "from_asset", "from_amount", "to_asset", "to_amount", "outputs"}, windowHistory, `"entry_hash", "tx_index"`},
+		{"pn_history_lookup", []string{"entry_hash", "tx_index", "address"}, windowHistory, `"entry_hash", "tx_index", "address"`},
+		{"pn_dblocks", []string{"height", "keymr", "opr_eblock", "spr_eblock", "tx_eblock"}, `"height" = ?2`, `"height"`},
+		{"pn_eth_addresses", []string{"eth_address", "address", "height"}, `"height" <= ?2`, `"eth_address"`},
+	}
+}
+
+func (t stateSnapshotTable) quotedColumns() string {
+	quoted := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		quoted[i] = `"` + c + `"`
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// ExportState writes a snapshot of the state at the synced height to w. The
+// height must be the synced height of the database, as older balances are not
+// kept. The state hash is returned.
+func (p *Pegnet) ExportState(ctx context.Context, w io.Writer, height uint32) (*factom.Bytes32, error) {
+	// A transaction keeps the view consistent while the node keeps syncing
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	bs, err := p.SelectSynced(ctx, tx)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("the database has not synced any blocks")
+	} else if err != nil {
+		return nil, err
+	}
+	if bs.Synced != height {
+		return nil, fmt.Errorf("the database is synced to height %d, a snapshot can only be exported at the synced height", bs.Synced)
+	}
+	if backfill, err := p.SelectEthAddressesBackfill(ctx, tx); err != nil {
+		return nil, err
+	} else if !backfill.Done() {
+		return nil, fmt.Errorf("the ethereum addresses are still being backfilled, %d of %d batches are done", backfill.After, backfill.Last)
+	}
+
+	header := StateSnapshotHeader{
+		Version: StateSnapshotVersion,
+		Network: p.Config.GetString(config.Network),
+		Height:  height,
+		Created: time.Now(),
+	}
+	if dblock, err := p.SelectDBlock(ctx, tx, height); err == nil {
+		header.DBlockKeyMR = &dblock.KeyMR
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	from, err := stateSnapshotWindowStart(ctx, tx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	enc := gob.NewEncoder(gz)
+	if err := enc.Encode(header); err != nil {
+		return nil, err
+	}
+
+	h := newStateHash(height)
+	for _, table := range stateSnapshotTables {
+		if err := exportTable(ctx, tx, enc, h, table, from, height); err != nil {
+			return nil, fmt.Errorf("%s: %v", table.name, err)
+		}
+	}
+
+	stateHash := h.sum()
+	if err := enc.Encode(stateSnapshotChunk{StateHash: stateHash}); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return stateHash, nil
+}
+
+// stateSnapshotWindowStart returns the first height of the rates, grades and
+// winners to export. It reaches further back than the window if the last
+// rates or grade are older.
+func stateSnapshotWindowStart(ctx context.Context, q QueryAble, height uint32) (uint32, error) {
+	var from uint32
+	if height+1 > StateSnapshotWindow {
+		from = height + 1 - StateSnapshotWindow
+	}
+	for _, table := range []string{"pn_rate", "pn_grade"} {
+		var last uint32
+		err := q.QueryRowContext(ctx, fmt.Sprintf(`SELECT COALESCE(MAX("height"), 0) FROM "%s" WHERE "height" <= ?;`, table), height).Scan(&last)
+		if err != nil {
+			return 0, err
+		}
+		if last > 0 && last < from {
+			from = last
+		}
+	}
+	return from, nil
+}
+
+func exportTable(ctx context.Context, tx *sql.Tx, enc *gob.Encoder, h *stateHash, table stateSnapshotTable, from, height uint32) error {
+	query := fmt.Sprintf(`SELECT %s FROM "%s"`, table.quotedColumns(), table.name)
+	if table.filter != "" {
+		query += " WHERE " + table.filter
+	}
+	query += " ORDER BY " + table.order + ";"
+	var args []interface{}
+	if strings.Contains(table.filter, "?") {
+		args = []interface{}{from, height}
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if stateSnapshotUnhashed[table.name] {
+		h = nil
+	}
+	h.table(table.name)
+	chunk := stateSnapshotChunk{Table: table.name, Columns: table.columns}
+	for rows.Next() {
+		row := make([]interface{}, len(table.columns))
+		dest := make([]interface{}, len(row))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err := h.row(row); err != nil {
+			return err
+		}
+		chunk.Rows = append(chunk.Rows, row)
+		if len(chunk.Rows) == stateSnapshotChunkSize {
+			if err := enc.Encode(chunk); err != nil {
+				return err
+			}
+			chunk.Rows = nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	// Every table is sent, even when empty
+	return enc.Encode(chunk)
+}
+
+// ImportState seeds an empty database with a state snapshot, which is synced
+// from the height after it. The state hash is verified, and also compared to
+// expected if it is not nil.
+func (p *Pegnet) ImportState(ctx context.Context, r io.Reader, expected *factom.Bytes32) (*StateSnapshotHeader, *factom.Bytes32, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	dec := gob.NewDecoder(gz)
+	header := new(StateSnapshotHeader)
+	if err := dec.Decode(header); err != nil {
+		return nil, nil, fmt.Errorf("invalid snapshot: %v", err)
+	}
+	if header.Version != StateSnapshotVersion {
+		return nil, nil, fmt.Errorf("unsupported snapshot version %d, expected %d", header.Version, StateSnapshotVersion)
+	}
+	if network := p.Config.GetString(config.Network); header.Network != network {
+		return nil, nil, fmt.Errorf("the snapshot is of %s, but the node is configured for %s", header.Network, network)
+	}
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	if bs, err := p.SelectSynced(ctx, tx); err == nil {
+		return nil, nil, fmt.Errorf("the database is not empty, it is synced to height %d", bs.Synced)
+	} else if err != sql.ErrNoRows {
+		return nil, nil, err
+	}
+
+	tables := make(map[string]stateSnapshotTable)
+	for _, table := range stateSnapshotTables {
+		tables[table.name] = table
+	}
+
+	h := newStateHash(header.Height)
+	var current string
+	var stateHash *factom.Bytes32
+	for stateHash == nil {
+		var chunk stateSnapshotChunk
+		if err := dec.Decode(&chunk); err != nil {
+			return nil, nil, fmt.Errorf("invalid snapshot: %v", err)
+		}
+		if chunk.StateHash != nil {
+			stateHash = chunk.StateHash
+			break
+		}
+
+		table, ok := tables[chunk.Table]
+		if !ok || strings.Join(chunk.Columns, ",") != strings.Join(table.columns, ",") {
+			return nil, nil, fmt.Errorf("invalid snapshot: unexpected table %s (%s)", chunk.Table, strings.Join(chunk.Columns, ", "))
+		}
+		th := h
+		if stateSnapshotUnhashed[table.name] {
+			th = nil
+		}
+		if chunk.Table != current {
+			current = chunk.Table
+			th.table(current)
+		}
+
+		stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (?%s);`,
+			table.name, table.quotedColumns(), strings.Repeat(", ?", len(table.columns)-1)))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, row := range chunk.Rows {
+			if len(row) != len(table.columns) {
+				stmt.Close()
+				return nil, nil, fmt.Errorf("invalid snapshot: %s row of %d values", table.name, len(row))
+			}
+			if err := th.row(row); err != nil {
+				stmt.Close()
+				return nil, nil, err
+			}
+			if _, err := stmt.ExecContext(ctx, row...); err != nil {
+				stmt.Close()
+				return nil, nil, fmt.Errorf("%s: %v", table.name, err)
+			}
+		}
+		stmt.Close()
+	}
+
+	if computed := h.sum(); *computed != *stateHash {
+		return nil, nil, fmt.Errorf("the state hash of the snapshot does not match its contents: %s, computed %s", stateHash, computed)
+	}
+	if expected != nil && *expected != *stateHash {
+		return nil, nil, fmt.Errorf("the state hash of the snapshot is %s, expected %s", stateHash, expected)
+	}
+
+	// This also marks the height synced by this version, so the hard fork
+	// check on start does not take the heights before it for ones synced
+	// before versions were tracked
+	if err := p.InsertSynced(ctx, tx, &BlockSync{Synced: header.Height}); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return header, stateHash, nil
+}
+
+// stateHash is the hash of the exported state. Every value is written with a
+// type tag, and variable length values with their length. A nil stateHash
+// hashes nothing, for the tables that are not hashed.
+type stateHash struct {
+	h hash.Hash
+}
+
+func newStateHash(height uint32) *stateHash {
+	h := &stateHash{h: sha256.New()}
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], height)
+	h.h.Write(buf[:])
+	return h
+}
+
+func (h *stateHash) table(name string) {
+	if h == nil {
+		return
+	}
+	h.bytes('t', []byte(name))
+}
+
+func (h *stateHash) row(row []interface{}) error {
+	if h == nil {
+		return nil
+	}
+	h.h.Write([]byte{'r'})
+	for _, v := range row {
+		switch v := v.(type) {
+		case nil:
+			h.h.Write([]byte{'n'})
+		case int64:
+			h.uint64('i', uint64(v))
+		case float64:
+			h.uint64('f', math.Float64bits(v))
+		case bool:
+			if v {
+				h.uint64('b', 1)
+			} else {
+				h.uint64('b', 0)
+			}
+		case string:
+			h.bytes('s', []byte(v))
+		case []byte:
+			h.bytes('x', v)
+		default:
+			return fmt.Errorf("unexpected value of type %T", v)
+		}
+	}
+	return nil
+}
+
+func (h *stateHash) uint64(tag byte, v uint64) {
+	var buf [9]byte
+	buf[0] = tag
+	binary.BigEndian.PutUint64(buf[1:], v)
+	h.h.Write(buf[:])
+}
+
+func (h *stateHash) bytes(tag byte, data []byte) {
+	h.uint64(tag, uint64(len(data)))
+	h.h.Write(data)
+}
+
+func (h *stateHash) sum() *factom.Bytes32 {
+	var sum factom.Bytes32
+	copy(sum[:], h.h.Sum(nil))
+	return &sum
+}
@@ -0,0 +1,131 @@
+package pegnet
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStateSnapshotTestPegnet(t *testing.T, dir, name string) *Pegnet {
+	db, err := sql.Open("sqlite3", filepath.Join(dir, name))
+	require.NoError(t, err)
+	conf := viper.New()
+	conf.Set(config.Network, "MainNet")
+	p := &Pegnet{DB: db, Config: conf}
+	require.NoError(t, p.createTables())
+	return p
+}
+
+func TestPegnet_StateSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statesnapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	// Past the hard forks, which the imported node must not fail to check
+	height := Hardforks[2].ActivationHeight + 1000
+
+	adr := factom.FAAddress(factom.NewBytes32("a642a8674f46696cc47fdb6b65f9c87b2a19c5ea8123b3d2f0c13b6f33a9d5ef"))
+	eth, err := factom.NewEthSecret("0x7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+	require.NoError(t, err)
+	seed := func(p *Pegnet, linked bool) {
+		tx, err := p.DB.Begin()
+		require.NoError(t, err)
+		_, err = p.AddToBalance(ctx, tx, &adr, fat2.PTickerPEG, 500)
+		require.NoError(t, err)
+		require.NoError(t, p.SnapshotCurrent(ctx, tx))
+		for _, h := range []uint32{height - 900, height} {
+			require.NoError(t, p.insertRate(ctx, tx, h, "PEG", uint64(h)))
+		}
+		require.NoError(t, p.InsertDBlock(ctx, tx, DBlockKeyMRs{Height: height, KeyMR: factom.NewBytes32("cffce0f409ebba4ed236d49d89c70e4bd1f1367d86402a3363366683265a242d")}))
+		require.NoError(t, p.InsertSynced(ctx, tx, &BlockSync{Synced: height}))
+		if linked {
+			var batch fat2.TransactionBatch
+			batch.Entry.ExtIDs = []factom.Bytes{factom.Bytes("1234"), eth.RCD(), eth.Sign(nil)}
+			require.NoError(t, p.InsertEthAddresses(ctx, tx, &batch, 10))
+		}
+		require.NoError(t, tx.Commit())
+	}
+
+	src := newStateSnapshotTestPegnet(t, dir, "src.db")
+	defer src.DB.Close()
+	seed(src, true)
+
+	// The same state without the ethereum addresses, as on a node that synced
+	// before they were linked. It exports once they are backfilled.
+	upgraded := newStateSnapshotTestPegnet(t, dir, "upgraded.db")
+	defer upgraded.DB.Close()
+	seed(upgraded, false)
+	require.NoError(t, upgraded.UpdateEthAddressesBackfill(ctx, upgraded.DB, EthAddressesBackfill{Last: 1}))
+	var upgradedFile bytes.Buffer
+	_, err = upgraded.ExportState(ctx, &upgradedFile, height)
+	assert.Error(t, err, "not until the backfill is done")
+	require.NoError(t, upgraded.UpdateEthAddressesBackfill(ctx, upgraded.DB, EthAddressesBackfill{After: 1, Last: 1}))
+	upgradedHash, err := upgraded.ExportState(ctx, &upgradedFile, height)
+	require.NoError(t, err)
+
+	var file bytes.Buffer
+	_, err = src.ExportState(ctx, &file, height-1)
+	assert.Error(t, err, "only the synced height can be exported")
+	stateHash, err := src.ExportState(ctx, &file, height)
+	require.NoError(t, err)
+
+	// The hash only depends on the state
+	var again bytes.Buffer
+	againHash, err := src.ExportState(ctx, &again, height)
+	require.NoError(t, err)
+	assert.Equal(t, stateHash, againHash)
+	assert.Equal(t, upgradedHash, stateHash, "the ethereum addresses are not part of the hash")
+
+	dst := newStateSnapshotTestPegnet(t, dir, "dst.db")
+	defer dst.DB.Close()
+	wrong := factom.NewBytes32("d5e395125335a21cef0ceca528168e87fe929fdac1f156870c1b1be6502448b4")
+	_, _, err = dst.ImportState(ctx, bytes.NewReader(file.Bytes()), &wrong)
+	assert.Error(t, err, "the expected hash must match")
+
+	header, imported, err := dst.ImportState(ctx, bytes.NewReader(file.Bytes()), stateHash)
+	require.NoError(t, err)
+	assert.Equal(t, height, header.Height)
+	assert.Equal(t, stateHash, imported)
+	require.NotNil(t, header.DBlockKeyMR)
+
+	bal, err := dst.SelectBalance(ctx, &adr, fat2.PTickerPEG)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500), bal)
+	rates, err := dst.SelectRates(ctx, height)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(height), rates[fat2.PTickerPEG])
+	rates, err = dst.SelectRates(ctx, height-900)
+	require.NoError(t, err)
+	assert.Empty(t, rates, "rates before the window are not exported")
+	bs, err := dst.SelectSynced(ctx, dst.DB)
+	require.NoError(t, err)
+	assert.Equal(t, height, bs.Synced)
+	lowest, err := dst.LowestSynced(ctx, dst.DB)
+	require.NoError(t, err)
+	assert.Equal(t, height, lowest, "the imported height is synced by this version")
+	assert.NoError(t, dst.CheckHardForks(ctx, dst.DB), "the imported node starts")
+	fa, err := dst.SelectEthAddressFA(ctx, eth.EthAddress())
+	require.NoError(t, err, "the ethereum addresses are imported")
+	assert.Equal(t, eth.FAAddress(), fa)
+
+	// The imported state exports to the same hash
+	var reexport bytes.Buffer
+	reexportHash, err := dst.ExportState(ctx, &reexport, height)
+	require.NoError(t, err)
+	assert.Equal(t, stateHash, reexportHash)
+
+	_, _, err = dst.ImportState(ctx, bytes.NewReader(file.Bytes()), nil)
+	assert.Error(t, err, "only an empty database can be seeded")
+}
@@ -0,0 +1,155 @@
+package pegnet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+)
+
+// pn_submissions
+
+// The transaction entries submitted through send-transaction, kept until
+// they are executed or rejected, so a submission that did not make it into a
+// block is retried, also across restarts.
+const createTableSubmissions = `CREATE TABLE IF NOT EXISTS "pn_submissions" (
+        "id"          INTEGER PRIMARY KEY,
+        "key"         TEXT NOT NULL UNIQUE, -- the idempotency key
+        "entry_hash"  BLOB NOT NULL,
+        "entry"       BLOB NOT NULL,        -- the marshaled entry, revealed again if needed
+        "txid"        BLOB,                 -- of the last commit
+        "status"      TEXT NOT NULL,
+        "submitted"   INTEGER NOT NULL,     -- the synced height of the last commit or reveal
+        "height"      INTEGER NOT NULL,     -- the height it is included at, 0 until then
+        "attempts"    INTEGER NOT NULL,     -- the reveals so far
+        "error"       TEXT NOT NULL,        -- of the last failed commit or reveal
+        "created"     INTEGER NOT NULL,
+        "updated"     INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_submissions_status" ON "pn_submissions"("status");
+`
+
+// The statuses of a submission, in the order it goes through them
+const (
+	SubmissionQueued    = "queued"    // Not committed yet
+	SubmissionCommitted = "committed" // Paid for, not revealed yet
+	SubmissionRevealed  = "revealed"  // Revealed, not in a synced block yet
+	SubmissionIncluded  = "included"  // In the block at its height, in holding until executed
+	SubmissionExecuted  = "executed"
+	SubmissionRejected  = "rejected"
+	SubmissionFailed    = "failed" // Not in a block after every attempt
+)
+
+// SubmissionStatuses are all the statuses of a submission, and
+// ActiveSubmissionStatuses the ones the submission queue still has to advance
+var (
+	SubmissionStatuses = []string{SubmissionQueued, SubmissionCommitted, SubmissionRevealed, SubmissionIncluded,
+		SubmissionExecuted, SubmissionRejected, SubmissionFailed}
+	ActiveSubmissionStatuses = SubmissionStatuses[:4]
+)
+
+// Submission is a transaction entry submitted through send-transaction
+type Submission struct {
+	Key       string          `json:"idempotencykey"`
+	EntryHash *factom.Bytes32 `json:"entryhash"`
+	Entry     factom.Bytes    `json:"-"`
+	TxID      *factom.Bytes32 `json:"txid,omitempty"`
+	Status    string          `json:"status"`
+	Submitted uint32          `json:"submitted"`
+	Height    uint32          `json:"height,omitempty"`
+	Attempts  int             `json:"attempts"`
+	Error     string          `json:"error,omitempty"`
+	Created   time.Time       `json:"created"`
+	Updated   time.Time       `json:"updated"`
+}
+
+const selectSubmission = `SELECT "key", "entry_hash", "entry", "txid", "status", "submitted", "height",
+	"attempts", "error", "created", "updated" FROM "pn_submissions"`
+
+// InsertSubmission records a new submission
+func (Pegnet) InsertSubmission(ctx context.Context, q QueryAble, sub Submission) error {
+	_, err := q.ExecContext(ctx, `INSERT INTO "pn_submissions" ("key", "entry_hash", "entry", "txid", "status",
+		"submitted", "height", "attempts", "error", "created", "updated") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		sub.Key, sub.EntryHash[:], []byte(sub.Entry), submissionTxID(sub), sub.Status,
+		sub.Submitted, sub.Height, sub.Attempts, sub.Error, sub.Created.Unix(), sub.Updated.Unix())
+	return err
+}
+
+// UpdateSubmission records the progress of a submission
+func (Pegnet) UpdateSubmission(ctx context.Context, q QueryAble, sub Submission) error {
+	_, err := q.ExecContext(ctx, `UPDATE "pn_submissions" SET "txid" = ?, "status" = ?, "submitted" = ?,
+		"height" = ?, "attempts" = ?, "error" = ?, "updated" = ? WHERE "key" = ?;`,
+		submissionTxID(sub), sub.Status, sub.Submitted, sub.Height, sub.Attempts, sub.Error, sub.Updated.Unix(), sub.Key)
+	return err
+}
+
+func submissionTxID(sub Submission) []byte {
+	if sub.TxID == nil {
+		return nil
+	}
+	return sub.TxID[:]
+}
+
+// SelectSubmission returns the submission of an idempotency key.
+// sql.ErrNoRows is returned if there is none.
+func (Pegnet) SelectSubmission(ctx context.Context, q QueryAble, key string) (Submission, error) {
+	return scanSubmission(q.QueryRowContext(ctx, selectSubmission+` WHERE "key" = ?;`, key))
+}
+
+// SelectSubmissions returns the submissions with one of the statuses, or all
+// of them if none are given, newest first. At most limit are returned, all of
+// them if limit is < 0, along with the total count.
+func (Pegnet) SelectSubmissions(ctx context.Context, q QueryAble, statuses []string, offset, limit int) ([]Submission, int, error) {
+	var where string
+	args := make([]interface{}, len(statuses))
+	if len(statuses) > 0 {
+		where = ` WHERE "status" IN (?` + strings.Repeat(", ?", len(statuses)-1) + `)`
+		for i, status := range statuses {
+			args[i] = status
+		}
+	}
+
+	var count int
+	err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM "pn_submissions"`+where, args...).Scan(&count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := q.QueryContext(ctx, selectSubmission+where+fmt.Sprintf(` ORDER BY "id" DESC LIMIT %d OFFSET %d;`, limit, offset), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var subs []Submission
+	for rows.Next() {
+		sub, err := scanSubmission(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, count, rows.Err()
+}
+
+func scanSubmission(row interface{ Scan(...interface{}) error }) (Submission, error) {
+	var sub Submission
+	var hash, entry, txID []byte
+	var created, updated int64
+	err := row.Scan(&sub.Key, &hash, &entry, &txID, &sub.Status, &sub.Submitted, &sub.Height,
+		&sub.Attempts, &sub.Error, &created, &updated)
+	if err != nil {
+		return sub, err
+	}
+	sub.EntryHash = new(factom.Bytes32)
+	copy(sub.EntryHash[:], hash)
+	sub.Entry = entry
+	if txID != nil {
+		sub.TxID = new(factom.Bytes32)
+		copy(sub.TxID[:], txID)
+	}
+	sub.Created, sub.Updated = time.Unix(created, 0), time.Unix(updated, 0)
+	return sub, nil
+}
@@ -0,0 +1,70 @@
+package pegnet
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPegnet_Submissions(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "submissions")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "sql.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(createTableSubmissions)
+	require.NoError(t, err)
+	p := &Pegnet{DB: db}
+
+	created := time.Unix(1589276400, 0)
+	for i, key := range []string{"a", "b", "c"} {
+		require.NoError(t, p.InsertSubmission(ctx, db, Submission{
+			Key:       key,
+			EntryHash: &factom.Bytes32{byte(i)},
+			Entry:     factom.Bytes{0x00, byte(i)},
+			Status:    SubmissionQueued,
+			Submitted: 10,
+			Created:   created,
+			Updated:   created,
+		}))
+	}
+	assert.Error(t, p.InsertSubmission(ctx, db, Submission{Key: "a", EntryHash: &factom.Bytes32{}}), "keys are unique")
+
+	sub, err := p.SelectSubmission(ctx, db, "b")
+	require.NoError(t, err)
+	assert.Nil(t, sub.TxID)
+	sub.TxID = &factom.Bytes32{0xff}
+	sub.Status, sub.Attempts, sub.Error = SubmissionRevealed, 1, "timeout"
+	sub.Updated = created.Add(time.Minute)
+	require.NoError(t, p.UpdateSubmission(ctx, db, sub))
+
+	stored, err := p.SelectSubmission(ctx, db, "b")
+	require.NoError(t, err)
+	assert.Equal(t, sub, stored)
+
+	_, err = p.SelectSubmission(ctx, db, "d")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	subs, count, err := p.SelectSubmissions(ctx, db, nil, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	require.Len(t, subs, 2)
+	assert.Equal(t, "c", subs[0].Key, "newest first")
+
+	subs, count, err = p.SelectSubmissions(ctx, db, []string{SubmissionQueued}, 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, subs, 2)
+	assert.Equal(t, "a", subs[1].Key)
+}
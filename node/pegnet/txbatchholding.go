@@ -1,6 +1,7 @@
 package pegnet
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -27,8 +28,8 @@ const createTableTransactionBatchHolding = `CREATE TABLE IF NOT EXISTS "pn_trans
 // Note: It is assumed that the entry stored has already been validated as a fat2
 // transaction batch that contains at least one conversion. It is only put into
 // holding to be executed against future asset exchange rates.
-func (p *Pegnet) InsertTransactionBatchHolding(tx *sql.Tx, txBatch *fat2.TransactionBatch, height uint64, eblockKeyMR *factom.Bytes32) (int64, error) {
-	stmt, err := tx.Prepare(`INSERT INTO "pn_transaction_batch_holding"
+func (p *Pegnet) InsertTransactionBatchHolding(ctx context.Context, tx *sql.Tx, txBatch *fat2.TransactionBatch, height uint64, eblockKeyMR *factom.Bytes32) (int64, error) {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_transaction_batch_holding"
                 ("entry_hash", "entry_data", "height", "eblock_keymr", "unix_timestamp") VALUES
                 (?, ?, ?, ?, ?)`)
 	if err != nil {
@@ -39,7 +40,7 @@ func (p *Pegnet) InsertTransactionBatchHolding(tx *sql.Tx, txBatch *fat2.Transac
 	if err != nil {
 		return -1, err
 	}
-	res, err := stmt.Exec(txBatch.Entry.Hash[:], entryData, height, eblockKeyMR[:], txBatch.Entry.Timestamp.Unix())
+	res, err := stmt.ExecContext(ctx, txBatch.Entry.Hash[:], entryData, height, eblockKeyMR[:], txBatch.Entry.Timestamp.Unix())
 	if err != nil {
 		return -1, err
 	}
@@ -54,9 +55,9 @@ func (p *Pegnet) InsertTransactionBatchHolding(tx *sql.Tx, txBatch *fat2.Transac
 // that are in holding at the given height. It should be assumed that a TransactionBatch
 // in the database has already returned nil for TransactionBatch.Validate() and also that
 // TransactionBatch.HasConversions() returns true.
-func (p *Pegnet) SelectTransactionBatchesInHoldingAtHeight(height uint64) ([]*fat2.TransactionBatch, error) {
+func (p *Pegnet) SelectTransactionBatchesInHoldingAtHeight(ctx context.Context, height uint64) ([]*fat2.TransactionBatch, error) {
 	query := `SELECT "entry_data", "unix_timestamp" FROM "pn_transaction_batch_holding" WHERE "height" == ?;`
-	rows, err := p.DB.Query(query, height)
+	rows, err := p.DB.QueryContext(ctx, query, height)
 	if err != nil {
 		return nil, err
 	}
@@ -86,10 +87,10 @@ func (p *Pegnet) SelectTransactionBatchesInHoldingAtHeight(height uint64) ([]*fa
 	return txBatches, nil
 }
 
-func (p *Pegnet) DoesTransactionExist(entryhash factom.Bytes32) (bool, error) {
+func (p *Pegnet) DoesTransactionExist(ctx context.Context, entryhash factom.Bytes32) (bool, error) {
 	var found []byte
 	query := `SELECT "entry_hash" FROM "pn_address_transactions" WHERE "entry_hash" == ?;`
-	err := p.DB.QueryRow(query, entryhash[:]).Scan(&found)
+	err := p.DB.QueryRowContext(ctx, query, entryhash[:]).Scan(&found)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
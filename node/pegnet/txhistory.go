@@ -1,6 +1,7 @@
 package pegnet
 
 import (
+	"context"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
@@ -119,14 +120,20 @@ COMMIT;
 // only add a lookup reference if one doesn't already exist
 const insertLookupQuery = `INSERT INTO pn_history_lookup (entry_hash, tx_index, address) VALUES (?, ?, ?) ON CONFLICT DO NOTHING;`
 
-func (p *Pegnet) historySelectHelper(field string, data interface{}, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
+func (p *Pegnet) historySelectHelper(ctx context.Context, field string, data interface{}, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
 	countQuery, dataQuery, err := historyQueryBuilder(field, options)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	// A range query has no field to bind
+	var args []interface{}
+	if data != nil {
+		args = append(args, data)
+	}
+
 	var count int
-	err = p.DB.QueryRow(countQuery, data).Scan(&count)
+	err = p.DB.QueryRowContext(ctx, countQuery, args...).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -139,7 +146,7 @@ func (p *Pegnet) historySelectHelper(field string, data interface{}, options His
 		return nil, 0, fmt.Errorf("offset too big")
 	}
 
-	rows, err := p.DB.Query(dataQuery, data)
+	rows, err := p.DB.QueryContext(ctx, dataQuery, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -151,34 +158,40 @@ func (p *Pegnet) historySelectHelper(field string, data interface{}, options His
 
 // SelectTransactionHistoryActionsByHash returns the specified amount of transactions based on the hash.
 // Hash can be an entry hash from the opr and transaction chains, or a transaction hash from an fblock.
-func (p *Pegnet) SelectTransactionHistoryActionsByHash(hash *factom.Bytes32, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
-	return p.historySelectHelper("entry_hash", hash[:], options)
+func (p *Pegnet) SelectTransactionHistoryActionsByHash(ctx context.Context, hash *factom.Bytes32, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
+	return p.historySelectHelper(ctx, "entry_hash", hash[:], options)
 }
 
 // SelectTransactionHistoryActionsByAddress uses the lookup table to retrieve all transactions that have
 // the specified address in either inputs or outputs
-func (p *Pegnet) SelectTransactionHistoryActionsByAddress(addr *factom.FAAddress, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
-	return p.historySelectHelper("address", addr[:], options)
+func (p *Pegnet) SelectTransactionHistoryActionsByAddress(ctx context.Context, addr *factom.FAAddress, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
+	return p.historySelectHelper(ctx, "address", addr[:], options)
 }
 
 // SelectTransactionHistoryActionsByTxID uses the lookup table to retrieve all transactions that have
 // the specified txid. A TxID is an entryhash + a transaction index
-func (p *Pegnet) SelectTransactionHistoryActionsByTxID(hash *factom.Bytes32, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
-	return p.historySelectHelper("entry_hash", hash[:], options)
+func (p *Pegnet) SelectTransactionHistoryActionsByTxID(ctx context.Context, hash *factom.Bytes32, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
+	return p.historySelectHelper(ctx, "entry_hash", hash[:], options)
 }
 
 // SelectTransactionHistoryActionsByHeight returns all transactions that were **entered** at the specified height.
-func (p *Pegnet) SelectTransactionHistoryActionsByHeight(height uint32, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
-	return p.historySelectHelper("height", height, options)
+func (p *Pegnet) SelectTransactionHistoryActionsByHeight(ctx context.Context, height uint32, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
+	return p.historySelectHelper(ctx, "height", height, options)
+}
+
+// SelectTransactionHistoryActionsByRange returns all transactions within the height and/or
+// time window set in the options.
+func (p *Pegnet) SelectTransactionHistoryActionsByRange(ctx context.Context, options HistoryQueryOptions) ([]HistoryTransaction, int, error) {
+	return p.historySelectHelper(ctx, "range", nil, options)
 }
 
 // SelectTransactionHistoryStatus returns the status of a transaction:
 // `-1` for a failed transaction, `0` for a pending transactions,
 // `height` for the block in which it was applied otherwise
-func (p *Pegnet) SelectTransactionHistoryStatus(hash *factom.Bytes32) (uint32, int32, error) {
+func (p *Pegnet) SelectTransactionHistoryStatus(ctx context.Context, hash *factom.Bytes32) (uint32, int32, error) {
 	var height uint32
 	var executed int32
-	err := p.DB.QueryRow("SELECT height, executed FROM pn_history_txbatch WHERE entry_hash = ?", hash[:]).Scan(&height, &executed)
+	err := p.DB.QueryRowContext(ctx, "SELECT height, executed FROM pn_history_txbatch WHERE entry_hash = ?", hash[:]).Scan(&height, &executed)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return 0, 0, nil
@@ -188,13 +201,24 @@ func (p *Pegnet) SelectTransactionHistoryStatus(hash *factom.Bytes32) (uint32, i
 	return height, executed, nil
 }
 
+// SelectTransactionHistoryHeightBeforeTime returns the highest height with history entries
+// timestamped before the given time. Returns 0 if there are none.
+func (p *Pegnet) SelectTransactionHistoryHeightBeforeTime(ctx context.Context, t time.Time) (uint32, error) {
+	var height sql.NullInt64
+	err := p.DB.QueryRowContext(ctx, "SELECT MAX(height) FROM pn_history_txbatch WHERE timestamp < ?", t.Unix()).Scan(&height)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(height.Int64), nil
+}
+
 // SetTransactionHistoryExecuted updates a transaction's executed status
-func (p *Pegnet) SetTransactionHistoryExecuted(tx *sql.Tx, txbatch *fat2.TransactionBatch, executed int64) error {
-	stmt, err := tx.Prepare(`UPDATE "pn_history_txbatch" SET executed = ? WHERE entry_hash = ?`)
+func (p *Pegnet) SetTransactionHistoryExecuted(ctx context.Context, tx *sql.Tx, txbatch *fat2.TransactionBatch, executed int64) error {
+	stmt, err := tx.PrepareContext(ctx, `UPDATE "pn_history_txbatch" SET executed = ? WHERE entry_hash = ?`)
 	if err != nil {
 		return err
 	}
-	_, err = stmt.Exec(executed, txbatch.Entry.Hash[:])
+	_, err = stmt.ExecContext(ctx, executed, txbatch.Entry.Hash[:])
 	if err != nil {
 		return err
 	}
@@ -203,12 +227,12 @@ func (p *Pegnet) SetTransactionHistoryExecuted(tx *sql.Tx, txbatch *fat2.Transac
 
 // SetTransactionHistoryConvertedAmount updates a conversion with the actual conversion value.
 // This is done in the same SQL Transaction as updating its executed status
-func (p *Pegnet) SetTransactionHistoryConvertedAmount(tx *sql.Tx, txbatch *fat2.TransactionBatch, index int, amount int64) error {
-	stmt, err := tx.Prepare(`UPDATE "pn_history_transaction" SET to_amount = ? WHERE entry_hash = ? AND tx_index = ?`)
+func (p *Pegnet) SetTransactionHistoryConvertedAmount(ctx context.Context, tx *sql.Tx, txbatch *fat2.TransactionBatch, index int, amount int64) error {
+	stmt, err := tx.PrepareContext(ctx, `UPDATE "pn_history_transaction" SET to_amount = ? WHERE entry_hash = ? AND tx_index = ?`)
 	if err != nil {
 		return err
 	}
-	_, err = stmt.Exec(amount, txbatch.Entry.Hash[:], index)
+	_, err = stmt.ExecContext(ctx, amount, txbatch.Entry.Hash[:], index)
 	if err != nil {
 		return err
 	}
@@ -219,7 +243,7 @@ func (p *Pegnet) SetTransactionHistoryConvertedAmount(tx *sql.Tx, txbatch *fat2.
 // with the actual amount of PEG received and the refund amount. The refund amount
 // will appear as an output.
 // This is done in the same SQL Transaction as updating its executed status
-func (p *Pegnet) SetTransactionHistoryPEGConvertedRequestAmount(tx *sql.Tx, txbatch *fat2.TransactionBatch, index int, pegAmount, refundAmount int64) error {
+func (p *Pegnet) SetTransactionHistoryPEGConvertedRequestAmount(ctx context.Context, tx *sql.Tx, txbatch *fat2.TransactionBatch, index int, pegAmount, refundAmount int64) error {
 	outputs := make([]HistoryTransactionOutput, 1)
 	outputs[0] = HistoryTransactionOutput{
 		Address: txbatch.Transactions[index].Input.Address,
@@ -230,11 +254,11 @@ func (p *Pegnet) SetTransactionHistoryPEGConvertedRequestAmount(tx *sql.Tx, txba
 		return err
 	}
 
-	stmt, err := tx.Prepare(`UPDATE "pn_history_transaction" SET to_amount = ?, outputs = ? WHERE entry_hash = ? AND tx_index = ?`)
+	stmt, err := tx.PrepareContext(ctx, `UPDATE "pn_history_transaction" SET to_amount = ?, outputs = ? WHERE entry_hash = ? AND tx_index = ?`)
 	if err != nil {
 		return err
 	}
-	_, err = stmt.Exec(pegAmount, out, txbatch.Entry.Hash[:], index)
+	_, err = stmt.ExecContext(ctx, pegAmount, out, txbatch.Entry.Hash[:], index)
 	if err != nil {
 		return err
 	}
@@ -242,26 +266,26 @@ func (p *Pegnet) SetTransactionHistoryPEGConvertedRequestAmount(tx *sql.Tx, txba
 }
 
 // InsertTransactionHistoryTxBatch inserts a transaction from the transaction chain into the history system
-func (p *Pegnet) InsertTransactionHistoryTxBatch(tx *sql.Tx, blockorder int, txbatch *fat2.TransactionBatch, height uint32) error {
-	stmt, err := tx.Prepare(`INSERT INTO "pn_history_txbatch"
+func (p *Pegnet) InsertTransactionHistoryTxBatch(ctx context.Context, tx *sql.Tx, blockorder int, txbatch *fat2.TransactionBatch, height uint32) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_txbatch"
                 (entry_hash, height, blockorder, timestamp, executed) VALUES
                 (?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
-	_, err = stmt.Exec(txbatch.Entry.Hash[:], height, blockorder, txbatch.Entry.Timestamp.Unix(), 0)
+	_, err = stmt.ExecContext(ctx, txbatch.Entry.Hash[:], height, blockorder, txbatch.Entry.Timestamp.Unix(), 0)
 	if err != nil {
 		return err
 	}
 
-	txStatement, err := tx.Prepare(`INSERT INTO "pn_history_transaction"
+	txStatement, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_transaction"
                 (entry_hash, tx_index, action_type, from_address, from_asset, from_amount, to_asset, to_amount, outputs) VALUES
                 (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 
-	lookup, err := tx.Prepare(insertLookupQuery)
+	lookup, err := tx.PrepareContext(ctx, insertLookupQuery)
 	if err != nil {
 		return err
 	}
@@ -274,12 +298,12 @@ func (p *Pegnet) InsertTransactionHistoryTxBatch(tx *sql.Tx, blockorder int, txb
 			typ = Transfer
 		}
 
-		if _, err = lookup.Exec(txbatch.Entry.Hash[:], index, action.Input.Address[:]); err != nil {
+		if _, err = lookup.ExecContext(ctx, txbatch.Entry.Hash[:], index, action.Input.Address[:]); err != nil {
 			return err
 		}
 
 		if action.IsConversion() {
-			_, err = txStatement.Exec(txbatch.Entry.Hash[:], index, typ,
+			_, err = txStatement.ExecContext(ctx, txbatch.Entry.Hash[:], index, typ,
 				action.Input.Address[:], action.Input.Type.String(), action.Input.Amount, // from
 				action.Conversion.String(), 0, "") // to
 			if err != nil {
@@ -290,7 +314,7 @@ func (p *Pegnet) InsertTransactionHistoryTxBatch(tx *sql.Tx, blockorder int, txb
 			outputs := make([]HistoryTransactionOutput, len(action.Transfers))
 			for i, transfer := range action.Transfers {
 				outputs[i] = HistoryTransactionOutput{Address: transfer.Address, Amount: int64(transfer.Amount)}
-				if _, err = lookup.Exec(txbatch.Entry.Hash[:], index, transfer.Address[:]); err != nil {
+				if _, err = lookup.ExecContext(ctx, txbatch.Entry.Hash[:], index, transfer.Address[:]); err != nil {
 					return err
 				}
 			}
@@ -299,7 +323,7 @@ func (p *Pegnet) InsertTransactionHistoryTxBatch(tx *sql.Tx, blockorder int, txb
 				return err
 			}
 
-			if _, err = txStatement.Exec(txbatch.Entry.Hash[:], index, typ,
+			if _, err = txStatement.ExecContext(ctx, txbatch.Entry.Hash[:], index, typ,
 				action.Input.Address[:], action.Input.Type.String(), action.Input.Amount,
 				"", 0, outputData); err != nil {
 				return err
@@ -312,36 +336,36 @@ func (p *Pegnet) InsertTransactionHistoryTxBatch(tx *sql.Tx, blockorder int, txb
 
 // InsertFCTBurn inserts a payout for an FCT burn into the system.
 // Note that from_asset and to_asset are hardcoded
-func (p *Pegnet) InsertFCTBurn(tx *sql.Tx, fBlockHash *factom.Bytes32, burn factom.FactoidTransaction, height uint32) error {
-	stmt, err := tx.Prepare(`INSERT INTO "pn_history_txbatch"
+func (p *Pegnet) InsertFCTBurn(ctx context.Context, tx *sql.Tx, fBlockHash *factom.Bytes32, burn factom.FactoidTransaction, height uint32) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_txbatch"
                 (entry_hash, height, blockorder, timestamp, executed) VALUES
                 (?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 
-	lookup, err := tx.Prepare(insertLookupQuery)
+	lookup, err := tx.PrepareContext(ctx, insertLookupQuery)
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(burn.TransactionID[:], height, -1, burn.FactoidTransactionHeader.TimestampSalt.Unix(), height)
+	_, err = stmt.ExecContext(ctx, burn.TransactionID[:], height, -1, burn.FactoidTransactionHeader.TimestampSalt.Unix(), height)
 	if err != nil {
 		return err
 	}
 
-	burnStatement, err := tx.Prepare(`INSERT INTO "pn_history_transaction"
+	burnStatement, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_transaction"
                 (entry_hash, tx_index, action_type, from_address, from_asset, from_amount, to_asset, to_amount, outputs) VALUES
                 (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 
-	if _, err = burnStatement.Exec(burn.TransactionID[:], 0, FCTBurn, burn.FCTInputs[0].Address[:], "FCT", burn.FCTInputs[0].Amount, "pFCT", burn.FCTInputs[0].Amount, ""); err != nil {
+	if _, err = burnStatement.ExecContext(ctx, burn.TransactionID[:], 0, FCTBurn, burn.FCTInputs[0].Address[:], "FCT", burn.FCTInputs[0].Amount, "pFCT", burn.FCTInputs[0].Amount, ""); err != nil {
 		return err
 	}
 
-	if _, err = lookup.Exec(burn.TransactionID[:], 0, burn.FCTInputs[0].Address[:]); err != nil {
+	if _, err = lookup.ExecContext(ctx, burn.TransactionID[:], 0, burn.FCTInputs[0].Address[:]); err != nil {
 		return err
 	}
 
@@ -350,37 +374,37 @@ func (p *Pegnet) InsertFCTBurn(tx *sql.Tx, fBlockHash *factom.Bytes32, burn fact
 
 // InsertCoinbase inserts the payouts from mining into the history system.
 // There is one transaction per winning OPR, with the entry hash pointing to that specific opr
-func (p *Pegnet) InsertCoinbase(tx *sql.Tx, winner *grader.GradingOPR, addr []byte, timestamp time.Time) error {
-	stmt, err := tx.Prepare(`INSERT INTO "pn_history_txbatch"
+func (p *Pegnet) InsertCoinbase(ctx context.Context, tx *sql.Tx, winner *grader.GradingOPR, addr []byte, timestamp time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_txbatch"
                 (entry_hash, height, blockorder, timestamp, executed) VALUES
                 (?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 
-	lookup, err := tx.Prepare(insertLookupQuery)
+	lookup, err := tx.PrepareContext(ctx, insertLookupQuery)
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(winner.EntryHash, winner.OPR.GetHeight(), 0, timestamp.Unix(), winner.OPR.GetHeight())
+	_, err = stmt.ExecContext(ctx, winner.EntryHash, winner.OPR.GetHeight(), 0, timestamp.Unix(), winner.OPR.GetHeight())
 	if err != nil {
 		return err
 	}
 
-	coinbaseStatement, err := tx.Prepare(`INSERT INTO "pn_history_transaction"
+	coinbaseStatement, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_transaction"
                 (entry_hash, tx_index, action_type, from_address, from_asset, from_amount, to_asset, to_amount, outputs) VALUES
                 (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 
-	_, err = coinbaseStatement.Exec(winner.EntryHash, 0, Coinbase, addr, "", 0, "PEG", winner.Payout(), "")
+	_, err = coinbaseStatement.ExecContext(ctx, winner.EntryHash, 0, Coinbase, addr, "", 0, "PEG", winner.Payout(), "")
 	if err != nil {
 		return err
 	}
 
-	if _, err = lookup.Exec(winner.EntryHash, 0, addr); err != nil {
+	if _, err = lookup.ExecContext(ctx, winner.EntryHash, 0, addr); err != nil {
 		return err
 	}
 
@@ -389,37 +413,37 @@ func (p *Pegnet) InsertCoinbase(tx *sql.Tx, winner *grader.GradingOPR, addr []by
 
 // InsertCoinbase inserts the payouts from staking into the history system.
 // There is one transaction per winning SPR, with the entry hash pointing to that specific spr
-func (p *Pegnet) InsertStaking100Coinbase(tx *sql.Tx, winner *graderStake.GradingSPR, addr []byte, timestamp time.Time) error {
-	stmt, err := tx.Prepare(`INSERT INTO "pn_history_txbatch"
+func (p *Pegnet) InsertStaking100Coinbase(ctx context.Context, tx *sql.Tx, winner *graderStake.GradingSPR, addr []byte, timestamp time.Time) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_txbatch"
                 (entry_hash, height, blockorder, timestamp, executed) VALUES
                 (?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 
-	lookup, err := tx.Prepare(insertLookupQuery)
+	lookup, err := tx.PrepareContext(ctx, insertLookupQuery)
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(winner.EntryHash, winner.SPR.GetHeight(), 0, timestamp.Unix(), winner.SPR.GetHeight())
+	_, err = stmt.ExecContext(ctx, winner.EntryHash, winner.SPR.GetHeight(), 0, timestamp.Unix(), winner.SPR.GetHeight())
 	if err != nil {
 		return err
 	}
 
-	coinbaseStatement, err := tx.Prepare(`INSERT INTO "pn_history_transaction"
+	coinbaseStatement, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_transaction"
                 (entry_hash, tx_index, action_type, from_address, from_asset, from_amount, to_asset, to_amount, outputs) VALUES
                 (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 
-	_, err = coinbaseStatement.Exec(winner.EntryHash, 0, Coinbase, addr, "", 0, "PEG", winner.Payout(), "")
+	_, err = coinbaseStatement.ExecContext(ctx, winner.EntryHash, 0, Coinbase, addr, "", 0, "PEG", winner.Payout(), "")
 	if err != nil {
 		return err
 	}
 
-	if _, err = lookup.Exec(winner.EntryHash, 0, addr); err != nil {
+	if _, err = lookup.ExecContext(ctx, winner.EntryHash, 0, addr); err != nil {
 		return err
 	}
 
@@ -428,7 +452,7 @@ func (p *Pegnet) InsertStaking100Coinbase(tx *sql.Tx, winner *graderStake.Gradin
 
 // InsertStakingCoinbase inserts the payouts from mining into the history system.
 // There is one transaction per winning OPR, with the entry hash pointing to that specific opr
-func (p *Pegnet) InsertStakingCoinbase(tx *sql.Tx, txid string, height uint32, heightTimestamp time.Time, payouts map[string]uint64, addressMap map[string]factom.FAAddress) error {
+func (p *Pegnet) InsertStakingCoinbase(ctx context.Context, tx *sql.Tx, txid string, height uint32, heightTimestamp time.Time, payouts map[string]uint64, addressMap map[string]factom.FAAddress) error {
 	txidBytes, err := hex.DecodeString(txid)
 	if err != nil {
 		return err
@@ -436,7 +460,7 @@ func (p *Pegnet) InsertStakingCoinbase(tx *sql.Tx, txid string, height uint32, h
 
 	// 	First we need to record the batch. The batch is the entire set of transactions, where
 	// 	each tx is a stake payout.
-	stmt, err := tx.Prepare(`INSERT INTO "pn_history_txbatch"
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_txbatch"
                 (entry_hash, height, blockorder, timestamp, executed) VALUES
                 (?, ?, ?, ?, ?)`)
 	if err != nil {
@@ -445,7 +469,7 @@ func (p *Pegnet) InsertStakingCoinbase(tx *sql.Tx, txid string, height uint32, h
 
 	// The Entryhash is the custom txid, it is not an actual entry on chain
 	// The executed height is the same height as the recorded.
-	_, err = stmt.Exec(txidBytes, height, 0, heightTimestamp.Unix(), height)
+	_, err = stmt.ExecContext(ctx, txidBytes, height, 0, heightTimestamp.Unix(), height)
 	if err != nil {
 		return err
 	}
@@ -464,25 +488,25 @@ func (p *Pegnet) InsertStakingCoinbase(tx *sql.Tx, txid string, height uint32, h
 
 		// Insert each payout as a coinbase.
 		// Insert the TX
-		coinbaseStatement, err := tx.Prepare(`INSERT INTO "pn_history_transaction"
+		coinbaseStatement, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_transaction"
 		            (entry_hash, tx_index, action_type, from_address, from_asset, from_amount, to_asset, to_amount, outputs) VALUES
 		            (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 		if err != nil {
 			return err
 		}
 
-		_, err = coinbaseStatement.Exec(txidBytes, index, Coinbase, add, "", 0, "PEG", payout, "")
+		_, err = coinbaseStatement.ExecContext(ctx, txidBytes, index, Coinbase, add, "", 0, "PEG", payout, "")
 		if err != nil {
 			return err
 		}
 
 		// Insert into lookup table
-		lookup, err := tx.Prepare(insertLookupQuery)
+		lookup, err := tx.PrepareContext(ctx, insertLookupQuery)
 		if err != nil {
 			return err
 		}
 
-		if _, err = lookup.Exec(txidBytes, index, add); err != nil {
+		if _, err = lookup.ExecContext(ctx, txidBytes, index, add); err != nil {
 			return err
 		}
 
@@ -491,7 +515,7 @@ func (p *Pegnet) InsertStakingCoinbase(tx *sql.Tx, txid string, height uint32, h
 	return nil
 }
 
-func (p *Pegnet) InsertDeveloperRewardCoinbase(tx *sql.Tx, txid string, addTxid string, height uint32, heightTimestamp time.Time, payout uint64, faAdd factom.FAAddress) error {
+func (p *Pegnet) InsertDeveloperRewardCoinbase(ctx context.Context, tx *sql.Tx, txid string, addTxid string, height uint32, heightTimestamp time.Time, payout uint64, faAdd factom.FAAddress) error {
 	txidBytes, err := hex.DecodeString(txid)
 	if err != nil {
 		log.WithError(err).Errorf("bytes not decoded")
@@ -500,7 +524,7 @@ func (p *Pegnet) InsertDeveloperRewardCoinbase(tx *sql.Tx, txid string, addTxid
 
 	// 	First we need to record the batch. The batch is the entire set of transactions, where
 	// 	each tx is a deverloper reward.
-	stmt, err := tx.Prepare(`INSERT INTO "pn_history_txbatch"
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_txbatch"
                 (entry_hash, height, blockorder, timestamp, executed) VALUES
                 (?, ?, ?, ?, ?)`)
 	if err != nil {
@@ -510,7 +534,7 @@ func (p *Pegnet) InsertDeveloperRewardCoinbase(tx *sql.Tx, txid string, addTxid
 
 	// The Entryhash is the custom txid, it is not an actual entry on chain
 	// The executed height is the same height as the recorded.
-	_, err = stmt.Exec(txidBytes, height, 0, heightTimestamp.Unix(), height)
+	_, err = stmt.ExecContext(ctx, txidBytes, height, 0, heightTimestamp.Unix(), height)
 	if err != nil {
 		log.WithError(err).Errorf("query exec failed")
 		return err
@@ -529,7 +553,7 @@ func (p *Pegnet) InsertDeveloperRewardCoinbase(tx *sql.Tx, txid string, addTxid
 
 	// Insert each payout as a coinbase.
 	// Insert the TX
-	coinbaseStatement, err := tx.Prepare(`INSERT INTO "pn_history_transaction"
+	coinbaseStatement, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_transaction"
 		            (entry_hash, tx_index, action_type, from_address, from_asset, from_amount, to_asset, to_amount, outputs) VALUES
 		            (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
@@ -537,19 +561,19 @@ func (p *Pegnet) InsertDeveloperRewardCoinbase(tx *sql.Tx, txid string, addTxid
 		return err
 	}
 
-	_, err = coinbaseStatement.Exec(txidBytes, index, Coinbase, add, "", 0, "PEG", payout, "")
+	_, err = coinbaseStatement.ExecContext(ctx, txidBytes, index, Coinbase, add, "", 0, "PEG", payout, "")
 	if err != nil {
 		log.WithError(err).Errorf("statement exec failed")
 		return err
 	}
 
 	//Insert into lookup table
-	lookup, err := tx.Prepare(insertLookupQuery)
+	lookup, err := tx.PrepareContext(ctx, insertLookupQuery)
 	if err != nil {
 		return err
 	}
 
-	if _, err = lookup.Exec(txidBytes, index, add); err != nil {
+	if _, err = lookup.ExecContext(ctx, txidBytes, index, add); err != nil {
 		return err
 	}
 
@@ -557,7 +581,7 @@ func (p *Pegnet) InsertDeveloperRewardCoinbase(tx *sql.Tx, txid string, addTxid
 }
 
 // Special construction to nullify burn address
-func (p *Pegnet) InsertZeroingCoinbase(tx *sql.Tx, txid string, addTxid string, height uint32, heightTimestamp time.Time, payout uint64, asset string, faAdd factom.FAAddress) error {
+func (p *Pegnet) InsertZeroingCoinbase(ctx context.Context, tx *sql.Tx, txid string, addTxid string, height uint32, heightTimestamp time.Time, payout uint64, asset string, faAdd factom.FAAddress) error {
 	txidBytes, err := hex.DecodeString(txid)
 	if err != nil {
 		return err
@@ -565,7 +589,7 @@ func (p *Pegnet) InsertZeroingCoinbase(tx *sql.Tx, txid string, addTxid string,
 
 	// 	First we need to record the batch. The batch is the entire set of transactions, where
 	// 	each tx is a stake payout.
-	stmt, err := tx.Prepare(`INSERT INTO "pn_history_txbatch"
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_txbatch"
                 (entry_hash, height, blockorder, timestamp, executed) VALUES
                 (?, ?, ?, ?, ?)`)
 	if err != nil {
@@ -574,7 +598,7 @@ func (p *Pegnet) InsertZeroingCoinbase(tx *sql.Tx, txid string, addTxid string,
 
 	// The Entryhash is the custom txid, it is not an actual entry on chain
 	// The executed height is the same height as the recorded.
-	_, err = stmt.Exec(txidBytes, height, 0, heightTimestamp.Unix(), height)
+	_, err = stmt.ExecContext(ctx, txidBytes, height, 0, heightTimestamp.Unix(), height)
 	if err != nil {
 		return err
 	}
@@ -591,25 +615,25 @@ func (p *Pegnet) InsertZeroingCoinbase(tx *sql.Tx, txid string, addTxid string,
 
 	// Decrease each balance as a coinbase.
 	// Insert the TX
-	coinbaseStatement, err := tx.Prepare(`INSERT INTO "pn_history_transaction"
+	coinbaseStatement, err := tx.PrepareContext(ctx, `INSERT INTO "pn_history_transaction"
 		            (entry_hash, tx_index, action_type, from_address, from_asset, from_amount, to_asset, to_amount, outputs) VALUES
 		            (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
 
-	_, err = coinbaseStatement.Exec(txidBytes, index, Coinbase, add, "", 0, asset, -payout, "") // -payout means we substract value
+	_, err = coinbaseStatement.ExecContext(ctx, txidBytes, index, Coinbase, add, "", 0, asset, -payout, "") // -payout means we substract value
 	if err != nil {
 		return err
 	}
 
 	// Insert into lookup table
-	lookup, err := tx.Prepare(insertLookupQuery)
+	lookup, err := tx.PrepareContext(ctx, insertLookupQuery)
 	if err != nil {
 		return err
 	}
 
-	if _, err = lookup.Exec(txidBytes, index, add); err != nil {
+	if _, err = lookup.ExecContext(ctx, txidBytes, index, add); err != nil {
 		return err
 	}
 
@@ -0,0 +1,97 @@
+package pegnet
+
+import (
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+)
+
+// HistoryBalanceEffect is a single change to the balance of one asset of an
+// address. A history transaction is flattened into one or more of these,
+// which is the format used to export the history of an address for accounting.
+type HistoryBalanceEffect struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Height    int64         `json:"height"`
+	Executed  int32         `json:"executed"`
+	TxID      string        `json:"txid"`
+	TxAction  HistoryAction `json:"txaction"`
+	Asset     string        `json:"asset"`
+	// Amount is negative if the balance decreased
+	Amount int64 `json:"amount"`
+	// Counterparty is the other address of a transfer. It is empty for
+	// conversions, coinbases, and burns.
+	Counterparty string `json:"counterparty,omitempty"`
+
+	// Conversion only. The rates and PIP-10 averages are set by the caller
+	// as they depend on the executed height.
+	FromAsset   string `json:"fromasset,omitempty"`
+	ToAsset     string `json:"toasset,omitempty"`
+	FromRate    uint64 `json:"fromrate,omitempty"`
+	ToRate      uint64 `json:"torate,omitempty"`
+	FromAverage uint64 `json:"fromaverage,omitempty"`
+	ToAverage   uint64 `json:"toaverage,omitempty"`
+}
+
+// BalanceEffects returns the changes the transaction made to the balances of
+// the given address. Transactions that have not been executed (pending or
+// failed) have no effect.
+//
+// Transfers are flattened from the outputs: the sender gets one entry per
+// output, the receiver one entry per output addressed to it. A transfer to
+// oneself results in both.
+func (h HistoryTransaction) BalanceEffects(addr factom.FAAddress) []HistoryBalanceEffect {
+	if h.Executed <= 0 {
+		return nil
+	}
+
+	base := HistoryBalanceEffect{
+		Timestamp: h.Timestamp,
+		Height:    h.Height,
+		Executed:  h.Executed,
+		TxID:      h.TxID,
+		TxAction:  h.TxAction,
+	}
+	isSender := h.FromAddress != nil && *h.FromAddress == addr
+
+	var effects []HistoryBalanceEffect
+	add := func(asset string, amount int64, counterparty string) {
+		e := base
+		e.Asset = asset
+		e.Amount = amount
+		e.Counterparty = counterparty
+		effects = append(effects, e)
+	}
+
+	switch h.TxAction {
+	case Transfer:
+		for _, out := range h.Outputs {
+			if isSender {
+				add(h.FromAsset, -out.Amount, out.Address.String())
+			}
+			if out.Address == addr {
+				add(h.FromAsset, out.Amount, h.FromAddress.String())
+			}
+		}
+	case Conversion:
+		if !isSender {
+			return nil
+		}
+		base.FromAsset = h.FromAsset
+		base.ToAsset = h.ToAsset
+		add(h.FromAsset, -h.FromAmount, "")
+		add(h.ToAsset, h.ToAmount, "")
+		// PEG conversions that hit the bank limit refund the remainder
+		for _, out := range h.Outputs {
+			if out.Address == addr {
+				add(h.FromAsset, out.Amount, "")
+			}
+		}
+	case Coinbase, FCTBurn:
+		if !isSender {
+			return nil
+		}
+		add(h.ToAsset, h.ToAmount, "")
+	}
+
+	return effects
+}
@@ -0,0 +1,53 @@
+package pegnet
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+)
+
+func TestHistoryTransaction_BalanceEffects(t *testing.T) {
+	var a, b, c factom.FAAddress
+	a[0], b[0], c[0] = 1, 2, 3
+
+	transfer := HistoryTransaction{TxID: "0-tx", Executed: 10, TxAction: Transfer, FromAddress: &a, FromAsset: "pUSD", FromAmount: 30,
+		Outputs: []HistoryTransactionOutput{{Address: b, Amount: 10}, {Address: c, Amount: 20}}}
+	self := HistoryTransaction{TxID: "0-tx", Executed: 10, TxAction: Transfer, FromAddress: &a, FromAsset: "pUSD", FromAmount: 5,
+		Outputs: []HistoryTransactionOutput{{Address: a, Amount: 5}}}
+	conversion := HistoryTransaction{TxID: "0-tx", Executed: 10, TxAction: Conversion, FromAddress: &a, FromAsset: "PEG", FromAmount: 100,
+		ToAsset: "pUSD", ToAmount: 2, Outputs: []HistoryTransactionOutput{{Address: a, Amount: 40}}}
+	coinbase := HistoryTransaction{TxID: "0-tx", Executed: 10, TxAction: Coinbase, FromAddress: &a, ToAsset: "PEG", ToAmount: 50}
+	pending := transfer
+	pending.Executed = 0
+
+	effect := func(tx HistoryTransaction, asset string, amount int64, counterparty string) HistoryBalanceEffect {
+		e := HistoryBalanceEffect{TxID: tx.TxID, Executed: tx.Executed, TxAction: tx.TxAction, Asset: asset, Amount: amount, Counterparty: counterparty}
+		if tx.TxAction == Conversion {
+			e.FromAsset, e.ToAsset = tx.FromAsset, tx.ToAsset
+		}
+		return e
+	}
+
+	tests := []struct {
+		name string
+		tx   HistoryTransaction
+		addr factom.FAAddress
+		want []HistoryBalanceEffect
+	}{
+		{"transfer sender", transfer, a, []HistoryBalanceEffect{effect(transfer, "pUSD", -10, b.String()), effect(transfer, "pUSD", -20, c.String())}},
+		{"transfer receiver", transfer, c, []HistoryBalanceEffect{effect(transfer, "pUSD", 20, a.String())}},
+		{"transfer to self", self, a, []HistoryBalanceEffect{effect(self, "pUSD", -5, a.String()), effect(self, "pUSD", 5, a.String())}},
+		{"conversion with refund", conversion, a, []HistoryBalanceEffect{effect(conversion, "PEG", -100, ""), effect(conversion, "pUSD", 2, ""), effect(conversion, "PEG", 40, "")}},
+		{"conversion other address", conversion, b, nil},
+		{"coinbase", coinbase, a, []HistoryBalanceEffect{effect(coinbase, "PEG", 50, "")}},
+		{"pending", pending, a, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tx.BalanceEffects(tt.addr); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BalanceEffects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
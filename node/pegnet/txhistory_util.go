@@ -28,6 +28,21 @@ const (
 	FCTBurn
 )
 
+// String returns the lowercase name of the action
+func (a HistoryAction) String() string {
+	switch a {
+	case Transfer:
+		return "transfer"
+	case Conversion:
+		return "conversion"
+	case Coinbase:
+		return "coinbase"
+	case FCTBurn:
+		return "burn"
+	}
+	return "invalid"
+}
+
 // QueryLimit is the amount of transactions to return in one query
 const QueryLimit = 50
 
@@ -68,6 +83,42 @@ type HistoryQueryOptions struct {
 	// to be "off"
 	UseTxIndex bool
 	TxIndex    int
+
+	// FromHeight and ToHeight limit the results to batches entered within
+	// the inclusive height range. 0 means unbounded.
+	FromHeight uint32
+	ToHeight   uint32
+	// FromTime and ToTime limit the results to batches with a timestamp
+	// in [FromTime, ToTime). The zero time means unbounded.
+	FromTime time.Time
+	ToTime   time.Time
+}
+
+// HasRange returns true if any of the height or time window options are set
+func (o HistoryQueryOptions) HasRange() bool {
+	return o.FromHeight > 0 || o.ToHeight > 0 || !o.FromTime.IsZero() || !o.ToTime.IsZero()
+}
+
+// rangeConditions returns the sql conditions for the height and time windows.
+// The conditions reference the "batch" table.
+func (o HistoryQueryOptions) rangeConditions() string {
+	var conds []string
+	if o.FromHeight > 0 {
+		conds = append(conds, fmt.Sprintf("batch.height >= %d", o.FromHeight))
+	}
+	if o.ToHeight > 0 {
+		conds = append(conds, fmt.Sprintf("batch.height <= %d", o.ToHeight))
+	}
+	if !o.FromTime.IsZero() {
+		conds = append(conds, fmt.Sprintf("batch.timestamp >= %d", o.FromTime.Unix()))
+	}
+	if !o.ToTime.IsZero() {
+		conds = append(conds, fmt.Sprintf("batch.timestamp < %d", o.ToTime.Unix()))
+	}
+	if len(conds) == 0 {
+		return ""
+	}
+	return " AND " + strings.Join(conds, " AND ")
 }
 
 const historyQueryFields = "batch.history_id, batch.entry_hash, batch.height, batch.timestamp, batch.executed," +
@@ -88,15 +139,19 @@ func historyQueryBuilder(field string, options HistoryQueryOptions) (string, str
 	var from, where, fromCount, whereCount string
 	switch field {
 	case "address":
-		if types != nil || options.Asset != "" {
+		from = "pn_history_lookup lookup, pn_history_txbatch batch, pn_history_transaction tx"
+		where = "lookup.address = ? AND lookup.entry_hash = tx.entry_hash AND lookup.tx_index = tx.tx_index AND batch.entry_hash = tx.entry_hash"
+		if options.HasRange() {
+			// The window is on the batch table, so the count needs it too
+			fromCount = from
+			whereCount = where
+		} else if types != nil || options.Asset != "" {
 			fromCount = "pn_history_lookup lookup, pn_history_transaction tx"
 			whereCount = "lookup.address = ? AND lookup.entry_hash = tx.entry_hash AND lookup.tx_index = tx.tx_index"
 		} else {
 			fromCount = "pn_history_lookup"
 			whereCount = "address = ?"
 		}
-		from = "pn_history_lookup lookup, pn_history_txbatch batch, pn_history_transaction tx"
-		where = "lookup.address = ? AND lookup.entry_hash = tx.entry_hash AND lookup.tx_index = tx.tx_index AND batch.entry_hash = tx.entry_hash"
 	case "entry_hash":
 		fallthrough
 	case "height":
@@ -104,6 +159,15 @@ func historyQueryBuilder(field string, options HistoryQueryOptions) (string, str
 		where = fmt.Sprintf("batch.entry_hash = tx.entry_hash AND batch.%s = ?", field)
 		fromCount = from
 		whereCount = where
+	case "range":
+		// No single field to match, the window options do all the filtering
+		if !options.HasRange() {
+			return "", "", fmt.Errorf("a range query requires a height or time window")
+		}
+		from = "pn_history_txbatch batch, pn_history_transaction tx"
+		where = "batch.entry_hash = tx.entry_hash"
+		fromCount = from
+		whereCount = where
 	default:
 		return "", "", fmt.Errorf("developer error - unimplemented history query builder field")
 	}
@@ -122,6 +186,12 @@ func historyQueryBuilder(field string, options HistoryQueryOptions) (string, str
 		whereCount += fmt.Sprintf(" AND (tx.from_asset = '%s' OR tx.to_asset = '%s')", options.Asset, options.Asset)
 	}
 
+	if options.HasRange() {
+		rng := options.rangeConditions()
+		where += rng
+		whereCount += rng
+	}
+
 	if types != nil {
 		where = fmt.Sprintf("(%s) AND tx.action_type IN(%s)", where, strings.Join(types, ","))
 		whereCount = fmt.Sprintf("(%s) AND tx.action_type IN(%s)", whereCount, strings.Join(types, ","))
@@ -3,6 +3,7 @@ package pegnet
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestHistoryQueryBuilder(t *testing.T) {
@@ -26,6 +27,10 @@ func TestHistoryQueryBuilder(t *testing.T) {
 		{"height, default args", args{"height", HistoryQueryOptions{}}, "SELECT COUNT(*) FROM pn_history_txbatch batch, pn_history_transaction tx WHERE batch.entry_hash = tx.entry_hash AND batch.height = ?", "SELECT batch.history_id, batch.entry_hash, batch.height, batch.timestamp, batch.executed,tx.tx_index, tx.action_type, tx.from_address, tx.from_asset, tx.from_amount, tx.outputs,tx.to_asset, tx.to_amount FROM pn_history_txbatch batch, pn_history_transaction tx WHERE batch.entry_hash = tx.entry_hash AND batch.height = ? ORDER BY batch.history_id ASC LIMIT 50 OFFSET 0", false},
 		{"address, default args", args{"address", HistoryQueryOptions{}}, "SELECT COUNT(*) FROM pn_history_lookup WHERE address = ?", "SELECT batch.history_id, batch.entry_hash, batch.height, batch.timestamp, batch.executed,tx.tx_index, tx.action_type, tx.from_address, tx.from_asset, tx.from_amount, tx.outputs,tx.to_asset, tx.to_amount FROM pn_history_lookup lookup, pn_history_txbatch batch, pn_history_transaction tx WHERE lookup.address = ? AND lookup.entry_hash = tx.entry_hash AND lookup.tx_index = tx.tx_index AND batch.entry_hash = tx.entry_hash ORDER BY batch.history_id ASC LIMIT 50 OFFSET 0", false},
 		{"address, typed", args{"address", HistoryQueryOptions{Conversion: true, Transfer: true}}, "SELECT COUNT(*) FROM pn_history_lookup lookup, pn_history_transaction tx WHERE (lookup.address = ? AND lookup.entry_hash = tx.entry_hash AND lookup.tx_index = tx.tx_index) AND tx.action_type IN(1,2)", "SELECT batch.history_id, batch.entry_hash, batch.height, batch.timestamp, batch.executed,tx.tx_index, tx.action_type, tx.from_address, tx.from_asset, tx.from_amount, tx.outputs,tx.to_asset, tx.to_amount FROM pn_history_lookup lookup, pn_history_txbatch batch, pn_history_transaction tx WHERE (lookup.address = ? AND lookup.entry_hash = tx.entry_hash AND lookup.tx_index = tx.tx_index AND batch.entry_hash = tx.entry_hash) AND tx.action_type IN(1,2) ORDER BY batch.history_id ASC LIMIT 50 OFFSET 0", false},
+		{"address, height range", args{"address", HistoryQueryOptions{FromHeight: 100, ToHeight: 200}}, "SELECT COUNT(*) FROM pn_history_lookup lookup, pn_history_txbatch batch, pn_history_transaction tx WHERE lookup.address = ? AND lookup.entry_hash = tx.entry_hash AND lookup.tx_index = tx.tx_index AND batch.entry_hash = tx.entry_hash AND batch.height >= 100 AND batch.height <= 200", "SELECT batch.history_id, batch.entry_hash, batch.height, batch.timestamp, batch.executed,tx.tx_index, tx.action_type, tx.from_address, tx.from_asset, tx.from_amount, tx.outputs,tx.to_asset, tx.to_amount FROM pn_history_lookup lookup, pn_history_txbatch batch, pn_history_transaction tx WHERE lookup.address = ? AND lookup.entry_hash = tx.entry_hash AND lookup.tx_index = tx.tx_index AND batch.entry_hash = tx.entry_hash AND batch.height >= 100 AND batch.height <= 200 ORDER BY batch.history_id ASC LIMIT 50 OFFSET 0", false},
+		{"range, time window", args{"range", HistoryQueryOptions{FromTime: time.Unix(1577836800, 0), ToTime: time.Unix(1609459200, 0)}}, "SELECT COUNT(*) FROM pn_history_txbatch batch, pn_history_transaction tx WHERE batch.entry_hash = tx.entry_hash AND batch.timestamp >= 1577836800 AND batch.timestamp < 1609459200", "SELECT batch.history_id, batch.entry_hash, batch.height, batch.timestamp, batch.executed,tx.tx_index, tx.action_type, tx.from_address, tx.from_asset, tx.from_amount, tx.outputs,tx.to_asset, tx.to_amount FROM pn_history_txbatch batch, pn_history_transaction tx WHERE batch.entry_hash = tx.entry_hash AND batch.timestamp >= 1577836800 AND batch.timestamp < 1609459200 ORDER BY batch.history_id ASC LIMIT 50 OFFSET 0", false},
+		{"range, typed", args{"range", HistoryQueryOptions{FromHeight: 100, FCTBurn: true}}, "SELECT COUNT(*) FROM pn_history_txbatch batch, pn_history_transaction tx WHERE (batch.entry_hash = tx.entry_hash AND batch.height >= 100) AND tx.action_type IN(4)", "SELECT batch.history_id, batch.entry_hash, batch.height, batch.timestamp, batch.executed,tx.tx_index, tx.action_type, tx.from_address, tx.from_asset, tx.from_amount, tx.outputs,tx.to_asset, tx.to_amount FROM pn_history_txbatch batch, pn_history_transaction tx WHERE (batch.entry_hash = tx.entry_hash AND batch.height >= 100) AND tx.action_type IN(4) ORDER BY batch.history_id ASC LIMIT 50 OFFSET 0", false},
+		{"range, no window", args{"range", HistoryQueryOptions{}}, "", "", true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
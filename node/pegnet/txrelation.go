@@ -1,6 +1,7 @@
 package pegnet
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/Factom-Asset-Tokens/factom"
@@ -33,17 +34,17 @@ CREATE INDEX IF NOT EXISTS "idx_address_transactions_address" ON "pn_address_tra
 // returned.
 //
 // If isConversion is true, the to field will automatically be set to true.
-func (p *Pegnet) InsertTransactionRelation(tx *sql.Tx, adr factom.FAAddress, entryHash *factom.Bytes32, txIndex uint64, to bool, isConversion bool) (int64, error) {
+func (p *Pegnet) InsertTransactionRelation(ctx context.Context, tx *sql.Tx, adr factom.FAAddress, entryHash *factom.Bytes32, txIndex uint64, to bool, isConversion bool) (int64, error) {
 	// If an address is the sender and the receiver, then we only record the sender side, not the receiver.
 	// This is some loss of information, but for the use case of getting all related transactions,
 	// it is fine.
-	stmt, err := tx.Prepare(`INSERT INTO "pn_address_transactions"
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO "pn_address_transactions"
                 ("entry_hash", "address", "tx_index", "to", "conversion") VALUES
                 (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`)
 	if err != nil {
 		return -1, err
 	}
-	res, err := stmt.Exec(entryHash[:], adr[:], txIndex, to || isConversion, isConversion)
+	res, err := stmt.ExecContext(ctx, entryHash[:], adr[:], txIndex, to || isConversion, isConversion)
 	if err != nil {
 		return -1, err
 	}
@@ -56,8 +57,8 @@ func (p *Pegnet) InsertTransactionRelation(tx *sql.Tx, adr factom.FAAddress, ent
 
 // IsReplayTransaction returns true if there exist any transaction relations in the
 // "pn_address_transactions" table.
-func (p *Pegnet) IsReplayTransaction(tx *sql.Tx, entryHash *factom.Bytes32) (bool, error) {
-	rows, err := tx.Query(`SELECT * FROM "pn_address_transactions" WHERE "entry_hash" = ?;`, entryHash[:])
+func (p *Pegnet) IsReplayTransaction(ctx context.Context, tx *sql.Tx, entryHash *factom.Bytes32) (bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT * FROM "pn_address_transactions" WHERE "entry_hash" = ?;`, entryHash[:])
 	if err != nil {
 		return false, err
 	}
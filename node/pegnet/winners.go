@@ -36,7 +36,7 @@ func (p *Pegnet) SelectMinerDominance(ctx context.Context, start, stop int) (Min
 
 	// First check the start/stop bounds and tighten them if we need to
 	stmtString := `SELECT COALESCE(MIN(height), 0) AS min, COALESCE(MAX(height), 0) AS max FROM pn_winners`
-	row := p.DB.QueryRow(stmtString)
+	row := p.DB.QueryRowContext(ctx, stmtString)
 	var min, max int
 	err := row.Scan(&min, &max)
 	if err != nil {
@@ -0,0 +1,205 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/pegnet/pegnetd/node/pegnet"
+	log "github.com/sirupsen/logrus"
+)
+
+// PendingPool holds the transaction batches in factomd's pending entries for
+// the transaction chain, the ones that are not in a synced block yet. The pool
+// is emptied when a block is synced, and refilled by the next poll.
+type PendingPool struct {
+	mu      sync.RWMutex
+	batches []*PendingBatch
+	// entries are all the polled entries, nil for the invalid ones
+	entries map[factom.Bytes32]*PendingBatch
+	// generation is incremented on every reset, so a poll that started
+	// before a block was synced does not bring back its entries
+	generation int
+}
+
+// PendingBatch is a valid transaction batch from the pending entries
+type PendingBatch struct {
+	*fat2.TransactionBatch
+	// History has the history entries of the batch, as they are recorded
+	// once it is in a block, without a height
+	History []pegnet.HistoryTransaction
+}
+
+// NewPendingPool returns an empty pool
+func NewPendingPool() *PendingPool {
+	return &PendingPool{entries: make(map[factom.Bytes32]*PendingBatch)}
+}
+
+// Batches returns the pending transaction batches, in the order factomd
+// returned them
+func (p *PendingPool) Batches() []*PendingBatch {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]*PendingBatch(nil), p.batches...)
+}
+
+// Batch returns the pending transaction batch of the entry hash, or nil
+func (p *PendingPool) Batch(hash factom.Bytes32) *PendingBatch {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.entries[hash]
+}
+
+// Balances returns the amounts the pending transactions send to and from the
+// address, by asset. The outputs of conversions are not known until they are
+// executed, so only their inputs are included.
+func (p *PendingPool) Balances(addr factom.FAAddress) (incoming, outgoing map[fat2.PTicker]uint64) {
+	incoming, outgoing = make(map[fat2.PTicker]uint64), make(map[fat2.PTicker]uint64)
+	for _, batch := range p.Batches() {
+		for _, tx := range batch.Transactions {
+			if tx.Input.Address == addr {
+				outgoing[tx.Input.Type] += tx.Input.Amount
+			}
+			if tx.IsConversion() {
+				continue
+			}
+			for _, transfer := range tx.Transfers {
+				if transfer.Address == addr {
+					incoming[tx.Input.Type] += transfer.Amount
+				}
+			}
+		}
+	}
+	return incoming, outgoing
+}
+
+func (p *PendingPool) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches = nil
+	p.entries = make(map[factom.Bytes32]*PendingBatch)
+	p.generation++
+}
+
+func (p *PendingPool) snapshot() (map[factom.Bytes32]*PendingBatch, int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.entries, p.generation
+}
+
+func (p *PendingPool) replace(generation int, batches []*PendingBatch, entries map[factom.Bytes32]*PendingBatch) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if generation != p.generation {
+		return // A block was synced during the poll
+	}
+	p.batches = batches
+	p.entries = entries
+}
+
+// PendingSync polls the pending entries into the pending pool every period
+// until the context is done
+func (d *Pegnetd) PendingSync(ctx context.Context) {
+	period := d.Config.GetDuration(config.PendingPollPeriod)
+	for {
+		if err := d.pollPending(ctx); err != nil && ctx.Err() == nil {
+			log.WithError(err).Debug("failed to poll the pending entries")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(period):
+		}
+	}
+}
+
+func (d *Pegnetd) pollPending(ctx context.Context) error {
+	pending := new(factom.PendingEntries)
+	if err := pending.Get(ctx, d.FactomClient); err != nil {
+		return err
+	}
+
+	known, generation := d.Pending.snapshot()
+	height := d.GetCurrentSync() + 1
+	entries := make(map[factom.Bytes32]*PendingBatch)
+	var batches []*PendingBatch
+	for _, entry := range pending.Entries(&config.TransactionChain) {
+		if entry.Hash == nil {
+			continue
+		}
+		if _, ok := entries[*entry.Hash]; ok {
+			continue
+		}
+		batch, ok := known[*entry.Hash]
+		if !ok {
+			var err error
+			if batch, err = d.pendingBatch(ctx, entry, height); err != nil {
+				// Tried again on the next poll
+				log.WithError(err).WithField("entryhash", entry.Hash.String()).Debug("failed to get a pending entry")
+				continue
+			}
+		}
+		entries[*entry.Hash] = batch
+		if batch != nil {
+			batches = append(batches, batch)
+		}
+	}
+	d.Pending.replace(generation, batches, entries)
+	return nil
+}
+
+// pendingBatch returns the transaction batch of a pending entry, or nil if it
+// is not valid or already in a synced block
+func (d *Pegnetd) pendingBatch(ctx context.Context, entry factom.Entry, height uint32) (*PendingBatch, error) {
+	synced, _, err := d.Pegnet.SelectTransactionHistoryStatus(ctx, entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if synced != 0 {
+		return nil, nil
+	}
+
+	if err := entry.Get(ctx, d.FactomClient); err != nil {
+		return nil, err
+	}
+	// The timestamp in the ExtIDs is checked against the one of the entry,
+	// which is not known until it is in a block
+	entry.Timestamp = time.Now()
+	txBatch, err := fat2.NewTransactionBatch(entry, int32(height))
+	if err != nil {
+		return nil, nil // Bad formatted entry
+	}
+	return &PendingBatch{TransactionBatch: txBatch, History: pendingHistory(txBatch)}, nil
+}
+
+// pendingHistory returns the history entries of the transactions of a batch
+func pendingHistory(txBatch *fat2.TransactionBatch) []pegnet.HistoryTransaction {
+	history := make([]pegnet.HistoryTransaction, len(txBatch.Transactions))
+	for i, tx := range txBatch.Transactions {
+		from := tx.Input.Address
+		h := pegnet.HistoryTransaction{
+			Hash:        txBatch.Entry.Hash,
+			TxID:        pegnet.FormatTxID(i, txBatch.Entry.Hash.String()),
+			Timestamp:   txBatch.Entry.Timestamp,
+			TxIndex:     i,
+			FromAddress: &from,
+			FromAsset:   tx.Input.Type.String(),
+			FromAmount:  int64(tx.Input.Amount),
+		}
+		if tx.IsConversion() {
+			h.TxAction = pegnet.Conversion
+			h.ToAsset = tx.Conversion.String()
+		} else {
+			h.TxAction = pegnet.Transfer
+			h.Outputs = make([]pegnet.HistoryTransactionOutput, len(tx.Transfers))
+			for j, transfer := range tx.Transfers {
+				h.Outputs[j] = pegnet.HistoryTransactionOutput{Address: transfer.Address, Amount: int64(transfer.Amount)}
+			}
+		}
+		history[i] = h
+	}
+	return history
+}
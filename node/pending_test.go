@@ -0,0 +1,66 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/pegnet/pegnetd/node/pegnet"
+)
+
+func pendingTestBatch(hash byte, txs ...fat2.Transaction) *PendingBatch {
+	txBatch := &fat2.TransactionBatch{Transactions: txs}
+	txBatch.Entry.Hash = &factom.Bytes32{hash}
+	return &PendingBatch{TransactionBatch: txBatch, History: pendingHistory(txBatch)}
+}
+
+func TestPendingPool(t *testing.T) {
+	a, b := factom.FAAddress{1}, factom.FAAddress{2}
+	transfer := pendingTestBatch(1, fat2.Transaction{
+		Input:     fat2.TypedAddressAmountTuple{Address: a, Amount: 10, Type: fat2.PTickerPEG},
+		Transfers: []fat2.AddressAmountTuple{{Address: b, Amount: 10}},
+	})
+	conversion := pendingTestBatch(2, fat2.Transaction{
+		Input:      fat2.TypedAddressAmountTuple{Address: b, Amount: 5, Type: fat2.PTickerPEG},
+		Conversion: fat2.PTickerUSD,
+	})
+
+	pool := NewPendingPool()
+	_, generation := pool.snapshot()
+	pool.replace(generation, []*PendingBatch{transfer, conversion}, map[factom.Bytes32]*PendingBatch{
+		*transfer.Entry.Hash:   transfer,
+		*conversion.Entry.Hash: conversion,
+		{3}:                    nil, // invalid
+	})
+
+	if len(pool.Batches()) != 2 || pool.Batch(factom.Bytes32{2}) != conversion || pool.Batch(factom.Bytes32{3}) != nil {
+		t.Errorf("unexpected batches %v", pool.Batches())
+	}
+
+	// The output of the conversion is not known until it is executed
+	incoming, outgoing := pool.Balances(b)
+	if len(incoming) != 1 || incoming[fat2.PTickerPEG] != 10 {
+		t.Errorf("unexpected incoming %v", incoming)
+	}
+	if len(outgoing) != 1 || outgoing[fat2.PTickerPEG] != 5 {
+		t.Errorf("unexpected outgoing %v", outgoing)
+	}
+
+	history := conversion.History
+	if len(history) != 1 || history[0].TxAction != pegnet.Conversion || history[0].ToAsset != "pUSD" ||
+		history[0].TxID != pegnet.FormatTxID(0, conversion.Entry.Hash.String()) {
+		t.Errorf("unexpected history %+v", history)
+	}
+	if history := transfer.History; len(history) != 1 || history[0].TxAction != pegnet.Transfer ||
+		len(history[0].Outputs) != 1 || history[0].Outputs[0].Address != b {
+		t.Errorf("unexpected history %+v", history)
+	}
+
+	// A poll that started before a block was synced is dropped
+	_, generation = pool.snapshot()
+	pool.reset()
+	pool.replace(generation, []*PendingBatch{transfer}, map[factom.Bytes32]*PendingBatch{*transfer.Entry.Hash: transfer})
+	if len(pool.Batches()) != 0 || pool.Batch(*transfer.Entry.Hash) != nil {
+		t.Errorf("expected an empty pool after a block was synced")
+	}
+}
@@ -0,0 +1,266 @@
+package pnl
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/pegnet/pegnetd/node/pegnet"
+)
+
+// Method is the cost basis method used to match disposals to acquisitions
+type Method string
+
+const (
+	// FIFO matches a disposal with the oldest acquisitions first
+	FIFO Method = "fifo"
+	// Average uses the average cost of all the units held
+	Average Method = "avg"
+)
+
+// Valuer returns the value of an amount of an asset at a given height,
+// denominated in the report currency. The amount is never negative.
+type Valuer func(asset string, amount int64, height uint32) (int64, error)
+
+// Lot is a single line of the itemized report. A disposed lot is the part of
+// an acquisition that was matched with a disposal. An open lot is the part of
+// an acquisition that is still held, valued at the end of the report.
+//
+// For the Average method, disposals are not matched with a specific
+// acquisition, so the acquisition fields are empty.
+type Lot struct {
+	Asset  string `json:"asset"`
+	Amount int64  `json:"amount"`
+
+	AcquiredTxID   string    `json:"acquiredtxid,omitempty"`
+	AcquiredHeight int64     `json:"acquiredheight,omitempty"`
+	AcquiredTime   time.Time `json:"acquiredtime,omitempty"`
+
+	// Empty for open lots
+	DisposedTxID   string    `json:"disposedtxid,omitempty"`
+	DisposedHeight int64     `json:"disposedheight,omitempty"`
+	DisposedTime   time.Time `json:"disposedtime,omitempty"`
+
+	Cost int64 `json:"cost"`
+	// Proceeds is the value at disposal, or the value at the end of the report
+	// for open lots
+	Proceeds int64 `json:"proceeds"`
+	Gain     int64 `json:"gain"`
+	Open     bool  `json:"open"`
+}
+
+// AssetSummary are the totals for one asset
+type AssetSummary struct {
+	Asset      string `json:"asset"`
+	Balance    int64  `json:"balance"`
+	Cost       int64  `json:"cost"`
+	Value      int64  `json:"value"`
+	Realized   int64  `json:"realized"`
+	Unrealized int64  `json:"unrealized"`
+}
+
+// Report is the profit and loss of a single address
+type Report struct {
+	Method Method         `json:"method"`
+	Assets []AssetSummary `json:"assets"`
+	Lots   []Lot          `json:"lots"`
+}
+
+// lot is an acquisition that is (partially) held
+type lot struct {
+	txid   string
+	height int64
+	time   time.Time
+	amount int64
+	cost   int64
+}
+
+// position is the state of a single asset
+type position struct {
+	lots    []lot // FIFO only
+	amount  int64
+	cost    int64
+	summary AssetSummary
+}
+
+// Compute builds the report for the balance effects of an address, which must
+// be in the order they were executed. Every acquisition and disposal is valued
+// at its executed height.
+//
+// Realized gains are only reported for disposals at or after `since`, but the
+// effects before it are needed to know the cost basis. Open lots are valued at
+// `endHeight`.
+//
+// A disposal of more than the history shows was acquired has a cost basis of 0
+// for the difference.
+func Compute(method Method, effects []pegnet.HistoryBalanceEffect, since time.Time, endHeight uint32, value Valuer) (*Report, error) {
+	if method != FIFO && method != Average {
+		return nil, fmt.Errorf("unknown method '%s'", method)
+	}
+
+	report := &Report{Method: method, Assets: make([]AssetSummary, 0), Lots: make([]Lot, 0)}
+	positions := make(map[string]*position)
+
+	for _, e := range netEffects(effects) {
+		p, ok := positions[e.Asset]
+		if !ok {
+			p = &position{summary: AssetSummary{Asset: e.Asset}}
+			positions[e.Asset] = p
+		}
+
+		amount := e.Amount
+		if amount < 0 {
+			amount = -amount
+		}
+		val, err := value(e.Asset, amount, uint32(e.Executed))
+		if err != nil {
+			return nil, fmt.Errorf("%s at height %d: %s", e.Asset, e.Executed, err.Error())
+		}
+
+		if e.Amount > 0 {
+			p.amount += amount
+			p.cost += val
+			if method == FIFO {
+				p.lots = append(p.lots, lot{txid: e.TxID, height: e.Height, time: e.Timestamp, amount: amount, cost: val})
+			}
+			continue
+		}
+
+		// Match the disposal with what is held
+		var matched []Lot
+		remaining := amount
+		switch method {
+		case FIFO:
+			for remaining > 0 && len(p.lots) > 0 {
+				l := &p.lots[0]
+				take := min(l.amount, remaining)
+				cost := fraction(l.cost, take, l.amount)
+				matched = append(matched, Lot{Amount: take, AcquiredTxID: l.txid, AcquiredHeight: l.height, AcquiredTime: l.time, Cost: cost})
+
+				l.amount -= take
+				l.cost -= cost
+				p.amount -= take
+				p.cost -= cost
+				remaining -= take
+				if l.amount == 0 {
+					p.lots = p.lots[1:]
+				}
+			}
+		case Average:
+			if p.amount > 0 {
+				take := min(p.amount, remaining)
+				cost := fraction(p.cost, take, p.amount)
+				matched = append(matched, Lot{Amount: take, Cost: cost})
+
+				p.amount -= take
+				p.cost -= cost
+				remaining -= take
+			}
+		}
+		if remaining > 0 {
+			matched = append(matched, Lot{Amount: remaining})
+		}
+
+		if e.Timestamp.Before(since) {
+			continue
+		}
+		for _, l := range matched {
+			l.Asset = e.Asset
+			l.DisposedTxID, l.DisposedHeight, l.DisposedTime = e.TxID, e.Height, e.Timestamp
+			l.Proceeds = fraction(val, l.Amount, amount)
+			l.Gain = l.Proceeds - l.Cost
+			p.summary.Realized += l.Gain
+			report.Lots = append(report.Lots, l)
+		}
+	}
+
+	assets := make([]string, 0, len(positions))
+	for asset := range positions {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	// Value what is still held
+	for _, asset := range assets {
+		p := positions[asset]
+		end, err := value(asset, p.amount, endHeight)
+		if err != nil {
+			return nil, fmt.Errorf("%s at height %d: %s", asset, endHeight, err.Error())
+		}
+
+		p.summary.Balance = p.amount
+		p.summary.Cost = p.cost
+		p.summary.Value = end
+		p.summary.Unrealized = end - p.cost
+		report.Assets = append(report.Assets, p.summary)
+
+		open := func(l Lot) {
+			l.Asset = asset
+			l.Proceeds = fraction(end, l.Amount, p.amount)
+			l.Gain = l.Proceeds - l.Cost
+			l.Open = true
+			report.Lots = append(report.Lots, l)
+		}
+		switch {
+		case p.amount == 0:
+		case method == FIFO:
+			for _, l := range p.lots {
+				open(Lot{Amount: l.amount, AcquiredTxID: l.txid, AcquiredHeight: l.height, AcquiredTime: l.time, Cost: l.cost})
+			}
+		case method == Average:
+			open(Lot{Amount: p.amount, Cost: p.cost})
+		}
+	}
+
+	return report, nil
+}
+
+// netEffects merges the effects of a transaction on the same asset, so a
+// transfer to oneself or the refund of a PEG conversion is not treated as a
+// disposal followed by an acquisition. Effects that cancel out are dropped.
+func netEffects(effects []pegnet.HistoryBalanceEffect) []pegnet.HistoryBalanceEffect {
+	var res []pegnet.HistoryBalanceEffect
+	start := 0 // the first effect of the current transaction in res
+	for _, e := range effects {
+		if len(res) > 0 && res[len(res)-1].TxID != e.TxID {
+			start = len(res)
+		}
+
+		merged := false
+		for i := start; i < len(res); i++ {
+			if res[i].Asset == e.Asset {
+				res[i].Amount += e.Amount
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			res = append(res, e)
+		}
+	}
+
+	netted := res[:0]
+	for _, e := range res {
+		if e.Amount != 0 {
+			netted = append(netted, e)
+		}
+	}
+	return netted
+}
+
+// fraction returns a * num / den without overflowing
+func fraction(a, num, den int64) int64 {
+	if den == 0 {
+		return 0
+	}
+	r := new(big.Int).Mul(big.NewInt(a), big.NewInt(num))
+	return r.Quo(r, big.NewInt(den)).Int64()
+}
+
+func min(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,95 @@
+package pnl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pegnet/pegnetd/node/pegnet"
+	. "github.com/pegnet/pegnetd/node/pnl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// price is the value of one unit of PEG at each height
+var price = map[uint32]int64{1: 10, 2: 20, 3: 40, 4: 30}
+
+func valuer(asset string, amount int64, height uint32) (int64, error) {
+	if asset == "pUSD" {
+		return amount, nil
+	}
+	return amount * price[height], nil
+}
+
+func effect(txid string, height int64, asset string, amount int64) pegnet.HistoryBalanceEffect {
+	return pegnet.HistoryBalanceEffect{
+		TxID:      txid,
+		Height:    height,
+		Executed:  int32(height),
+		Timestamp: time.Unix(height*600, 0),
+		Asset:     asset,
+		Amount:    amount,
+	}
+}
+
+var history = []pegnet.HistoryBalanceEffect{
+	effect("a", 1, "PEG", 10),  // cost 100
+	effect("b", 2, "PEG", 10),  // cost 200
+	effect("c", 3, "PEG", -15), // proceeds 600
+	effect("c", 3, "pUSD", 600),
+	// a transfer to oneself has no effect
+	effect("d", 4, "PEG", -5),
+	effect("d", 4, "PEG", 5),
+}
+
+func TestCompute_FIFO(t *testing.T) {
+	r, err := Compute(FIFO, history, time.Time{}, 4, valuer)
+	require.NoError(t, err)
+
+	require.Len(t, r.Assets, 2)
+	peg := r.Assets[0]
+	assert.Equal(t, AssetSummary{Asset: "PEG", Balance: 5, Cost: 100, Value: 150, Realized: 600 - 100 - 100, Unrealized: 50}, peg)
+	assert.Equal(t, AssetSummary{Asset: "pUSD", Balance: 600, Cost: 600, Value: 600}, r.Assets[1])
+
+	// 2 disposed lots, 1 open PEG lot, 1 open pUSD lot
+	require.Len(t, r.Lots, 4)
+	assert.Equal(t, "a", r.Lots[0].AcquiredTxID)
+	assert.Equal(t, int64(10), r.Lots[0].Amount)
+	assert.Equal(t, int64(400-100), r.Lots[0].Gain)
+	assert.Equal(t, "b", r.Lots[1].AcquiredTxID)
+	assert.Equal(t, int64(5), r.Lots[1].Amount)
+	assert.Equal(t, int64(200-100), r.Lots[1].Gain)
+	assert.True(t, r.Lots[2].Open)
+	assert.Equal(t, "b", r.Lots[2].AcquiredTxID)
+	assert.Equal(t, int64(150-100), r.Lots[2].Gain)
+}
+
+func TestCompute_Average(t *testing.T) {
+	r, err := Compute(Average, history, time.Time{}, 4, valuer)
+	require.NoError(t, err)
+
+	// average cost is 15 per PEG
+	assert.Equal(t, AssetSummary{Asset: "PEG", Balance: 5, Cost: 75, Value: 150, Realized: 600 - 225, Unrealized: 75}, r.Assets[0])
+	require.Len(t, r.Lots, 3)
+	assert.Equal(t, "", r.Lots[0].AcquiredTxID)
+	assert.Equal(t, int64(225), r.Lots[0].Cost)
+}
+
+func TestCompute_Since(t *testing.T) {
+	// the disposal is before the window, so nothing is realized
+	r, err := Compute(FIFO, history, time.Unix(4*600, 0), 4, valuer)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), r.Assets[0].Realized)
+	assert.Equal(t, int64(100), r.Assets[0].Cost)
+	for _, l := range r.Lots {
+		assert.True(t, l.Open)
+	}
+}
+
+func TestCompute_NoBasis(t *testing.T) {
+	r, err := Compute(FIFO, []pegnet.HistoryBalanceEffect{effect("a", 1, "PEG", -10)}, time.Time{}, 4, valuer)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), r.Assets[0].Realized)
+
+	_, err = Compute("lifo", history, time.Time{}, 4, valuer)
+	assert.Error(t, err)
+}
@@ -0,0 +1,296 @@
+package node
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/node/pegnet"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrSubmissionKeyReused is returned when an idempotency key is submitted
+// again with a different entry
+var ErrSubmissionKeyReused = errors.New("the idempotency key was used for a different entry")
+
+// submissionCallTimeout bounds every commit and reveal, so a factomd that
+// does not answer does not hold up the queue
+const submissionCallTimeout = 10 * time.Second
+
+// SubmissionQueue commits and reveals the transaction entries of
+// send-transaction, and follows them until they are executed or rejected. A
+// commit or reveal that fails is retried, and a reveal that does not make it
+// into a block within resubmit blocks is revealed again, up to attempts times.
+type SubmissionQueue struct {
+	// A submission is claimed by the key while it is advanced, so it is
+	// advanced by one caller at a time. The lock is not held across the
+	// calls to factomd.
+	mu       sync.Mutex
+	advanced map[string]bool
+	d        *Pegnetd
+
+	resubmit uint32
+	attempts int
+	timeout  time.Duration
+	wake     chan struct{}
+
+	now func() time.Time
+}
+
+func newSubmissionQueue(d *Pegnetd) *SubmissionQueue {
+	return &SubmissionQueue{
+		advanced: make(map[string]bool),
+		d:        d,
+		resubmit: uint32(d.Config.GetInt(config.SubmissionsResubmit)),
+		attempts: d.Config.GetInt(config.SubmissionsAttempts),
+		timeout:  submissionCallTimeout,
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// claim claims the submission of the key, and returns false if it is claimed
+// already
+func (q *SubmissionQueue) claim(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.advanced[key] {
+		return false
+	}
+	q.advanced[key] = true
+	return true
+}
+
+func (q *SubmissionQueue) release(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.advanced, key)
+}
+
+// Submit queues the entry under the idempotency key, the entry hash if it is
+// empty, and makes a first attempt at committing and revealing it. An entry
+// submitted again under the same key is not submitted twice, the existing
+// submission is returned instead. check is called before a new entry is
+// queued, and its error is returned as is. A failed attempt is not an error,
+// it is recorded in the submission and retried by SubmissionSync.
+func (q *SubmissionQueue) Submit(ctx context.Context, key string, entry factom.Entry, check func() error) (sub pegnet.Submission, err error) {
+	data, err := entry.MarshalBinary()
+	if err != nil {
+		return sub, err
+	}
+	hash := factom.ComputeEntryHash(data)
+	if key == "" {
+		key = hash.String()
+	}
+
+	existing := func() (pegnet.Submission, bool, error) {
+		sub, err := q.d.Pegnet.SelectSubmission(ctx, q.d.Pegnet.DB, key)
+		if err == sql.ErrNoRows {
+			return sub, false, nil
+		}
+		if err == nil && *sub.EntryHash != hash {
+			err = ErrSubmissionKeyReused
+		}
+		return sub, true, err
+	}
+	if sub, ok, err := existing(); ok || err != nil {
+		return sub, err
+	}
+	if err := check(); err != nil {
+		return sub, err
+	}
+
+	// The submission is inserted and claimed at once, so it is not advanced
+	// by process before the first attempt
+	q.mu.Lock()
+	if sub, ok, err := existing(); ok || err != nil {
+		q.mu.Unlock()
+		return sub, err
+	}
+	now := q.now()
+	sub = pegnet.Submission{
+		Key:       key,
+		EntryHash: &hash,
+		Entry:     data,
+		Status:    pegnet.SubmissionQueued,
+		Submitted: q.d.GetCurrentSync(),
+		Created:   now,
+		Updated:   now,
+	}
+	if err := q.d.Pegnet.InsertSubmission(ctx, q.d.Pegnet.DB, sub); err != nil {
+		q.mu.Unlock()
+		return sub, err
+	}
+	q.advanced[key] = true
+	q.mu.Unlock()
+	defer q.release(key)
+
+	if err := q.advance(ctx, &sub); err != nil {
+		return sub, err
+	}
+	sub.Updated = q.now()
+	// The outcome of the attempt is recorded even if the caller went away,
+	// so the entry is not committed twice
+	return sub, q.d.Pegnet.UpdateSubmission(context.Background(), q.d.Pegnet.DB, sub)
+}
+
+// notify wakes up SubmissionSync, once a block is synced
+func (q *SubmissionQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// process advances all the active submissions, except the ones that are
+// being advanced by Submit
+func (q *SubmissionQueue) process(ctx context.Context) error {
+	subs, _, err := q.d.Pegnet.SelectSubmissions(ctx, q.d.Pegnet.DB, pegnet.ActiveSubmissionStatuses, 0, -1)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if err := q.processKey(ctx, sub.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processKey advances the submission of the key, if it is not claimed
+func (q *SubmissionQueue) processKey(ctx context.Context, key string) error {
+	if !q.claim(key) {
+		return nil
+	}
+	defer q.release(key)
+
+	// It may have been advanced since it was selected
+	sub, err := q.d.Pegnet.SelectSubmission(ctx, q.d.Pegnet.DB, key)
+	if err != nil {
+		return err
+	}
+	before := sub
+	if err := q.advance(ctx, &sub); err != nil {
+		return err
+	}
+	if sub.Status == before.Status && sub.Error == before.Error && sub.Attempts == before.Attempts &&
+		sub.Height == before.Height && sub.Submitted == before.Submitted {
+		return nil
+	}
+	sub.Updated = q.now()
+	return q.d.Pegnet.UpdateSubmission(ctx, q.d.Pegnet.DB, sub)
+}
+
+// advance moves a submission along its statuses as far as it can. The errors
+// of factomd are recorded in the submission, the returned error is internal.
+func (q *SubmissionQueue) advance(ctx context.Context, sub *pegnet.Submission) error {
+	height := q.d.GetCurrentSync()
+
+	// A commit or reveal that timed out may have made it anyway
+	synced, executed, err := q.d.Pegnet.SelectTransactionHistoryStatus(ctx, sub.EntryHash)
+	if err != nil {
+		return err
+	}
+	switch {
+	case executed > 0:
+		sub.Status, sub.Height, sub.Error = pegnet.SubmissionExecuted, synced, ""
+		return nil
+	case executed < 0:
+		sub.Status, sub.Height, sub.Error = pegnet.SubmissionRejected, synced, ""
+		return nil
+	case synced != 0:
+		sub.Status, sub.Height, sub.Error = pegnet.SubmissionIncluded, synced, ""
+		return nil
+	}
+
+	if sub.Status == pegnet.SubmissionRevealed && height < sub.Submitted+q.resubmit {
+		return nil // It still has time to make it into a block
+	}
+	// An entry that made it into a block, but not into the history, is not
+	// a valid transaction, or was revealed too late. It is not revealed or
+	// paid for again.
+	if sub.Attempts > 0 && sub.Status != pegnet.SubmissionCommitted && q.onChain(ctx, sub) {
+		sub.Status, sub.Error = pegnet.SubmissionRejected, "the entry is in a block, but it is not a valid transaction"
+		return nil
+	}
+
+	if sub.Status == pegnet.SubmissionRevealed {
+		if sub.Attempts >= q.attempts {
+			sub.Status = pegnet.SubmissionFailed
+			return nil
+		}
+		// Revealed again with the same commit, which is committed again if
+		// the reveal fails
+		sub.Status = pegnet.SubmissionCommitted
+	}
+
+	if sub.Status == pegnet.SubmissionQueued {
+		var es factom.EsAddress
+		if err := es.Set(q.d.Config.GetString(config.ECPrivateKey)); err != nil {
+			sub.Error = err.Error()
+			return nil
+		}
+		var entry factom.Entry
+		if err := entry.UnmarshalBinary(sub.Entry); err != nil {
+			return err
+		}
+		commit, _, txID, err := entry.Compose(es)
+		if err != nil {
+			return err
+		}
+		if err := q.call(ctx, func(ctx context.Context) error { return q.d.FactomClient.Commit(ctx, commit) }); err != nil {
+			sub.Error = err.Error()
+			return nil
+		}
+		sub.Status, sub.TxID, sub.Submitted, sub.Error = pegnet.SubmissionCommitted, &txID, height, ""
+	}
+
+	if sub.Status == pegnet.SubmissionCommitted {
+		if err := q.call(ctx, func(ctx context.Context) error { return q.d.FactomClient.Reveal(ctx, sub.Entry) }); err != nil {
+			sub.Error = err.Error()
+			if height >= sub.Submitted+q.resubmit {
+				// The commit did not make it, or expired
+				sub.Status = pegnet.SubmissionQueued
+			}
+			return nil
+		}
+		sub.Status, sub.Submitted, sub.Error = pegnet.SubmissionRevealed, height, ""
+		sub.Attempts++
+	}
+	return nil
+}
+
+// onChain returns true if factomd has the entry of the submission. An error
+// of factomd counts as false, the commit or reveal that follows fails as well.
+func (q *SubmissionQueue) onChain(ctx context.Context, sub *pegnet.Submission) bool {
+	entry := factom.Entry{Hash: sub.EntryHash}
+	return q.call(ctx, func(ctx context.Context) error { return entry.Get(ctx, q.d.FactomClient) }) == nil
+}
+
+// call calls factomd within the timeout
+func (q *SubmissionQueue) call(ctx context.Context, f func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+	return f(ctx)
+}
+
+// SubmissionSync advances the active submissions every period, and once a
+// block is synced, until the context is done
+func (d *Pegnetd) SubmissionSync(ctx context.Context) {
+	period := d.Config.GetDuration(config.SubmissionsRetry)
+	for {
+		if err := d.Submissions.process(ctx); err != nil && ctx.Err() == nil {
+			log.WithError(err).Warn("failed to process the submission queue")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.Submissions.wake:
+		case <-time.After(period):
+		}
+	}
+}
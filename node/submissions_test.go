@@ -0,0 +1,235 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/node/pegnet"
+	"github.com/spf13/viper"
+)
+
+// fakeSubmitFactomd counts the commits and reveals it accepts, and serves the
+// raw data of the entries in blocks
+type fakeSubmitFactomd struct {
+	commits, reveals int
+	down             bool
+	hang             int32 // until the request is canceled, set atomically
+	blocks           fakeEntryFactomd
+}
+
+func (f *fakeSubmitFactomd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		ID     int    `json:"id"`
+		Method string `json:"method"`
+		Params struct {
+			Hash  factom.Bytes32 `json:"hash"`
+			Entry factom.Bytes   `json:"entry"`
+		} `json:"params"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	if atomic.LoadInt32(&f.hang) == 1 {
+		<-r.Context().Done()
+		return
+	}
+	switch req.Method {
+	case "commit-entry":
+		f.commits++
+	case "reveal-entry":
+		if _, ok := f.blocks[factom.ComputeEntryHash(req.Params.Entry)]; ok {
+			f.error(w, req.ID, "Repeated Commit")
+			return
+		}
+		f.reveals++
+	case "raw-data":
+		data, ok := f.blocks[req.Params.Hash]
+		if !ok {
+			f.error(w, req.ID, "Missing Chain Head")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": map[string]interface{}{"data": data}})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": map[string]string{}})
+}
+
+func (f *fakeSubmitFactomd) error(w http.ResponseWriter, id int, message string) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": id,
+		"error": map[string]interface{}{"code": -32009, "message": message}})
+}
+
+func TestSubmissionQueue(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "submissions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fake := &fakeSubmitFactomd{down: true, blocks: make(fakeEntryFactomd)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	es, err := factom.GenerateEsAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := viper.New()
+	conf.Set(config.SqliteDBPath, filepath.Join(dir, "sql.db"))
+	conf.Set(config.ECPrivateKey, es.String())
+	conf.Set(config.SubmissionsResubmit, 3)
+	conf.Set(config.SubmissionsAttempts, 2)
+
+	d := &Pegnetd{Config: conf, FactomClient: factom.NewClient(), Sync: &pegnet.BlockSync{Synced: 100}}
+	d.FactomClient.FactomdServer = server.URL + "/v2"
+	d.Pegnet = pegnet.New(conf)
+	if err := d.Pegnet.Init(); err != nil {
+		t.Fatal(err)
+	}
+	d.Submissions = newSubmissionQueue(d)
+
+	entry := factom.Entry{ChainID: &factom.Bytes32{1}, ExtIDs: []factom.Bytes{{1}}, Content: factom.Bytes("{}")}
+	check := func() error { return nil }
+
+	// factomd is down, the entry stays queued
+	sub, err := d.Submissions.Submit(ctx, "", entry, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Status != pegnet.SubmissionQueued || sub.Error == "" || sub.Key != sub.EntryHash.String() {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+
+	// The same key is not submitted twice, and only for the same entry
+	fake.down = false
+	if again, err := d.Submissions.Submit(ctx, sub.Key, entry, check); err != nil || again.Status != pegnet.SubmissionQueued {
+		t.Errorf("unexpected submission %+v, %v", again, err)
+	}
+	other := entry
+	other.Content = factom.Bytes("{ }")
+	if _, err := d.Submissions.Submit(ctx, sub.Key, other, check); err != ErrSubmissionKeyReused {
+		t.Errorf("expected ErrSubmissionKeyReused, got %v", err)
+	}
+	if fake.commits != 0 {
+		t.Errorf("expected no commits, got %d", fake.commits)
+	}
+
+	status := func() pegnet.Submission {
+		if err := d.Submissions.process(ctx); err != nil {
+			t.Fatal(err)
+		}
+		sub, err := d.Pegnet.SelectSubmission(ctx, d.Pegnet.DB, sub.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sub
+	}
+
+	if sub := status(); sub.Status != pegnet.SubmissionRevealed || sub.Attempts != 1 || sub.TxID == nil || sub.Error != "" {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+
+	// Not in a block within resubmit blocks, it is revealed again, until
+	// there are no attempts left
+	d.Sync.Synced = 103
+	if sub := status(); sub.Status != pegnet.SubmissionRevealed || sub.Attempts != 2 || sub.Submitted != 103 {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+	if fake.commits != 1 || fake.reveals != 2 {
+		t.Errorf("expected 1 commit and 2 reveals, got %d and %d", fake.commits, fake.reveals)
+	}
+
+	// Included, and then executed
+	d.Sync.Synced = 104
+	_, err = d.Pegnet.DB.Exec(`INSERT INTO "pn_history_txbatch" (entry_hash, height, blockorder, timestamp, executed) VALUES (?, ?, ?, ?, ?)`,
+		sub.EntryHash[:], 104, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub := status(); sub.Status != pegnet.SubmissionIncluded || sub.Height != 104 {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+	if _, err = d.Pegnet.DB.Exec(`UPDATE "pn_history_txbatch" SET executed = 105`); err != nil {
+		t.Fatal(err)
+	}
+	if sub := status(); sub.Status != pegnet.SubmissionExecuted || sub.Height != 104 {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+
+	// Another entry fails once it is out of attempts
+	entry.Content = factom.Bytes(`{"a":1}`)
+	sub, err = d.Submissions.Submit(ctx, "b", entry, check)
+	if err != nil || sub.Status != pegnet.SubmissionRevealed {
+		t.Fatalf("unexpected submission %+v, %v", sub, err)
+	}
+	d.Sync.Synced = 107
+	status()
+	d.Sync.Synced = 110
+	if sub := status(); sub.Status != pegnet.SubmissionFailed || sub.Attempts != 2 {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+
+	// A factomd that does not answer times out, and the entry stays queued
+	atomic.StoreInt32(&fake.hang, 1)
+	d.Submissions.timeout = 50 * time.Millisecond
+	entry.Content = factom.Bytes(`{"a":2}`)
+	sub, err = d.Submissions.Submit(ctx, "c", entry, check)
+	if err != nil || sub.Status != pegnet.SubmissionQueued || sub.Error == "" {
+		t.Fatalf("unexpected submission %+v, %v", sub, err)
+	}
+
+	// A submission that is being advanced is left alone by process, and is
+	// returned as is to the callers of its key
+	atomic.StoreInt32(&fake.hang, 0)
+	if !d.Submissions.claim("c") {
+		t.Fatal("expected the submission to be released")
+	}
+	if sub := status(); sub.Status != pegnet.SubmissionQueued {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+	if again, err := d.Submissions.Submit(ctx, "c", entry, check); err != nil || again.Status != pegnet.SubmissionQueued {
+		t.Errorf("unexpected submission %+v, %v", again, err)
+	}
+	d.Submissions.release("c")
+	if sub := status(); sub.Status != pegnet.SubmissionRevealed {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+
+	// An entry that is in a block, but not in the history, is not a valid
+	// transaction, and is not committed again
+	entry.Content = factom.Bytes(`{"a":3}`)
+	sub, err = d.Submissions.Submit(ctx, "d", entry, check)
+	if err != nil || sub.Status != pegnet.SubmissionRevealed {
+		t.Fatalf("unexpected submission %+v, %v", sub, err)
+	}
+	data, err := entry.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.blocks[*sub.EntryHash] = data
+	commits := fake.commits
+	d.Sync.Synced = 113
+	if sub := status(); sub.Status != pegnet.SubmissionRejected || sub.Error == "" || sub.Attempts != 1 {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+	d.Sync.Synced = 120
+	if sub := status(); sub.Status != pegnet.SubmissionRejected || sub.Attempts != 1 {
+		t.Errorf("unexpected submission %+v", sub)
+	}
+	if fake.commits != commits {
+		t.Errorf("expected no commits, got %d", fake.commits-commits)
+	}
+}
@@ -0,0 +1,56 @@
+package node
+
+import (
+	"github.com/pegnet/pegnetd/node/pegnet"
+)
+
+// blockSummary is the summary of the block being synced. SyncBlock fills it
+// in and records it, and it is passed to the OnBlockSynced callbacks once the
+// block is committed.
+type blockSummary pegnet.BlockSummary
+
+func newBlockSummary(height uint32) *blockSummary {
+	return &blockSummary{
+		Height:     height,
+		OPRWinners: []pegnet.BlockWinner{},
+		SPRWinners: []pegnet.BlockWinner{},
+		Payouts:    make(map[string]uint64),
+	}
+}
+
+// The methods that record the outcomes are no-ops on a nil summary, for the
+// callers of the sync steps outside of SyncBlock.
+
+func (s *blockSummary) executed() {
+	if s != nil {
+		s.Executed++
+	}
+}
+
+func (s *blockSummary) rejected() {
+	if s != nil {
+		s.Rejected++
+	}
+}
+
+func (s *blockSummary) held() {
+	if s != nil {
+		s.Held++
+	}
+}
+
+func (s *blockSummary) paid(category string, amount uint64) {
+	if s != nil {
+		s.Payouts[category] += amount
+	}
+}
+
+// pegRequests adds the PEG conversions of a height in holding. Before the V4
+// update each height in holding has its own bank.
+func (s *blockSummary) pegRequests(bank, requested, allocated uint64) {
+	if s != nil {
+		s.Bank += bank
+		s.PEGRequested += requested
+		s.PEGAllocated += allocated
+	}
+}
@@ -45,6 +45,16 @@ OuterSyncLoop:
 			continue // Loop will just keep retrying until factomd is reached
 		}
 
+		// Make sure factomd still agrees with what we synced
+		if err := d.VerifyTip(ctx, heights.DirectoryBlock); err != nil {
+			if divergence, ok := err.(*DivergenceError); ok {
+				log.WithField("height", d.Sync.Synced).Fatal(divergence)
+			}
+			log.WithError(err).Errorf("failed to verify the synced dblock")
+			time.Sleep(retryPeriod)
+			continue
+		}
+
 		if d.Sync.Synced >= heights.DirectoryBlock {
 			// We are currently synced, nothing to do. If we are above it, the factomd could
 			// be rebooted
@@ -105,6 +115,10 @@ OuterSyncLoop:
 			// one by one. We can only sync our current synced height +1
 			// TODO: This skips the genesis block. I'm sure that is fine
 			if err := d.SyncBlock(ctx, tx, d.Sync.Synced+1); err != nil {
+				if divergence, ok := err.(*DivergenceError); ok {
+					_ = tx.Rollback()
+					hLog.Fatal(divergence)
+				}
 				hLog.WithError(err).Errorf("failed to sync height")
 				time.Sleep(retryPeriod)
 				// If we fail, we backout to the outer loop. This allows error handling on factomd state to be a bit
@@ -120,7 +134,7 @@ OuterSyncLoop:
 			// Bump our sync, and march forward
 
 			d.Sync.Synced++
-			err = d.Pegnet.InsertSynced(tx, d.Sync)
+			err = d.Pegnet.InsertSynced(ctx, tx, d.Sync)
 			if err != nil {
 				d.Sync.Synced--
 				hLog.WithError(err).Errorf("unable to update synced metadata")
@@ -141,6 +155,8 @@ OuterSyncLoop:
 					// TODO evaluate if we can recover from this point or not
 					hLog.WithError(err).Fatal("unable to roll back transaction")
 				}
+			} else {
+				d.blockSynced(pegnet.BlockSummary(*d.summary))
 			}
 
 			elapsed := time.Since(start)
@@ -184,7 +200,7 @@ func (d *Pegnetd) MintTokensForBalance(ctx context.Context, tx *sql.Tx, height u
 	}
 
 	for _, tokenSupply := range MintTotalSupplyMap {
-		_, err := d.Pegnet.AddToBalance(tx, &FAGlobalMintAddress, tokenSupply.Ticker, tokenSupply.Amount*1e8)
+		_, err := d.Pegnet.AddToBalance(ctx, tx, &FAGlobalMintAddress, tokenSupply.Ticker, tokenSupply.Amount*1e8)
 		if err != nil {
 			fLog.WithFields(log.Fields{
 				"token":  tokenSupply.Ticker,
@@ -210,7 +226,7 @@ func (d *Pegnetd) NullifyMintedTokens(ctx context.Context, tx *sql.Tx, height ui
 	}
 
 	// Get all balances for the address
-	balances, err := d.Pegnet.SelectBalances(&FAGlobalMintAddress)
+	balances, err := d.Pegnet.SelectBalances(ctx, &FAGlobalMintAddress)
 	if err != nil {
 		fLog.WithFields(log.Fields{
 			"err": err,
@@ -221,7 +237,7 @@ func (d *Pegnetd) NullifyMintedTokens(ctx context.Context, tx *sql.Tx, height ui
 		// Substract from every issuance
 		ticker := tokenSupply.Ticker
 		value, _ := balances[ticker]
-		_, _, err := d.Pegnet.SubFromBalance(tx, &FAGlobalMintAddress, ticker, value) // lastInd, txErr, err
+		_, _, err := d.Pegnet.SubFromBalance(ctx, tx, &FAGlobalMintAddress, ticker, value) // lastInd, txErr, err
 		if err != nil {
 			fLog.WithFields(log.Fields{
 				"ticker":  ticker,
@@ -254,11 +270,15 @@ func (d *Pegnetd) NullifyBurnAddress(ctx context.Context, tx *sql.Tx, height uin
 		}
 	}
 
+	d.summary = newBlockSummary(height)
+
 	dblock := new(factom.DBlock)
 	dblock.Height = height
 	if err := dblock.Get(nil, d.FactomClient); err != nil {
 		return err
 	}
+	d.summary.KeyMR = *dblock.KeyMR
+	d.summary.Timestamp = dblock.Timestamp
 	heightTimestamp := dblock.Timestamp
 
 	// We need to mock a TXID to record zeroing
@@ -268,7 +288,7 @@ func (d *Pegnetd) NullifyBurnAddress(ctx context.Context, tx *sql.Tx, height uin
 	// 2. substract amounts for every ticker
 
 	// Get all balances for the address
-	balances, err := d.Pegnet.SelectBalances(&FAGlobalBurnAddress)
+	balances, err := d.Pegnet.SelectBalances(ctx, &FAGlobalBurnAddress)
 
 	if err != nil {
 		fLog.WithFields(log.Fields{
@@ -285,7 +305,7 @@ func (d *Pegnetd) NullifyBurnAddress(ctx context.Context, tx *sql.Tx, height uin
 
 		// Substract from every issuance
 		value, _ := balances[ticker]
-		_, _, err := d.Pegnet.SubFromBalance(tx, &FAGlobalBurnAddress, ticker, value) // lastInd, txErr, err
+		_, _, err := d.Pegnet.SubFromBalance(ctx, tx, &FAGlobalBurnAddress, ticker, value) // lastInd, txErr, err
 		if err != nil {
 			fLog.WithFields(log.Fields{
 				"time":    heightTimestamp,
@@ -317,7 +337,7 @@ func (d *Pegnetd) NullifyBurnAddress(ctx context.Context, tx *sql.Tx, height uin
 		}).Info("burn nullify | prep")
 
 		if height < config.V202EnhanceActivation {
-			err = d.Pegnet.InsertZeroingCoinbase(tx, txid, addTxid, height, heightTimestamp, value, ticker.String(), FAGlobalBurnAddress)
+			err = d.Pegnet.InsertZeroingCoinbase(ctx, tx, txid, addTxid, height, heightTimestamp, value, ticker.String(), FAGlobalBurnAddress)
 			if err != nil {
 				fLog.WithFields(log.Fields{
 					"error": err,
@@ -357,6 +377,15 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 		return err
 	}
 
+	// The new dblock has to build on the one we synced before it
+	if prev, err := d.Pegnet.SelectDBlock(ctx, tx, height-1); err == nil {
+		if *dblock.PrevKeyMR != prev.KeyMR {
+			return &DivergenceError{Height: prev.Height, Block: "DBlock", Synced: prev.KeyMR.String(), Factomd: dblock.PrevKeyMR.String()}
+		}
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
 	// First, gather all entries we need from factomd
 	oprEBlock := dblock.EBlock(config.OPRChain)
 	if oprEBlock != nil {
@@ -376,6 +405,15 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 			return err
 		}
 	}
+	if err := d.Pegnet.InsertDBlock(ctx, tx, dblockKeyMRs(dblock, oprEBlock, sprEBlock, transactionsEBlock)); err != nil {
+		return err
+	}
+	if oprEBlock != nil {
+		d.summary.OPREntries = len(oprEBlock.Entries)
+	}
+	if sprEBlock != nil {
+		d.summary.SPREntries = len(sprEBlock.Entries)
+	}
 
 	// Then, grade the new OPR Block. The results of this will be used
 	// to execute conversions that are in holding.
@@ -388,7 +426,7 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 		}
 		isRatesAvailable = gradedBlock != nil && 0 < len(gradedBlock.Winners())
 		if gradedBlock != nil {
-			err = d.Pegnet.InsertGradeBlock(tx, oprEBlock, gradedBlock)
+			err = d.Pegnet.InsertGradeBlock(ctx, tx, oprEBlock, gradedBlock)
 			if err != nil {
 				return err
 			}
@@ -409,7 +447,7 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 					phase = pegnet.PEGPriceIsFloating
 				}
 
-				err = d.Pegnet.InsertRates(tx, height, winners[0].OPR.GetOrderedAssetsUint(), phase)
+				err = d.Pegnet.InsertRates(ctx, tx, height, winners[0].OPR.GetOrderedAssetsUint(), phase)
 				if err != nil {
 					return err
 				}
@@ -432,7 +470,7 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 		var sprWinners []opr.AssetUint
 
 		if gradedBlock != nil {
-			err = d.Pegnet.InsertGradeBlock(tx, oprEBlock, gradedBlock)
+			err = d.Pegnet.InsertGradeBlock(ctx, tx, oprEBlock, gradedBlock)
 			if err != nil {
 				return err
 			}
@@ -462,7 +500,7 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 			isRatesAvailable = true
 			var phase pegnet.PEGPricingPhase
 			phase = pegnet.PEGPriceIsFloating
-			err = d.Pegnet.InsertRates(tx, height, filteredRates, phase)
+			err = d.Pegnet.InsertRates(ctx, tx, height, filteredRates, phase)
 			if err != nil {
 				return err
 			}
@@ -472,6 +510,20 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 		}
 	}
 
+	d.summary.Rates = isRatesAvailable
+	if gradedBlock != nil {
+		for _, winner := range gradedBlock.Winners() {
+			d.summary.OPRWinners = append(d.summary.OPRWinners, pegnet.BlockWinner{
+				EntryHash: winner.EntryHash, Address: winner.OPR.GetAddress(), Payout: winner.Payout()})
+		}
+	}
+	if gradedSPRBlock != nil && height >= config.V20HeightActivation {
+		for _, winner := range gradedSPRBlock.Winners() {
+			d.summary.SPRWinners = append(d.summary.SPRWinners, pegnet.BlockWinner{
+				EntryHash: winner.EntryHash, Address: winner.SPR.GetAddress(), Payout: winner.Payout()})
+		}
+	}
+
 	// Only apply transactions if we crossed the activation
 	if height >= config.TransactionConversionActivation {
 		rates, err := d.Pegnet.SelectPendingRates(ctx, tx, height)
@@ -499,7 +551,7 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 			// If no rates for second time, skip Snapshot logic
 			// otherwise proceed with payout
 			if rates != nil {
-				err := d.SnapshotPayouts(tx, fLog, rates, height, dblock.Timestamp)
+				err := d.SnapshotPayouts(ctx, tx, fLog, rates, height, dblock.Timestamp)
 				if err != nil {
 					// something wrong happend during payout execution
 					return err
@@ -528,7 +580,7 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 
 		//2) Sync transactions in current height and apply transactions
 		if transactionsEBlock != nil {
-			if err = d.ApplyTransactionBlock(tx, transactionsEBlock); err != nil {
+			if err = d.ApplyTransactionBlock(ctx, tx, transactionsEBlock); err != nil {
 				return err
 			}
 		}
@@ -547,7 +599,7 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 	// 4) Apply effects of graded OPR Block (PEG rewards, if any)
 	//    These funds will be available for transactions and conversions executed in the next block
 	if gradedBlock != nil {
-		if err := d.ApplyGradedOPRBlock(tx, gradedBlock, dblock.Timestamp); err != nil {
+		if err := d.ApplyGradedOPRBlock(ctx, tx, gradedBlock, dblock.Timestamp); err != nil {
 			return err
 		}
 	}
@@ -556,7 +608,7 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 		// 5) Apply effects of graded SPR Block (PEG rewards, if any)
 		//    These funds will be available for transactions and conversions executed in the next block
 		if gradedSPRBlock != nil {
-			if err := d.ApplyGradedSPRBlock(tx, gradedSPRBlock, dblock.Timestamp); err != nil {
+			if err := d.ApplyGradedSPRBlock(ctx, tx, gradedSPRBlock, dblock.Timestamp); err != nil {
 				return err
 			}
 		}
@@ -572,27 +624,27 @@ func (d *Pegnetd) SyncBlock(ctx context.Context, tx *sql.Tx, height uint32) erro
 
 		// we want function to accepts dev list as parameter, so different corner cases
 		// can be assigned
-		err := d.DevelopersPayouts(tx, fLog, height, dblock.Timestamp, developersList)
+		err := d.DevelopersPayouts(ctx, tx, fLog, height, dblock.Timestamp, developersList)
 		if err != nil {
 			fLog.WithFields(log.Fields{"section": "devReward", "reason": "developer reward"}).Tracef("something wrong happend during dev payout execution")
 		}
 	}
 
-	return nil
+	return d.Pegnet.InsertBlockSummary(ctx, tx, pegnet.BlockSummary(*d.summary))
 }
 
 // SnapshotPayouts moves the current shapshot to the "past", and updates the current snapshot. Then
 // it proceeds to do the snapshot staking payouts.
-func (d *Pegnetd) SnapshotPayouts(tx *sql.Tx, fLog *log.Entry, rates map[fat2.PTicker]uint64, height uint32, heightTimestamp time.Time) error {
+func (d *Pegnetd) SnapshotPayouts(ctx context.Context, tx *sql.Tx, fLog *log.Entry, rates map[fat2.PTicker]uint64, height uint32, heightTimestamp time.Time) error {
 	// Snapshot
 	snapStart := time.Now()
-	err := d.Pegnet.SnapshotCurrent(tx)
+	err := d.Pegnet.SnapshotCurrent(ctx, tx)
 	if err != nil {
 		return err // Snapshot fails stop all progress and block syncing
 	}
 
 	// Payout snapshot
-	balances, err := d.Pegnet.SelectSnapshotBalances(tx)
+	balances, err := d.Pegnet.SelectSnapshotBalances(ctx, tx)
 	if err != nil {
 		return err // Need to do staking payouts
 	}
@@ -679,7 +731,7 @@ func (d *Pegnetd) SnapshotPayouts(tx *sql.Tx, fLog *log.Entry, rates map[fat2.PT
 
 	// ---- Database Payouts ----
 	// Inserts tx into the db
-	err = d.Pegnet.InsertStakingCoinbase(tx, txid, height, heightTimestamp, set.Payouts(), addressMap)
+	err = d.Pegnet.InsertStakingCoinbase(ctx, tx, txid, height, heightTimestamp, set.Payouts(), addressMap)
 	if err != nil {
 		return err
 	}
@@ -688,10 +740,11 @@ func (d *Pegnetd) SnapshotPayouts(tx *sql.Tx, fLog *log.Entry, rates map[fat2.PT
 	for addTxid, payout := range set.Payouts() {
 		add := addressMap[addTxid] // The address to pay
 
-		_, err = d.Pegnet.AddToBalance(tx, &add, fat2.PTickerPEG, payout)
+		_, err = d.Pegnet.AddToBalance(ctx, tx, &add, fat2.PTickerPEG, payout)
 		if err != nil {
 			return err
 		}
+		d.summary.paid(pegnet.PayoutHolders, payout)
 	}
 
 	// -- End staking calculations
@@ -706,7 +759,7 @@ func (d *Pegnetd) SnapshotPayouts(tx *sql.Tx, fLog *log.Entry, rates map[fat2.PT
 
 // Developers Reward Payouts
 // implementation of PIP16 - distributed rewards collected for developers every 24h
-func (d *Pegnetd) DevelopersPayouts(tx *sql.Tx, fLog *log.Entry, height uint32, heightTimestamp time.Time, developers []DevReward) error {
+func (d *Pegnetd) DevelopersPayouts(ctx context.Context, tx *sql.Tx, fLog *log.Entry, height uint32, heightTimestamp time.Time, developers []DevReward) error {
 
 	totalPayout := uint64(conversions.PerBlockDevelopers) * pegnet.SnapshotRate // every day
 	payoutStart := time.Now()
@@ -732,10 +785,11 @@ func (d *Pegnetd) DevelopersPayouts(tx *sql.Tx, fLog *log.Entry, height uint32,
 		}
 		addr, err := factom.NewFAAddress(dev.DevAddress)
 
-		_, err = d.Pegnet.AddToBalance(tx, &addr, fat2.PTickerPEG, rewardPayout)
+		_, err = d.Pegnet.AddToBalance(ctx, tx, &addr, fat2.PTickerPEG, rewardPayout)
 		if err != nil {
 			return err
 		}
+		d.summary.paid(pegnet.PayoutDevelopers, rewardPayout)
 
 		// Mock entry hash value
 		addTxid := fmt.Sprintf("%d-%s", i, txid)
@@ -763,7 +817,7 @@ func (d *Pegnetd) DevelopersPayouts(tx *sql.Tx, fLog *log.Entry, height uint32,
 
 		// ---- Database Payouts ----
 		// Inserts tx into the db
-		err = d.Pegnet.InsertDeveloperRewardCoinbase(tx, txid, addTxid, height, heightTimestamp, rewardPayout, FADevAddress)
+		err = d.Pegnet.InsertDeveloperRewardCoinbase(ctx, tx, txid, addTxid, height, heightTimestamp, rewardPayout, FADevAddress)
 		if err != nil {
 			log.Info("dev insertion error")
 			return err
@@ -838,7 +892,7 @@ func multiFetch(eblock *factom.EBlock, c *factom.Client) error {
 // The bank is the total amount of PEG allowed to be issued for any given height.
 func (d *Pegnetd) SyncBank(ctx context.Context, sqlTx *sql.Tx, currentHeight uint32) error {
 	if (currentHeight >= config.V4OPRUpdate) && (currentHeight < config.V20HeightActivation) { // V4 forward tracks this
-		err := d.Pegnet.InsertBankAmount(sqlTx, int32(currentHeight), int64(pegnet.BankBaseAmount))
+		err := d.Pegnet.InsertBankAmount(ctx, sqlTx, int32(currentHeight), int64(pegnet.BankBaseAmount))
 		if err != nil {
 			return err
 		}
@@ -863,7 +917,7 @@ func (d *Pegnetd) ApplyTransactionBatchesInHolding(ctx context.Context, sqlTx *s
 	// Usually height is just currentHeight-1, but it can be farther back
 	// if the miners have skipped a block
 	for i := height; i < currentHeight; i++ {
-		txBatches, err := d.Pegnet.SelectTransactionBatchesInHoldingAtHeight(uint64(i))
+		txBatches, err := d.Pegnet.SelectTransactionBatchesInHoldingAtHeight(ctx, uint64(i))
 		if err != nil {
 			return err
 		}
@@ -876,16 +930,18 @@ func (d *Pegnetd) ApplyTransactionBatchesInHolding(ctx context.Context, sqlTx *s
 
 			if currentHeight >= config.V20HeightActivation {
 				if err := txBatch.ValidatePegTx(int32(currentHeight)); err != nil {
-					d.Pegnet.SetTransactionHistoryExecuted(sqlTx, txBatch, -2)
+					d.Pegnet.SetTransactionHistoryExecuted(ctx, sqlTx, txBatch, -2)
+					d.summary.rejected()
 					continue
 				}
 			}
 
 			if err := txBatch.Validate(int32(currentHeight)); err != nil {
-				d.Pegnet.SetTransactionHistoryExecuted(sqlTx, txBatch, -2)
+				d.Pegnet.SetTransactionHistoryExecuted(ctx, sqlTx, txBatch, -2)
+				d.summary.rejected()
 				continue
 			}
-			isReplay, err := d.Pegnet.IsReplayTransaction(sqlTx, txBatch.Entry.Hash)
+			isReplay, err := d.Pegnet.IsReplayTransaction(ctx, sqlTx, txBatch.Entry.Hash)
 			if err != nil {
 				return err
 			} else if isReplay {
@@ -894,7 +950,7 @@ func (d *Pegnetd) ApplyTransactionBatchesInHolding(ctx context.Context, sqlTx *s
 
 			// This will apply all batche inputs, and all batch outputs except
 			// conversions to PEG if we are above the PegnetConversionLimit Act
-			err = d.applyTransactionBatch(sqlTx, txBatch, rates, averages, currentHeight)
+			err = d.applyTransactionBatch(ctx, sqlTx, txBatch, rates, averages, currentHeight)
 			// The err needs to be converted to a code. If the err is still
 			// not nil, then the code is 0 and the error is probably db related.
 			// If the code is < 0, the tx is rejected.
@@ -903,8 +959,10 @@ func (d *Pegnetd) ApplyTransactionBatchesInHolding(ctx context.Context, sqlTx *s
 			if err != nil { // Likely a db error
 				return err
 			} else if rejectCode < 0 { // Tx rejected
-				d.Pegnet.SetTransactionHistoryExecuted(sqlTx, txBatch, rejectCode)
+				d.Pegnet.SetTransactionHistoryExecuted(ctx, sqlTx, txBatch, rejectCode)
+				d.summary.rejected()
 			} else if err == nil { // Tx accepted
+				d.summary.executed()
 				if currentHeight < config.V20HeightActivation {
 					// If PegnetConversion limits are on, we process conversions to
 					// peg in a second pass.
@@ -927,7 +985,7 @@ func (d *Pegnetd) ApplyTransactionBatchesInHolding(ctx context.Context, sqlTx *s
 		if currentHeight >= config.PegnetConversionLimitActivation && currentHeight < config.V4OPRUpdate {
 			// All heights before v4 use the currentHeight-1 with a 5K PEG bank
 			bank := pegnet.BankBaseAmount
-			err = d.recordPegnetRequests(sqlTx, pegConversions, rates, averages, currentHeight, bank, int32(currentHeight-1))
+			err = d.recordPegnetRequests(ctx, sqlTx, pegConversions, rates, averages, currentHeight, bank, int32(currentHeight-1))
 			if err != nil {
 				return err
 			}
@@ -938,11 +996,11 @@ func (d *Pegnetd) ApplyTransactionBatchesInHolding(ctx context.Context, sqlTx *s
 	// Process all pending using the same bank
 	if (currentHeight >= config.V4OPRUpdate) && (currentHeight < config.V20HeightActivation) {
 		// The bank entry should be here from the sync banks called before this function.
-		bentry, err := d.Pegnet.SelectBankEntry(sqlTx, int32(currentHeight))
+		bentry, err := d.Pegnet.SelectBankEntry(ctx, sqlTx, int32(currentHeight))
 		if err != nil {
 			return err
 		}
-		err = d.recordPegnetRequests(sqlTx, pegConversions, rates, averages, currentHeight, uint64(bentry.BankAmount), int32(currentHeight))
+		err = d.recordPegnetRequests(ctx, sqlTx, pegConversions, rates, averages, currentHeight, uint64(bentry.BankAmount), int32(currentHeight))
 		if err != nil {
 			return err
 		}
@@ -954,7 +1012,7 @@ func (d *Pegnetd) ApplyTransactionBatchesInHolding(ctx context.Context, sqlTx *s
 // ApplyTransactionBlock puts conversion-containing transaction batches into holding,
 // and applys the balance updates for all transaction batches able to be executed
 // immediately. If an error is returned, the sql.Tx should be rolled back by the caller.
-func (d *Pegnetd) ApplyTransactionBlock(sqlTx *sql.Tx, eblock *factom.EBlock) error {
+func (d *Pegnetd) ApplyTransactionBlock(ctx context.Context, sqlTx *sql.Tx, eblock *factom.EBlock) error {
 	for blockorder, entry := range eblock.Entries {
 		txBatch, err := fat2.NewTransactionBatch(entry, int32(eblock.Height))
 		if err != nil {
@@ -967,7 +1025,7 @@ func (d *Pegnetd) ApplyTransactionBlock(sqlTx *sql.Tx, eblock *factom.EBlock) er
 			"conversions": txBatch.HasConversions(),
 			"txs":         len(txBatch.Transactions)}).Tracef("tx found")
 
-		isReplay, err := d.Pegnet.IsReplayTransaction(sqlTx, txBatch.Entry.Hash)
+		isReplay, err := d.Pegnet.IsReplayTransaction(ctx, sqlTx, txBatch.Entry.Hash)
 		if err != nil {
 			return err
 		} else if isReplay {
@@ -975,7 +1033,10 @@ func (d *Pegnetd) ApplyTransactionBlock(sqlTx *sql.Tx, eblock *factom.EBlock) er
 		}
 		// At this point, we know that the transaction batch is valid and able to be executed.
 
-		if err = d.Pegnet.InsertTransactionHistoryTxBatch(sqlTx, blockorder, txBatch, eblock.Height); err != nil {
+		if err = d.Pegnet.InsertTransactionHistoryTxBatch(ctx, sqlTx, blockorder, txBatch, eblock.Height); err != nil {
+			return err
+		}
+		if err = d.Pegnet.InsertEthAddresses(ctx, sqlTx, txBatch, eblock.Height); err != nil {
 			return err
 		}
 
@@ -983,19 +1044,23 @@ func (d *Pegnetd) ApplyTransactionBlock(sqlTx *sql.Tx, eblock *factom.EBlock) er
 		// in a future block. This prevents gaming of conversions where an actor
 		// can know the exchange rates of the future ahead of time.
 		if txBatch.HasConversions() {
-			_, err = d.Pegnet.InsertTransactionBatchHolding(sqlTx, txBatch, uint64(eblock.Height), eblock.KeyMR)
+			_, err = d.Pegnet.InsertTransactionBatchHolding(ctx, sqlTx, txBatch, uint64(eblock.Height), eblock.KeyMR)
 			if err != nil {
 				return err
 			}
+			d.summary.held()
 			continue
 		}
 
 		// No conversions in the batch, it can be applied immediately
-		if err = d.applyTransactionBatch(sqlTx, txBatch, nil, nil, eblock.Height); err != nil &&
+		if err = d.applyTransactionBatch(ctx, sqlTx, txBatch, nil, nil, eblock.Height); err != nil &&
 			err != pegnet.InsufficientBalanceErr { // Allowed Exception
 			return err
 		} else if err == pegnet.InsufficientBalanceErr {
-			d.Pegnet.SetTransactionHistoryExecuted(sqlTx, txBatch, -1)
+			d.Pegnet.SetTransactionHistoryExecuted(ctx, sqlTx, txBatch, -1)
+			d.summary.rejected()
+		} else {
+			d.summary.executed()
 		}
 	}
 	return nil
@@ -1004,6 +1069,7 @@ func (d *Pegnetd) ApplyTransactionBlock(sqlTx *sql.Tx, eblock *factom.EBlock) er
 // applyTransactionBatch
 //	currentHeight is just for tracing
 func (d *Pegnetd) applyTransactionBatch(
+	ctx context.Context,
 	sqlTx *sql.Tx,
 	txBatch *fat2.TransactionBatch,
 	rates map[fat2.PTicker]uint64,
@@ -1017,7 +1083,7 @@ func (d *Pegnetd) applyTransactionBatch(
 	// We need to do all checks up front, then apply the tx
 	for _, tx := range txBatch.Transactions {
 		// First check the input address has the funds
-		bals, err := d.Pegnet.SelectPendingBalances(sqlTx, &tx.Input.Address)
+		bals, err := d.Pegnet.SelectPendingBalances(ctx, sqlTx, &tx.Input.Address)
 		if err != nil {
 			return err
 		}
@@ -1104,7 +1170,7 @@ func (d *Pegnetd) applyTransactionBatch(
 	}
 
 	// The tx batch should be 100% valid to apply
-	err := d.recordBatch(sqlTx, txBatch, rates, averages, currentHeight)
+	err := d.recordBatch(ctx, sqlTx, txBatch, rates, averages, currentHeight)
 	if err != nil {
 		return err
 	}
@@ -1120,7 +1186,7 @@ func (d *Pegnetd) applyTransactionBatch(
 
 // recordBatch will submit the batch to the database. We assume the tx is 100%
 // valid at this point.
-func (d *Pegnetd) recordBatch(sqlTx *sql.Tx, txBatch *fat2.TransactionBatch, rates, averages map[fat2.PTicker]uint64, currentHeight uint32) error {
+func (d *Pegnetd) recordBatch(ctx context.Context, sqlTx *sql.Tx, txBatch *fat2.TransactionBatch, rates, averages map[fat2.PTicker]uint64, currentHeight uint32) error {
 	var FAGlobalBurnAddress factom.FAAddress
 	var err error
 	if currentHeight >= config.V202EnhanceActivation {
@@ -1133,18 +1199,18 @@ func (d *Pegnetd) recordBatch(sqlTx *sql.Tx, txBatch *fat2.TransactionBatch, rat
 	}
 
 	for txIndex, tx := range txBatch.Transactions {
-		_, txErr, err := d.Pegnet.SubFromBalance(sqlTx, &tx.Input.Address, tx.Input.Type, tx.Input.Amount)
+		_, txErr, err := d.Pegnet.SubFromBalance(ctx, sqlTx, &tx.Input.Address, tx.Input.Type, tx.Input.Amount)
 		if err != nil {
 			return err
 		} else if txErr != nil {
 			// This should fail the block
 			return fmt.Errorf("uncaught: %s", txErr.Error())
 		}
-		_, err = d.Pegnet.InsertTransactionRelation(sqlTx, tx.Input.Address, txBatch.Entry.Hash, uint64(txIndex), false, tx.IsConversion())
+		_, err = d.Pegnet.InsertTransactionRelation(ctx, sqlTx, tx.Input.Address, txBatch.Entry.Hash, uint64(txIndex), false, tx.IsConversion())
 		if err != nil {
 			return err
 		}
-		if err = d.Pegnet.SetTransactionHistoryExecuted(sqlTx, txBatch, int64(currentHeight)); err != nil {
+		if err = d.Pegnet.SetTransactionHistoryExecuted(ctx, sqlTx, txBatch, int64(currentHeight)); err != nil {
 			return err
 		}
 
@@ -1178,11 +1244,11 @@ func (d *Pegnetd) recordBatch(sqlTx *sql.Tx, txBatch *fat2.TransactionBatch, rat
 				return err
 			}
 
-			if err = d.Pegnet.SetTransactionHistoryConvertedAmount(sqlTx, txBatch, txIndex, outputAmount); err != nil {
+			if err = d.Pegnet.SetTransactionHistoryConvertedAmount(ctx, sqlTx, txBatch, txIndex, outputAmount); err != nil {
 				return err
 			}
 
-			_, err = d.Pegnet.AddToBalance(sqlTx, &tx.Input.Address, tx.Conversion, uint64(outputAmount))
+			_, err = d.Pegnet.AddToBalance(ctx, sqlTx, &tx.Input.Address, tx.Conversion, uint64(outputAmount))
 			if err != nil {
 				return err
 			}
@@ -1193,11 +1259,11 @@ func (d *Pegnetd) recordBatch(sqlTx *sql.Tx, txBatch *fat2.TransactionBatch, rat
 			for _, transfer := range tx.Transfers {
 				// if transfer to Burn address do nothing, otherwise add balances
 				if transfer.Address != FAGlobalBurnAddress {
-					_, err = d.Pegnet.AddToBalance(sqlTx, &transfer.Address, tx.Input.Type, transfer.Amount)
+					_, err = d.Pegnet.AddToBalance(ctx, sqlTx, &transfer.Address, tx.Input.Type, transfer.Amount)
 					if err != nil {
 						return err
 					}
-					_, err = d.Pegnet.InsertTransactionRelation(sqlTx, transfer.Address, txBatch.Entry.Hash, uint64(txIndex), true, false)
+					_, err = d.Pegnet.InsertTransactionRelation(ctx, sqlTx, transfer.Address, txBatch.Entry.Hash, uint64(txIndex), true, false)
 					if err != nil {
 						return err
 					}
@@ -1215,7 +1281,7 @@ type pegRequest struct {
 	TxIndex            int
 }
 
-func (d *Pegnetd) recordPegnetRequests(sqlTx *sql.Tx, txBatchs []*fat2.TransactionBatch, rates, averages map[fat2.PTicker]uint64, currentHeight uint32, bank uint64, bankHeight int32) error {
+func (d *Pegnetd) recordPegnetRequests(ctx context.Context, sqlTx *sql.Tx, txBatchs []*fat2.TransactionBatch, rates, averages map[fat2.PTicker]uint64, currentHeight uint32, bank uint64, bankHeight int32) error {
 	limit := conversions.NewConversionSupply(bank)
 	txData := make(map[string]pegRequest)
 
@@ -1271,24 +1337,26 @@ func (d *Pegnetd) recordPegnetRequests(sqlTx *sql.Tx, txBatchs []*fat2.Transacti
 			"inputtype":       tx.Input.Type.String(),
 		}).Tracef("refund set")
 
-		if err := d.Pegnet.SetTransactionHistoryPEGConvertedRequestAmount(sqlTx, txData[txid].Batch, txData[txid].TxIndex, int64(pegYield), refundAmt); err != nil {
+		if err := d.Pegnet.SetTransactionHistoryPEGConvertedRequestAmount(ctx, sqlTx, txData[txid].Batch, txData[txid].TxIndex, int64(pegYield), refundAmt); err != nil {
 			return err
 		}
 
 		// PEG addition
-		if _, err := d.Pegnet.AddToBalance(sqlTx, &tx.Input.Address, tx.Conversion, pegYield); err != nil {
+		if _, err := d.Pegnet.AddToBalance(ctx, sqlTx, &tx.Input.Address, tx.Conversion, pegYield); err != nil {
 			return err
 		}
 
 		// Refund
-		if _, err := d.Pegnet.AddToBalance(sqlTx, &tx.Input.Address, tx.Input.Type, uint64(refundAmt)); err != nil {
+		if _, err := d.Pegnet.AddToBalance(ctx, sqlTx, &tx.Input.Address, tx.Input.Type, uint64(refundAmt)); err != nil {
 			return err
 		}
 	}
 
+	d.summary.pegRequests(bank, limit.TotalRequested(), uint64(totalPaid))
+
 	// The bankheight == currentheight after V4Update fork
 	if bankHeight >= int32(config.V4OPRUpdate) {
-		err := d.Pegnet.UpdateBankEntry(sqlTx, bankHeight, totalPaid, int64(limit.TotalRequested()))
+		err := d.Pegnet.UpdateBankEntry(ctx, sqlTx, bankHeight, totalPaid, int64(limit.TotalRequested()))
 		if err != nil {
 			return err
 		}
@@ -1353,11 +1421,11 @@ func (d *Pegnetd) ApplyFactoidBlock(ctx context.Context, tx *sql.Tx, dblock *fac
 	for i := range burns {
 		var add factom.FAAddress
 		copy(add[:], burns[i].FCTInputs[0].Address[:])
-		if _, err := d.Pegnet.AddToBalance(tx, &add, fat2.PTickerFCT, burns[i].FCTInputs[0].Amount); err != nil {
+		if _, err := d.Pegnet.AddToBalance(ctx, tx, &add, fat2.PTickerFCT, burns[i].FCTInputs[0].Amount); err != nil {
 			return err
 		}
 
-		if err := d.Pegnet.InsertFCTBurn(tx, fblock.KeyMR, burns[i], dblock.Height); err != nil {
+		if err := d.Pegnet.InsertFCTBurn(ctx, tx, fblock.KeyMR, burns[i], dblock.Height); err != nil {
 			return err
 		}
 	}
@@ -1367,7 +1435,7 @@ func (d *Pegnetd) ApplyFactoidBlock(ctx context.Context, tx *sql.Tx, dblock *fac
 
 // ApplyGradedOPRBlock pays out PEG to the winners of the given GradedBlock.
 // If an error is returned, the sql.Tx should be rolled back by the caller.
-func (d *Pegnetd) ApplyGradedOPRBlock(tx *sql.Tx, gradedBlock grader.GradedBlock, timestamp time.Time) error {
+func (d *Pegnetd) ApplyGradedOPRBlock(ctx context.Context, tx *sql.Tx, gradedBlock grader.GradedBlock, timestamp time.Time) error {
 	winners := gradedBlock.Winners()
 	for i := range winners {
 		addr, err := factom.NewFAAddress(winners[i].OPR.GetAddress())
@@ -1382,20 +1450,21 @@ func (d *Pegnetd) ApplyGradedOPRBlock(tx *sql.Tx, gradedBlock grader.GradedBlock
 			continue
 		}
 
-		if _, err := d.Pegnet.AddToBalance(tx, &addr, fat2.PTickerPEG, uint64(winners[i].Payout())); err != nil {
+		if _, err := d.Pegnet.AddToBalance(ctx, tx, &addr, fat2.PTickerPEG, uint64(winners[i].Payout())); err != nil {
 			return err
 		}
 
-		if err := d.Pegnet.InsertCoinbase(tx, winners[i], addr[:], timestamp); err != nil {
+		if err := d.Pegnet.InsertCoinbase(ctx, tx, winners[i], addr[:], timestamp); err != nil {
 			return err
 		}
+		d.summary.paid(pegnet.PayoutMiners, uint64(winners[i].Payout()))
 	}
 	return nil
 }
 
 // ApplyGradedOPRBlock pays out PEG to the winners of the given GradedBlock.
 // If an error is returned, the sql.Tx should be rolled back by the caller.
-func (d *Pegnetd) ApplyGradedSPRBlock(tx *sql.Tx, gradedSPRBlock graderStake.GradedBlock, timestamp time.Time) error {
+func (d *Pegnetd) ApplyGradedSPRBlock(ctx context.Context, tx *sql.Tx, gradedSPRBlock graderStake.GradedBlock, timestamp time.Time) error {
 	winners := gradedSPRBlock.Winners()
 	for i := range winners {
 		addr, err := factom.NewFAAddress(winners[i].SPR.GetAddress())
@@ -1410,13 +1479,14 @@ func (d *Pegnetd) ApplyGradedSPRBlock(tx *sql.Tx, gradedSPRBlock graderStake.Gra
 			continue
 		}
 
-		if _, err := d.Pegnet.AddToBalance(tx, &addr, fat2.PTickerPEG, uint64(winners[i].Payout())); err != nil {
+		if _, err := d.Pegnet.AddToBalance(ctx, tx, &addr, fat2.PTickerPEG, uint64(winners[i].Payout())); err != nil {
 			return err
 		}
 
-		if err := d.Pegnet.InsertStaking100Coinbase(tx, winners[i], addr[:], timestamp); err != nil {
+		if err := d.Pegnet.InsertStaking100Coinbase(ctx, tx, winners[i], addr[:], timestamp); err != nil {
 			return err
 		}
+		d.summary.paid(pegnet.PayoutStakers, uint64(winners[i].Payout()))
 	}
 	return nil
 }
@@ -1,6 +1,13 @@
 package srv
 
-import "github.com/Factom-Asset-Tokens/factom"
+import (
+	"context"
+	"fmt"
+
+	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/node/pegnet"
+)
 
 // underlyingFA will return the FA address given an input of type
 // FA, Fe, or FE
@@ -22,3 +29,52 @@ func underlyingFA(addr string) (factom.FAAddress, error) {
 	add, err := factom.NewFAAddress(addr)
 	return add, err
 }
+
+// validAddress checks the format of an FA, Fe, FE, or 0x ethereum address.
+// Ethereum addresses can only be resolved against the database, see
+// resolveAddress.
+func validAddress(addr string) error {
+	if pegnet.IsEthAddress(addr) {
+		return nil
+	}
+	_, err := underlyingFA(addr)
+	return err
+}
+
+// resolveAddress returns the FA address of an FA, Fe, FE, or 0x ethereum
+// address. The address is expected to pass validAddress.
+func (s *APIServer) resolveAddress(ctx context.Context, addr string) (factom.FAAddress, error) {
+	if !pegnet.IsEthAddress(addr) {
+		return underlyingFA(addr)
+	}
+	fa, err := s.Node.Pegnet.SelectEthAddressFA(ctx, addr)
+	if err == pegnet.ErrUnknownEthAddress {
+		return fa, jrpc.NewError(ErrorAddressNotFound.Code, ErrorAddressNotFound.Message, err.Error())
+	}
+	return fa, err
+}
+
+// AddressForms are the equivalent forms of an address. The Fe, FE and 0x
+// forms are only given for addresses that are known to be an RCD-e, which is
+// once they signed a transaction.
+type AddressForms struct {
+	FA         string `json:"fa"`
+	Fe         string `json:"fe,omitempty"`
+	FE         string `json:"fegateway,omitempty"`
+	EthAddress string `json:"ethaddress,omitempty"`
+}
+
+// addressForms returns the equivalent forms of an FA address
+func (s *APIServer) addressForms(ctx context.Context, fa factom.FAAddress) (*AddressForms, error) {
+	forms := &AddressForms{FA: fa.String()}
+	eth, err := s.Node.Pegnet.SelectEthAddress(ctx, &fa)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the ethereum address: %v", err)
+	}
+	if eth != "" {
+		forms.Fe = factom.FeAddress(fa).String()
+		forms.FE = factom.FEGatewayAddress(fa).String()
+		forms.EthAddress = eth
+	}
+	return forms, nil
+}
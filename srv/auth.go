@@ -0,0 +1,289 @@
+package srv
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/spf13/viper"
+)
+
+// apiAuth authenticates the callers of the api, and authorizes the methods
+// they call. Callers authenticate with basic auth, a bearer token, or a client
+// certificate signed by the configured CA. Restricted methods need an
+// authenticated caller, the others are public. If no credentials are
+// configured, restricted methods are only served to the local host.
+type apiAuth struct {
+	user, pass  string
+	tokens      []string
+	clientCerts bool
+
+	// All methods are restricted if it has "*"
+	restricted map[string]bool
+}
+
+func newAPIAuth(conf *viper.Viper) *apiAuth {
+	a := &apiAuth{
+		user:        conf.GetString(config.APIUser),
+		pass:        conf.GetString(config.APIPass),
+		clientCerts: conf.GetString(config.APITLSClientCA) != "",
+		restricted:  make(map[string]bool),
+	}
+	for _, token := range conf.GetStringSlice(config.APITokens) {
+		if token != "" {
+			a.tokens = append(a.tokens, token)
+		}
+	}
+	for _, method := range conf.GetStringSlice(config.APIRestrictedMethods) {
+		a.restricted[method] = true
+	}
+	return a
+}
+
+// configured returns true if callers are able to authenticate
+func (a *apiAuth) configured() bool {
+	return a.user != "" || len(a.tokens) > 0 || a.clientCerts
+}
+
+func (a *apiAuth) isRestricted(method string) bool {
+	return a.restricted["*"] || a.restricted[method]
+}
+
+// authenticate returns the authenticated caller, or "" for a request that is
+// not authenticated. An error is returned if the request presented credentials
+// that are not valid.
+func (a *apiAuth) authenticate(r *http.Request) (string, error) {
+	if !a.configured() {
+		if isLoopback(r.RemoteAddr) {
+			return "localhost", nil
+		}
+		return "", nil
+	}
+	if user, pass, ok := r.BasicAuth(); ok {
+		if a.user == "" ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(a.user)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(a.pass)) != 1 {
+			return "", fmt.Errorf("invalid username or password")
+		}
+		return "user:" + user, nil
+	}
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token := strings.TrimPrefix(header, "Bearer ")
+		for _, t := range a.tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+				// The token itself is a secret, so callers are told apart by its hash
+				hash := sha256.Sum256([]byte(token))
+				return "token:" + hex.EncodeToString(hash[:4]), nil
+			}
+		}
+		return "", fmt.Errorf("invalid bearer token")
+	}
+	if a.clientCerts && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return "cert:" + r.TLS.VerifiedChains[0][0].Subject.CommonName, nil
+	}
+	return "", nil
+}
+
+type callerKey struct{}
+
+// caller returns the authenticated caller of the request, "" if there is none
+func caller(r *http.Request) string {
+	c, _ := r.Context().Value(callerKey{}).(string)
+	return c
+}
+
+// Handler rejects requests with invalid credentials, and unauthenticated
+// requests for restricted methods. The authenticated caller is added to the
+// context of the request.
+func (a *apiAuth) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := a.authenticate(r)
+		if err != nil {
+			a.unauthorized(w, err.Error())
+			return
+		}
+		if c != "" {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, c)))
+			return
+		}
+
+		methods, unreadable, err := methodsOf(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if unreadable > 0 && len(a.restricted) > 0 {
+			// The handler rejects them, but they are not trusted to be
+			a.unauthorized(w, "the request can not be read, and may call a restricted method")
+			return
+		}
+		for _, method := range methods {
+			if a.isRestricted(method) {
+				if a.configured() {
+					a.unauthorized(w, fmt.Sprintf("%s requires authentication", method))
+				} else {
+					a.unauthorized(w, fmt.Sprintf("%s is only served to the local host, unless api credentials are configured", method))
+				}
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *apiAuth) unauthorized(w http.ResponseWriter, reason string) {
+	if a.user != "" {
+		w.Header().Add("WWW-Authenticate", `Basic realm="pegnetd"`)
+	}
+	if len(a.tokens) > 0 {
+		w.Header().Add("WWW-Authenticate", `Bearer realm="pegnetd"`)
+	}
+	writeError(w, http.StatusUnauthorized, ErrorUnauthorized, reason)
+}
+
+// writeError answers a request that is rejected before it reaches the JSON-RPC
+// handler, with the error in the JSON-RPC form
+func writeError(w http.ResponseWriter, status int, err jrpc.Error, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      nil,
+		"error": map[string]interface{}{
+			"code":    err.Code,
+			"message": err.Message,
+			"data":    data,
+		},
+	})
+}
+
+// readBody reads the body of the request, and replaces it so it can be read
+// again
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+type methodsKey struct{}
+
+// methodsOf returns the methods of the request, either the one of a REST route
+// or the ones in the JSON-RPC body, and the number of requests in the body
+// whose method can not be read
+func methodsOf(r *http.Request) ([]string, int, error) {
+	if methods, ok := r.Context().Value(methodsKey{}).([]string); ok {
+		return methods, 0, nil
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	methods, unreadable := requestMethods(body)
+	return methods, unreadable, nil
+}
+
+// requestMethods returns the methods of a JSON-RPC request or batch, and the
+// number of requests whose method can not be read. Like the JSON-RPC handler,
+// every request of a batch is read on its own, so a batch with an invalid
+// request still has the methods of the others.
+func requestMethods(body []byte) ([]string, int) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		batch = []json.RawMessage{body}
+	}
+	methods := make([]string, 0, len(batch))
+	var unreadable int
+	for _, raw := range batch {
+		var req struct {
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(raw, &req); err != nil {
+			unreadable++
+			continue
+		}
+		methods = append(methods, req.Method)
+	}
+	return methods, unreadable
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// serverTLSConfig returns the tls config of the api, with the client CA if one
+// is configured
+func serverTLSConfig(conf *viper.Viper) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	caFile := conf.GetString(config.APITLSClientCA)
+	if caFile == "" {
+		return tlsConfig, nil
+	}
+	pool, err := certPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if conf.GetBool(config.APITLSRequireClientCert) {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// ClientTLSConfig returns the tls config for a Client. caFile is the CA of the
+// server certificate, if it is not signed by a system CA. certFile and keyFile
+// are the client certificate, if the server authenticates them. Each may be
+// empty.
+func ClientTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := certPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func certPool(file string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
@@ -0,0 +1,132 @@
+package srv
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pegnet/pegnetd/config"
+	"github.com/spf13/viper"
+)
+
+func TestRequestMethods(t *testing.T) {
+	tests := []struct {
+		body       string
+		methods    []string
+		unreadable int
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"get-sync-status"}`, []string{"get-sync-status"}, 0},
+		{`[{"method":"get-sync-status"},{"method":"send-transaction"}]`, []string{"get-sync-status", "send-transaction"}, 0},
+		{`[]`, nil, 0},
+		{`{"id":1}`, []string{""}, 0},
+		{`not json`, nil, 1},
+		{`[{"method":"send-transaction"},1]`, []string{"send-transaction"}, 1},
+		{`[1,{"method":5},{"method":"get-sync-status"}]`, []string{"get-sync-status"}, 2},
+	}
+	for _, tt := range tests {
+		methods, unreadable := requestMethods([]byte(tt.body))
+		if strings.Join(methods, ",") != strings.Join(tt.methods, ",") || unreadable != tt.unreadable {
+			t.Errorf("%s: expected %q and %d unreadable, got %q and %d", tt.body, tt.methods, tt.unreadable, methods, unreadable)
+		}
+	}
+}
+
+func TestAPIAuth(t *testing.T) {
+	const (
+		public     = `{"jsonrpc":"2.0","id":1,"method":"get-sync-status"}`
+		restricted = `{"jsonrpc":"2.0","id":1,"method":"send-transaction"}`
+		mixed      = `[{"jsonrpc":"2.0","id":1,"method":"get-sync-status"},{"jsonrpc":"2.0","id":2,"method":"send-transaction"}]`
+		invalid    = `[{"jsonrpc":"2.0","id":1,"method":"send-transaction"},1]`
+		unreadable = `[{"jsonrpc":"2.0","id":1,"method":"get-sync-status"},{"method":1}]`
+	)
+	configured := viper.New()
+	configured.Set(config.APIUser, "user")
+	configured.Set(config.APIPass, "pass")
+	configured.Set(config.APITokens, []string{"secret"})
+	configured.Set(config.APIRestrictedMethods, []string{"send-transaction"})
+	unconfigured := viper.New()
+	unconfigured.Set(config.APIRestrictedMethods, []string{"send-transaction"})
+
+	tests := []struct {
+		name       string
+		conf       *viper.Viper
+		remoteAddr string
+		header     string
+		body       string
+		status     int
+		caller     string
+	}{
+		{"a public method", configured, "10.0.0.1:1234", "", public, http.StatusOK, ""},
+		{"a restricted method", configured, "10.0.0.1:1234", "", restricted, http.StatusUnauthorized, ""},
+		{"a batch with a restricted method", configured, "10.0.0.1:1234", "", mixed, http.StatusUnauthorized, ""},
+		{"a batch with an invalid request", configured, "10.0.0.1:1234", "", invalid, http.StatusUnauthorized, ""},
+		{"a batch with an unreadable method", configured, "10.0.0.1:1234", "", unreadable, http.StatusUnauthorized, ""},
+		{"an unreadable method with a bearer token", configured, "10.0.0.1:1234", "Bearer secret", unreadable, http.StatusOK, "token:2bb80d53"},
+		{"a batch with a bearer token", configured, "10.0.0.1:1234", "Bearer secret", mixed, http.StatusOK, "token:2bb80d53"},
+		{"an invalid bearer token", configured, "10.0.0.1:1234", "Bearer wrong", public, http.StatusUnauthorized, ""},
+		{"an empty bearer token", configured, "10.0.0.1:1234", "Bearer ", public, http.StatusUnauthorized, ""},
+		{"basic auth", configured, "10.0.0.1:1234", "Basic dXNlcjpwYXNz", restricted, http.StatusOK, "user:user"},
+		{"invalid basic auth", configured, "10.0.0.1:1234", "Basic dXNlcjp3cm9uZw==", public, http.StatusUnauthorized, ""},
+		{"the local host with credentials configured", configured, "127.0.0.1:1234", "", restricted, http.StatusUnauthorized, ""},
+		{"the local host", unconfigured, "127.0.0.1:1234", "", mixed, http.StatusOK, "localhost"},
+		{"the local host over ipv6", unconfigured, "[::1]:1234", "", restricted, http.StatusOK, "localhost"},
+		{"a remote host", unconfigured, "10.0.0.1:1234", "", restricted, http.StatusUnauthorized, ""},
+		{"a remote host with an invalid request", unconfigured, "10.0.0.1:1234", "", invalid, http.StatusUnauthorized, ""},
+		{"a remote host calling a public method", unconfigured, "10.0.0.1:1234", "", public, http.StatusOK, ""},
+		{"credentials that are not configured", unconfigured, "10.0.0.1:1234", "Bearer secret", restricted, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		var served bool
+		var c string
+		h := newAPIAuth(tt.conf).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served, c = true, caller(r)
+		}))
+		r := httptest.NewRequest(http.MethodPost, "/v1", strings.NewReader(tt.body))
+		r.RemoteAddr = tt.remoteAddr
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.status || served != (tt.status == http.StatusOK) || c != tt.caller {
+			t.Errorf("%s: expected %d from %q, got %d from %q (served %v)", tt.name, tt.status, tt.caller, w.Code, c, served)
+		}
+	}
+}
+
+func TestAPIAuth_REST(t *testing.T) {
+	conf := viper.New()
+	conf.Set(config.APITokens, []string{"secret"})
+	conf.Set(config.APIRestrictedMethods, []string{"get-submissions"})
+	s, cleanup := newTestAPIServer(t, conf, 10)
+	defer cleanup()
+	auth := newAPIAuth(conf)
+	h := s.restHandler(auth.Handler)
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"a restricted route", RESTPrefix + "submissions", "", http.StatusUnauthorized},
+		{"a restricted route with a query", RESTPrefix + "submissions?status=queued", "", http.StatusUnauthorized},
+		{"a restricted route with a token", RESTPrefix + "submissions", "secret", http.StatusOK},
+		{"a restricted route with an invalid token", RESTPrefix + "submissions", "wrong", http.StatusUnauthorized},
+		{"a public route", RESTPrefix + "blocks/5", "", http.StatusNotFound}, // There is no summary
+		{"an unknown route", RESTPrefix + "nothing", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
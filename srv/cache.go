@@ -0,0 +1,197 @@
+package srv
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"strconv"
+	"sync"
+
+	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/spf13/viper"
+)
+
+var cacheMetrics = expvar.NewMap("cache")
+
+func init() {
+	cacheMetrics.Set("hitrate", expvar.Func(func() interface{} {
+		hits, misses := expvarInt(cacheMetrics, "hits"), expvarInt(cacheMetrics, "misses")
+		if hits+misses == 0 {
+			return 0.0
+		}
+		return float64(hits) / float64(hits+misses)
+	}))
+}
+
+func expvarInt(m *expvar.Map, key string) int64 {
+	if v, ok := m.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// responseCache holds the marshaled results of the synced height, least
+// recently used first out when it is over one of its bounds. All entries are
+// dropped once a new block is synced.
+type responseCache struct {
+	mu         sync.Mutex
+	height     uint32
+	entries    map[string]*list.Element
+	lru        *list.List
+	bytes      int64
+	maxEntries int
+	maxBytes   int64
+}
+
+type cacheEntry struct {
+	key    string
+	result json.RawMessage
+}
+
+func newResponseCache(conf *viper.Viper) *responseCache {
+	return &responseCache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: conf.GetInt(config.APICacheMaxEntries),
+		maxBytes:   conf.GetInt64(config.APICacheMaxBytes),
+	}
+}
+
+func (c *responseCache) enabled() bool {
+	return c.maxEntries > 0 && c.maxBytes > 0
+}
+
+func (c *responseCache) get(height uint32, key string) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if height != c.height {
+		return nil, false
+	}
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cacheEntry).result, true
+}
+
+func (c *responseCache) put(height uint32, key string, result json.RawMessage) {
+	size := int64(len(key) + len(result))
+	if size > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if height < c.height {
+		return // Computed before the block that was just synced
+	}
+	if height > c.height {
+		c.reset(height)
+	}
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, result: result})
+	c.bytes += size
+	for len(c.entries) > c.maxEntries || c.bytes > c.maxBytes {
+		c.remove(c.lru.Back())
+		cacheMetrics.Add("evictions", 1)
+	}
+	c.publish()
+}
+
+// invalidate drops the entries of the heights before the synced height
+func (c *responseCache) invalidate(synced uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if synced > c.height {
+		c.reset(synced)
+		c.publish()
+	}
+}
+
+// reset must be called with the lock held
+func (c *responseCache) reset(height uint32) {
+	c.height = height
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+// remove must be called with the lock held
+func (c *responseCache) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= int64(len(entry.key) + len(entry.result))
+}
+
+// publish must be called with the lock held
+func (c *responseCache) publish() {
+	entries, bytes := new(expvar.Int), new(expvar.Int)
+	entries.Set(int64(len(c.entries)))
+	bytes.Set(c.bytes)
+	cacheMetrics.Set("entries", entries)
+	cacheMetrics.Set("bytes", bytes)
+}
+
+// cacheKey is the method and its params, with the keys of the params sorted so
+// equivalent requests share an entry
+func cacheKey(method string, params json.RawMessage) string {
+	var v interface{}
+	if len(params) > 0 && json.Unmarshal(params, &v) == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			params = canonical
+		}
+	}
+	if string(params) == "null" || string(params) == "{}" {
+		params = nil
+	}
+	return method + " " + string(params)
+}
+
+// cached returns the method with its results cached for the synced height.
+// Errors are not cached.
+func (s *APIServer) cached(method string, f jrpc.MethodFunc) jrpc.MethodFunc {
+	return func(ctx context.Context, params json.RawMessage) interface{} {
+		if s.cache == nil || !s.cache.enabled() {
+			return f(ctx, params)
+		}
+		height := s.Node.GetCurrentSync()
+		key := cacheKey(method, params)
+		if result, ok := s.cache.get(height, key); ok {
+			cacheMetrics.Add("hits", 1)
+			cacheMetrics.Add("hits."+method, 1)
+			return result
+		}
+		cacheMetrics.Add("misses", 1)
+		cacheMetrics.Add("misses."+method, 1)
+
+		result := f(ctx, params)
+		if _, ok := result.(error); ok {
+			return result
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return result
+		}
+		// A block synced while computing the result may or may not be in it
+		if s.Node.GetCurrentSync() == height {
+			s.cache.put(height, key, data)
+		}
+		return result
+	}
+}
+
+// heightHandler adds the synced height to the responses, which is the height
+// the results are valid for
+func (s *APIServer) heightHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Node != nil && s.Node.Sync != nil {
+			w.Header().Set("X-Pegnet-Height", strconv.FormatUint(uint64(s.Node.GetCurrentSync()), 10))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
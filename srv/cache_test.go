@@ -0,0 +1,76 @@
+package srv
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pegnet/pegnetd/config"
+	"github.com/spf13/viper"
+)
+
+func TestResponseCache(t *testing.T) {
+	conf := viper.New()
+	conf.Set(config.APICacheMaxEntries, 2)
+	conf.Set(config.APICacheMaxBytes, 1000)
+	c := newResponseCache(conf)
+
+	has := func(height uint32, key string) bool {
+		_, ok := c.get(height, key)
+		return ok
+	}
+
+	c.put(10, "a", json.RawMessage(`1`))
+	c.put(10, "b", json.RawMessage(`2`))
+	if !has(10, "a") || !has(10, "b") {
+		t.Fatal("expected the results of the height")
+	}
+	if has(11, "a") {
+		t.Error("expected no results of another height")
+	}
+
+	// a was used last, so b is evicted
+	_ = has(10, "a")
+	c.put(10, "c", json.RawMessage(`3`))
+	if !has(10, "a") || has(10, "b") || !has(10, "c") {
+		t.Error("expected the least recently used result to be evicted")
+	}
+
+	// A synced block drops every result
+	c.invalidate(11)
+	if has(10, "a") || has(11, "a") {
+		t.Error("expected the results to be invalidated")
+	}
+	c.put(10, "a", json.RawMessage(`1`))
+	if has(11, "a") || has(10, "a") {
+		t.Error("expected results computed before the synced block to be dropped")
+	}
+	c.invalidate(10)
+	c.put(11, "a", json.RawMessage(`1`))
+	if !has(11, "a") {
+		t.Error("expected an older height to not invalidate the cache")
+	}
+
+	// Results larger than the cache are not cached
+	c.put(11, "big", make(json.RawMessage, 1000))
+	if has(11, "big") || !has(11, "a") {
+		t.Error("expected a result larger than the cache to be left out")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	tests := []struct {
+		params string
+		key    string
+	}{
+		{``, "get-rich-list "},
+		{`null`, "get-rich-list "},
+		{`{}`, "get-rich-list "},
+		{`{"count":10,"asset":"PEG"}`, `get-rich-list {"asset":"PEG","count":10}`},
+		{`{ "asset": "PEG", "count": 10 }`, `get-rich-list {"asset":"PEG","count":10}`},
+	}
+	for _, tt := range tests {
+		if key := cacheKey("get-rich-list", json.RawMessage(tt.params)); key != tt.key {
+			t.Errorf("%s: expected %q, got %q", tt.params, tt.key, key)
+		}
+	}
+}
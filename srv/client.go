@@ -24,10 +24,15 @@ package srv
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/node/pegnet"
 )
 
 // Client makes RPC requests to pegnetd's APIs. Client embeds a jsonrpc2.Client,
@@ -36,8 +41,28 @@ import (
 type Client struct {
 	PegnetdServer string
 	jrpc.Client
+
+	// CallTimeout limits each attempt of a typed call, on top of the deadline
+	// of its context. Zero means no limit besides the http.Client's Timeout.
+	CallTimeout time.Duration
+	// Retry is the retry policy of the idempotent typed calls
+	Retry RetryPolicy
+}
+
+// RetryPolicy controls how idempotent calls are retried after a transport
+// error, or an http error status. RPC errors are never retried.
+type RetryPolicy struct {
+	// Attempts is the total number of attempts. 0 or 1 do not retry.
+	Attempts int
+	// Backoff is the wait before the first retry, doubled after every retry
+	Backoff time.Duration
+	// MaxBackoff caps the wait between retries, if set
+	MaxBackoff time.Duration
 }
 
+// DefaultRetryPolicy is the retry policy of NewClient
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, Backoff: 500 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
 // Defaults for the factomd and factom-walletd endpoints.
 const (
 	PegnetdDefault = "http://localhost:8070"
@@ -47,7 +72,7 @@ const (
 // localhost endpoints for factomd and factom-walletd, and 15 second timeouts
 // for each of the http.Clients.
 func NewClient() *Client {
-	c := &Client{PegnetdServer: PegnetdDefault}
+	c := &Client{PegnetdServer: PegnetdDefault, Retry: DefaultRetryPolicy}
 	c.Timeout = 15 * time.Second
 	return c
 }
@@ -60,3 +85,274 @@ func (c *Client) Request(method string, params, result interface{}) error {
 	}
 	return c.Client.Request(context.Background(), url, method, params, result)
 }
+
+// RPCError is an error returned by pegnetd. errors.Is matches it against the
+// errors in errors.go by code, regardless of the data of the error, so callers
+// can test errors.Is(err, srv.ErrorAddressNotFound).
+type RPCError struct {
+	Err jrpc.Error
+}
+
+func (e RPCError) Error() string {
+	if e.Err.Data == nil {
+		return e.Err.Message
+	}
+	return fmt.Sprintf("%s: %v", e.Err.Message, e.Err.Data)
+}
+
+// Unwrap returns the jrpc.Error
+func (e RPCError) Unwrap() error { return e.Err }
+
+// Is matches errors with the same code
+func (e RPCError) Is(target error) bool {
+	switch t := target.(type) {
+	case jrpc.Error:
+		return e.Err.Code == t.Code
+	case RPCError:
+		return e.Err.Code == t.Err.Code
+	}
+	return false
+}
+
+// call makes a request to pegnetd's v1 API, retrying idempotent requests
+// according to the retry policy. RPC errors are returned as RPCError.
+func (c *Client) call(ctx context.Context, idempotent bool, method string, params, result interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	attempts := 1
+	if idempotent && c.Retry.Attempts > 1 {
+		attempts = c.Retry.Attempts
+	}
+
+	url := c.PegnetdServer + "/v1"
+	backoff := c.Retry.Backoff
+	for attempt := 1; ; attempt++ {
+		if c.DebugRequest {
+			fmt.Println("pegnetdd:", url)
+		}
+		err := c.attempt(ctx, url, method, params, result)
+		if err == nil {
+			return nil
+		}
+		if jerr, ok := err.(jrpc.Error); ok {
+			return RPCError{Err: jerr}
+		}
+		// The jrpc client drops the error in the body of these
+		if strings.HasPrefix(err.Error(), "http: 401") {
+			return RPCError{Err: ErrorUnauthorized}
+		}
+		if strings.HasPrefix(err.Error(), "http: 429") {
+			return RPCError{Err: ErrorRateLimited}
+		}
+		if attempt >= attempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if c.Retry.MaxBackoff > 0 && backoff > c.Retry.MaxBackoff {
+			backoff = c.Retry.MaxBackoff
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, url, method string, params, result interface{}) error {
+	if c.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.CallTimeout)
+		defer cancel()
+	}
+	return c.Client.Request(ctx, url, method, params, result)
+}
+
+// retryable returns true for transport errors and http errors statuses, which
+// the jrpc client returns as "http: <status>"
+func retryable(err error) bool {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return !errors.Is(uerr.Err, context.Canceled)
+	}
+	return strings.HasPrefix(err.Error(), "http: 5")
+}
+
+// Properties returns the versions of pegnetd
+func (c *Client) Properties(ctx context.Context) (PegnetdProperties, error) {
+	var res PegnetdProperties
+	err := c.call(ctx, true, "properties", nil, &res)
+	return res, err
+}
+
+// GetSyncStatus returns the synced height of pegnetd and the height of factomd
+func (c *Client) GetSyncStatus(ctx context.Context) (ResultGetSyncStatus, error) {
+	var res ResultGetSyncStatus
+	err := c.call(ctx, true, "get-sync-status", nil, &res)
+	return res, err
+}
+
+// GetBalances returns the balances of an FA, Fe, FE or 0x address
+func (c *Client) GetBalances(ctx context.Context, address string) (ResultPegnetTickerMap, error) {
+	var res ResultPegnetTickerMap
+	err := c.call(ctx, true, "get-pegnet-balances", ParamsGetPegnetBalances{Address: address}, &res)
+	return res, err
+}
+
+// GetBalancesWithForms returns the balances of an address, and its equivalent
+// address forms
+func (c *Client) GetBalancesWithForms(ctx context.Context, address string) (ResultGetPegnetBalances, error) {
+	var res ResultGetPegnetBalances
+	err := c.call(ctx, true, "get-pegnet-balances", ParamsGetPegnetBalances{Address: address, AddressForms: true}, &res)
+	return res, err
+}
+
+// GetBalancesWithPending returns the balances of an address, and the amounts
+// of its pending transactions
+func (c *Client) GetBalancesWithPending(ctx context.Context, address string) (ResultGetPegnetBalances, error) {
+	var res ResultGetPegnetBalances
+	err := c.call(ctx, true, "get-pegnet-balances", ParamsGetPegnetBalances{Address: address, IncludePending: true}, &res)
+	return res, err
+}
+
+// GetIssuance returns the issuance of all assets
+func (c *Client) GetIssuance(ctx context.Context) (ResultGetIssuance, error) {
+	var res ResultGetIssuance
+	err := c.call(ctx, true, "get-pegnet-issuance", nil, &res)
+	return res, err
+}
+
+// GetRates returns the rates at a height, 0 for the synced height
+func (c *Client) GetRates(ctx context.Context, height uint32) (ResultPegnetTickerMap, error) {
+	var res ResultPegnetTickerMap
+	err := c.call(ctx, true, "get-pegnet-rates", ParamsGetPegnetRates{Height: height}, &res)
+	return res, err
+}
+
+// GetRatesRange returns the rates of a range of heights, or their buckets.
+// ErrorNotFound is returned if the range has no rates.
+func (c *Client) GetRatesRange(ctx context.Context, params ParamsGetPegnetRatesRange) (ResultGetPegnetRatesRange, error) {
+	var res ResultGetPegnetRatesRange
+	err := c.call(ctx, true, "get-pegnet-rates-range", params, &res)
+	return res, err
+}
+
+// GetRichList returns the richest addresses of an asset
+func (c *Client) GetRichList(ctx context.Context, params ParamsGetRichList) ([]ResultGetRichList, error) {
+	var res []ResultGetRichList
+	err := c.call(ctx, true, "get-rich-list", params, &res)
+	return res, err
+}
+
+// GetGlobalRichList returns the richest addresses by the pUSD value of all
+// their assets
+func (c *Client) GetGlobalRichList(ctx context.Context, params ParamsGetGlobalRichList) ([]ResultGlobalRichList, error) {
+	var res []ResultGlobalRichList
+	err := c.call(ctx, true, "get-global-rich-list", params, &res)
+	return res, err
+}
+
+// GetMinerDistribution returns the winning and graded miners of a range
+func (c *Client) GetMinerDistribution(ctx context.Context, params ParamsGetMiningDominance) (pegnet.MinerDominanceResult, error) {
+	var res pegnet.MinerDominanceResult
+	err := c.call(ctx, true, "get-miner-distribution", params, &res)
+	return res, err
+}
+
+// GetBank returns the bank of a height, 0 for the synced height
+func (c *Client) GetBank(ctx context.Context, height int32) (pegnet.BankEntry, error) {
+	var res pegnet.BankEntry
+	err := c.call(ctx, true, "get-bank", ParamsGetBank{Height: height}, &res)
+	return res, err
+}
+
+// GetGraded returns the graded oprs of a height, 0 for the synced height
+func (c *Client) GetGraded(ctx context.Context, height int32) (pegnet.GradedResult, error) {
+	var res pegnet.GradedResult
+	err := c.call(ctx, true, "get-graded", ParamsGetGraded{Height: height}, &res)
+	return res, err
+}
+
+// GetBlockSummary returns what syncing a height did, 0 for the synced height
+func (c *Client) GetBlockSummary(ctx context.Context, height uint32) (pegnet.BlockSummary, error) {
+	var res pegnet.BlockSummary
+	err := c.call(ctx, true, "get-block-summary", ParamsGetBlockSummary{Height: height}, &res)
+	return res, err
+}
+
+// GetTransactions returns the transactions matching the options
+func (c *Client) GetTransactions(ctx context.Context, opts ParamsGetPegnetTransaction) (ResultGetTransactions, error) {
+	var res ResultGetTransactions
+	err := c.call(ctx, true, "get-transactions", opts, &res)
+	return res, err
+}
+
+// GetTransaction returns the transaction of a txid
+func (c *Client) GetTransaction(ctx context.Context, txid string) (ResultGetTransactions, error) {
+	var res ResultGetTransactions
+	err := c.call(ctx, true, "get-transaction", ParamsGetPegnetTransaction{TxID: txid}, &res)
+	return res, err
+}
+
+// GetTransactionStatus returns the status of a transaction entry
+func (c *Client) GetTransactionStatus(ctx context.Context, hash *factom.Bytes32) (ResultGetTransactionStatus, error) {
+	var res ResultGetTransactionStatus
+	err := c.call(ctx, true, "get-transaction-status", ParamsGetPegnetTransactionStatus{Hash: hash}, &res)
+	return res, err
+}
+
+// GetSubmissionStatus returns the submission of send-transaction with the
+// idempotency key
+func (c *Client) GetSubmissionStatus(ctx context.Context, key string) (pegnet.Submission, error) {
+	var res pegnet.Submission
+	err := c.call(ctx, true, "get-submission-status", ParamsGetSubmissionStatus{Key: key}, &res)
+	return res, err
+}
+
+// GetSubmissions returns a page of the submissions of send-transaction
+func (c *Client) GetSubmissions(ctx context.Context, params ParamsGetSubmissions) (ResultGetSubmissions, error) {
+	var res ResultGetSubmissions
+	err := c.call(ctx, true, "get-submissions", params, &res)
+	return res, err
+}
+
+// ExportHistory returns a page of the balance effects of an address
+func (c *Client) ExportHistory(ctx context.Context, params ParamsExportHistory) (ResultExportHistory, error) {
+	var res ResultExportHistory
+	err := c.call(ctx, true, "export-history", params, &res)
+	return res, err
+}
+
+// GetPNLReport returns the profit and loss report of an address
+func (c *Client) GetPNLReport(ctx context.Context, params ParamsGetPNLReport) (ResultGetPNLReport, error) {
+	var res ResultGetPNLReport
+	err := c.call(ctx, true, "get-pnl-report", params, &res)
+	return res, err
+}
+
+// GetRateLimits returns the rate limits and the usage of the clients
+func (c *Client) GetRateLimits(ctx context.Context) (ResultGetRateLimits, error) {
+	var res ResultGetRateLimits
+	err := c.call(ctx, true, "get-rate-limits", nil, &res)
+	return res, err
+}
+
+// Discover returns the OpenRPC document of the api
+func (c *Client) Discover(ctx context.Context) (OpenRPCDocument, error) {
+	var res OpenRPCDocument
+	err := c.call(ctx, true, DiscoverMethod, nil, &res)
+	return res, err
+}
+
+// SendTransaction submits a transaction entry, paid for by the EC address of
+// pegnetd. With dryRun, the entry is only validated. The submission's
+// idempotency key is the entry hash, so a retry does not submit it twice.
+func (c *Client) SendTransaction(ctx context.Context, entry factom.Entry, dryRun bool) (ResultSendTransaction, error) {
+	params := ParamsSendTransaction{ExtIDs: entry.ExtIDs, Content: entry.Content, DryRun: dryRun}
+	params.ChainID = entry.ChainID
+	var res ResultSendTransaction
+	err := c.call(ctx, true, "send-transaction", params, &res)
+	return res, err
+}
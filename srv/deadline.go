@@ -0,0 +1,94 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
+	"github.com/pegnet/pegnetd/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// deadlines are how long each method may run. The context of the method is
+// canceled at its deadline, which interrupts its database queries.
+type deadlines struct {
+	timeout time.Duration
+	methods map[string]time.Duration
+}
+
+func newDeadlines(conf *viper.Viper) *deadlines {
+	d := &deadlines{
+		timeout: conf.GetDuration(config.APITimeout),
+		methods: make(map[string]time.Duration),
+	}
+	for method, timeout := range conf.GetStringMap(config.APITimeouts) {
+		if t, ok := durationValue(timeout); ok && t >= 0 {
+			d.methods[method] = t
+		} else {
+			log.WithField("method", method).Warnf("api: invalid timeout %v", timeout)
+		}
+	}
+	return d
+}
+
+// durationValue is a duration string, or a number of seconds
+func durationValue(v interface{}) (time.Duration, bool) {
+	if s, ok := v.(string); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		return d, err == nil
+	}
+	if i, ok := intValue(v); ok {
+		return time.Duration(i) * time.Second, true
+	}
+	return 0, false
+}
+
+// of returns the timeout of the method, 0 if it has none
+func (d *deadlines) of(method string) time.Duration {
+	if d == nil {
+		return 0
+	}
+	if t, ok := d.methods[method]; ok {
+		return t
+	}
+	return d.timeout
+}
+
+// withDeadline returns the method canceled at its deadline. A method that
+// fails, or panics, after its deadline has passed returns ErrorTimeout.
+func (s *APIServer) withDeadline(method string, f jrpc.MethodFunc) jrpc.MethodFunc {
+	return func(ctx context.Context, params json.RawMessage) (result interface{}) {
+		timeout := s.deadlines.of(method)
+		if timeout <= 0 {
+			return f(ctx, params)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		timedOut := func() bool { return ctx.Err() == context.DeadlineExceeded }
+		defer func() {
+			if r := recover(); r != nil {
+				if !timedOut() {
+					panic(r)
+				}
+				result = timeoutError(method, timeout)
+			}
+		}()
+		result = f(ctx, params)
+		if _, ok := result.(error); ok && timedOut() {
+			return timeoutError(method, timeout)
+		}
+		return result
+	}
+}
+
+func timeoutError(method string, timeout time.Duration) jrpc.Error {
+	log.WithFields(log.Fields{"method": method, "timeout": timeout}).Warn("api: method timed out")
+	err := ErrorTimeout
+	err.Data = fmt.Sprintf("%s did not finish within %s", method, timeout)
+	return err
+}
@@ -40,4 +40,25 @@ var (
 		"address may be invalid, or not yet tracked")
 	ErrorNotFound = jrpc.NewError(-32809, "Not Found",
 		"could not find what you were looking for")
+	ErrorUnauthorized = jrpc.NewError(-32810, "Unauthorized",
+		"the method requires authentication")
+	ErrorRateLimited = jrpc.NewError(-32811, "Rate Limited",
+		"too many requests, retry later")
+	ErrorTimeout = jrpc.NewError(-32812, "Timeout",
+		"the method did not finish within its deadline")
 )
+
+// Errors are the errors of the api, in the order of their codes
+var Errors = []jrpc.Error{
+	ErrorTokenNotFound,
+	ErrorTransactionNotFound,
+	ErrorInvalidTransaction,
+	ErrorTokenSyncing,
+	ErrorNoEC,
+	ErrorPendingDisabled,
+	ErrorAddressNotFound,
+	ErrorNotFound,
+	ErrorUnauthorized,
+	ErrorRateLimited,
+	ErrorTimeout,
+}
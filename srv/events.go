@@ -0,0 +1,253 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pegnet/pegnetd/node/pegnet"
+)
+
+// EventsPath is the path of the Server-Sent Events stream of synced blocks
+const EventsPath = "/events"
+
+// EventsMethod is the name of the stream for the api restrictions and the
+// rate limit weights
+const EventsMethod = "events"
+
+const (
+	// eventsHistory is how many of the recent blocks a reconnecting client
+	// can resume from without reading the database
+	eventsHistory = 1000
+	// eventsBackfillPage is how many older blocks are read from the database
+	// at a time
+	eventsBackfillPage = 100
+	// eventsBuffer is how many events a client can fall behind before it is
+	// disconnected, to resume with Last-Event-ID
+	eventsBuffer = 64
+	// eventsKeepAlive is how often an idle stream gets a comment, so proxies
+	// do not close it
+	eventsKeepAlive = 30 * time.Second
+	// eventsRetry is how many milliseconds clients wait to reconnect
+	eventsRetry = 5000
+)
+
+var eventMetrics = expvar.NewMap("events")
+
+// ResultEventsGap is the data of a gap event, the heights whose block events
+// can not be sent to a client that resumes from before them, as they were
+// synced before block summaries were recorded
+type ResultEventsGap struct {
+	FromHeight uint32 `json:"fromheight"`
+	ToHeight   uint32 `json:"toheight"`
+}
+
+// eventBroker fans the summaries of the synced blocks out to the streams, and
+// keeps the recent ones for the clients that reconnect
+type eventBroker struct {
+	mu          sync.Mutex
+	recent      []pegnet.BlockSummary // Oldest first
+	subscribers map[chan pegnet.BlockSummary]struct{}
+	closed      bool
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[chan pegnet.BlockSummary]struct{})}
+}
+
+func (b *eventBroker) publish(summary pegnet.BlockSummary) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.recent = append(b.recent, summary)
+	if len(b.recent) > eventsHistory {
+		b.recent = b.recent[len(b.recent)-eventsHistory:]
+	}
+	for ch := range b.subscribers {
+		select {
+		case ch <- summary:
+		default:
+			// Too slow, it can reconnect and resume where it was
+			b.remove(ch)
+			eventMetrics.Add("dropped", 1)
+		}
+	}
+	eventMetrics.Add("published", 1)
+}
+
+// subscribe returns the recent summaries after the height, and the channel of
+// the ones that follow. The channel is closed when the subscriber is dropped.
+func (b *eventBroker) subscribe(after uint32) ([]pegnet.BlockSummary, chan pegnet.BlockSummary) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var missed []pegnet.BlockSummary
+	for _, summary := range b.recent {
+		if summary.Height > after {
+			missed = append(missed, summary)
+		}
+	}
+	ch := make(chan pegnet.BlockSummary, eventsBuffer)
+	if b.closed {
+		close(ch)
+		return missed, ch
+	}
+	b.subscribers[ch] = struct{}{}
+	eventMetrics.Add("subscribers", 1)
+	return missed, ch
+}
+
+func (b *eventBroker) unsubscribe(ch chan pegnet.BlockSummary) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(ch)
+}
+
+// close ends all streams, so the server can shut down
+func (b *eventBroker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		b.remove(ch)
+	}
+}
+
+// remove must be called with the lock held
+func (b *eventBroker) remove(ch chan pegnet.BlockSummary) {
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+		eventMetrics.Add("subscribers", -1)
+	}
+}
+
+// eventsHandler serves the stream of block events. Like the REST gateway, the
+// request is named before it reaches protect, so the stream can be restricted
+// and weighted like a method.
+func (s *APIServer) eventsHandler(protect func(http.Handler) http.Handler) http.Handler {
+	serve := protect(http.HandlerFunc(s.serveEvents))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, fmt.Sprintf("%s is not allowed", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := context.WithValue(r.Context(), methodsKey{}, []string{EventsMethod})
+		serve.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// serveEvents sends a block event for every synced block. The id of an event
+// is its height, so a client that reconnects with Last-Event-ID gets the
+// blocks after it first, the recent ones from memory and older ones from the
+// database. The heights that have no summary are sent as a gap event instead.
+func (s *APIServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	after := s.Node.GetCurrentSync()
+	// EventSource can not set headers, so the first request can resume with
+	// the query instead
+	last := r.Header.Get("Last-Event-ID")
+	if last == "" {
+		last = r.URL.Query().Get("lastEventId")
+	}
+	if last != "" {
+		height, err := strconv.ParseUint(last, 10, 32)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid Last-Event-ID %q, it is a height", last), http.StatusBadRequest)
+			return
+		}
+		after = uint32(height)
+	}
+
+	missed, ch := s.events.subscribe(after)
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // For nginx
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, "retry: %d\n\n", eventsRetry)
+	flusher.Flush()
+
+	send := func(summary pegnet.BlockSummary) error {
+		if summary.Height <= after {
+			return nil // Already sent from the missed blocks
+		}
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		after = summary.Height
+		_, err = fmt.Fprintf(w, "id: %d\nevent: block\ndata: %s\n\n", summary.Height, data)
+		flusher.Flush()
+		return err
+	}
+	sendGap := func(to uint32) error {
+		data, err := json.Marshal(ResultEventsGap{FromHeight: after + 1, ToHeight: to})
+		if err != nil {
+			return err
+		}
+		after = to
+		_, err = fmt.Fprintf(w, "id: %d\nevent: gap\ndata: %s\n\n", to, data)
+		flusher.Flush()
+		return err
+	}
+
+	// The blocks before the recent ones, or all of them after a restart
+	to := s.Node.GetCurrentSync()
+	if len(missed) > 0 {
+		to = missed[0].Height - 1
+	}
+	for after < to {
+		summaries, err := s.Node.Pegnet.SelectBlockSummaries(r.Context(), s.Node.Pegnet.DB, after, to, eventsBackfillPage)
+		if err != nil {
+			return // The client resumes where it was
+		}
+		if len(summaries) == 0 {
+			if sendGap(to) != nil {
+				return
+			}
+			break
+		}
+		for _, summary := range summaries {
+			if summary.Height > after+1 && sendGap(summary.Height-1) != nil {
+				return
+			}
+			if send(summary) != nil {
+				return
+			}
+		}
+	}
+	for _, summary := range missed {
+		if send(summary) != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case summary, ok := <-ch:
+			if !ok || send(summary) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
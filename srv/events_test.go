@@ -0,0 +1,111 @@
+package srv
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/node"
+	"github.com/pegnet/pegnetd/node/pegnet"
+	"github.com/spf13/viper"
+)
+
+// newTestAPIServer returns an api server of a node with an empty database,
+// synced to the height, and the func that removes the database
+func newTestAPIServer(t *testing.T, conf *viper.Viper, synced uint32) (*APIServer, func()) {
+	dir, err := ioutil.TempDir("", "srv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Set(config.SqliteDBPath, filepath.Join(dir, "sql.db"))
+
+	n := &node.Pegnetd{Config: conf, Sync: &pegnet.BlockSync{Synced: synced}}
+	n.Pegnet = pegnet.New(conf)
+	if err := n.Pegnet.Init(); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return NewAPIServer(conf, n), func() {
+		n.Pegnet.DB.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// streamEvents returns the events of the stream as "event:id", until it has
+// been idle for a moment
+func streamEvents(s *APIServer, lastEventID string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	r := httptest.NewRequest(http.MethodGet, EventsPath, nil).WithContext(ctx)
+	if lastEventID != "" {
+		r.Header.Set("Last-Event-ID", lastEventID)
+	}
+	w := httptest.NewRecorder()
+	s.serveEvents(w, r)
+
+	var events []string
+	var id string
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			events = append(events, strings.TrimPrefix(line, "event: ")+":"+id)
+		}
+	}
+	return events
+}
+
+func TestServeEvents(t *testing.T) {
+	s, cleanup := newTestAPIServer(t, viper.New(), 10)
+	defer cleanup()
+
+	// Heights 1 to 4 were synced before summaries were recorded
+	tx, err := s.Node.Pegnet.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for height := uint32(5); height <= 10; height++ {
+		summary := pegnet.BlockSummary{Height: height, Timestamp: time.Unix(1589276400, 0)}
+		if err := s.Node.Pegnet.InsertBlockSummary(context.Background(), tx, summary); err != nil {
+			t.Fatal(err)
+		}
+		if height >= 9 {
+			s.events.publish(summary)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		restarted   bool
+		events      []string
+	}{
+		{"new clients get the blocks that follow", "", false, nil},
+		{"recent blocks", "8", false, []string{"block:9", "block:10"}},
+		{"older blocks", "6", false, []string{"block:7", "block:8", "block:9", "block:10"}},
+		{"blocks without a summary", "2", false, []string{"gap:4", "block:5", "block:6", "block:7", "block:8", "block:9", "block:10"}},
+		{"up to date", "10", false, nil},
+		{"after a restart", "7", true, []string{"block:8", "block:9", "block:10"}},
+		{"a gap after a restart", "0", true, []string{"gap:4", "block:5", "block:6", "block:7", "block:8", "block:9", "block:10"}},
+	}
+	for _, tt := range tests {
+		if tt.restarted {
+			s.events = newEventBroker()
+		}
+		events := streamEvents(s, tt.lastEventID)
+		if strings.Join(events, ",") != strings.Join(tt.events, ",") {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.events, events)
+		}
+	}
+}
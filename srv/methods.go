@@ -30,36 +30,50 @@ import (
 	"fmt"
 	"runtime"
 	"sort"
+	"time"
 
 	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
 	"github.com/Factom-Asset-Tokens/factom"
 	sqlite3 "github.com/mattn/go-sqlite3"
 	"github.com/pegnet/pegnetd/config"
 	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/pegnet/pegnetd/node"
 	"github.com/pegnet/pegnetd/node/conversions"
 	"github.com/pegnet/pegnetd/node/pegnet"
+	"github.com/pegnet/pegnetd/node/pnl"
 )
 
 func (s *APIServer) jrpcMethods() jrpc.MethodMap {
-	return jrpc.MethodMap{
-		"get-rich-list":          s.getRichList,
-		"get-global-rich-list":   s.getGlobalRichList,
-		"get-miner-distribution": s.getMiningDominance,
+	methods := jrpc.MethodMap{
+		"get-rich-list":          s.cached("get-rich-list", s.getRichList),
+		"get-global-rich-list":   s.cached("get-global-rich-list", s.getGlobalRichList),
+		"get-miner-distribution": s.cached("get-miner-distribution", s.getMiningDominance),
 		"get-bank":               s.getBank,
 		"get-transactions":       s.getTransactions(false),
 		"get-transaction-status": s.getTransactionStatus,
 		"get-transaction":        s.getTransactions(true),
+		"export-history":         s.exportHistory,
+		"get-pnl-report":         s.getPNLReport,
 		"get-pegnet-balances":    s.getPegnetBalances,
-		"get-pegnet-issuance":    s.getPegnetIssuance,
+		"get-pegnet-issuance":    s.cached("get-pegnet-issuance", s.getPegnetIssuance),
 		"get-graded":             s.getGraded,
+		"get-block-summary":      s.getBlockSummary,
 		"send-transaction":       s.sendTransaction,
+		"get-submission-status":  s.getSubmissionStatus,
+		"get-submissions":        s.getSubmissions,
 
 		"get-sync-status": s.getSyncStatus,
 		"properties":      s.properties,
 
-		"get-pegnet-rates": s.getPegnetRates,
-	}
+		"get-pegnet-rates":       s.getPegnetRates,
+		"get-pegnet-rates-range": s.getPegnetRatesRange,
 
+		"get-rate-limits": s.getRateLimits,
+	}
+	for name, f := range methods {
+		methods[name] = s.withDeadline(name, f)
+	}
+	return methods
 }
 
 type PegnetdProperties struct {
@@ -97,7 +111,7 @@ func (s *APIServer) getBank(ctx context.Context, data json.RawMessage) interface
 	if params.Height < int32(config.V4OPRUpdate) {
 		return jrpc.ErrorInvalidParams(fmt.Sprintf("the height %d is below the activation height (%d) of this feature", params.Height, config.V4OPRUpdate))
 	}
-	result, err := s.Node.Pegnet.SelectBankEntry(nil, params.Height)
+	result, err := s.Node.Pegnet.SelectBankEntry(ctx, nil, params.Height)
 	if err != nil {
 		return err
 	}
@@ -139,8 +153,9 @@ func (s *APIServer) getMiningDominance(ctx context.Context, data json.RawMessage
 }
 
 type ResultGlobalRichList struct {
-	Address string `json:"address"`
-	Equiv   uint64 `json:"pusd"`
+	Address      string        `json:"address"`
+	Equiv        uint64        `json:"pusd"`
+	AddressForms *AddressForms `json:"addressforms,omitempty"`
 }
 
 func (s *APIServer) getGlobalRichList(ctx context.Context, data json.RawMessage) interface{} {
@@ -166,7 +181,7 @@ func (s *APIServer) getGlobalRichList(ctx context.Context, data json.RawMessage)
 		return res
 	}
 
-	rich, err := s.Node.Pegnet.SelectAllBalances()
+	rich, err := s.Node.Pegnet.SelectAllBalances(ctx)
 	if err != nil {
 		return err
 	}
@@ -211,13 +226,23 @@ func (s *APIServer) getGlobalRichList(ctx context.Context, data json.RawMessage)
 		res = res[:params.Count]
 	}
 
+	if params.AddressForms {
+		for i := range res {
+			fa, _ := factom.NewFAAddress(res[i].Address)
+			if res[i].AddressForms, err = s.addressForms(ctx, fa); err != nil {
+				return err
+			}
+		}
+	}
+
 	return res
 }
 
 type ResultGetRichList struct {
-	Address string `json:"address"`
-	Amount  uint64 `json:"amount"`
-	Equiv   uint64 `json:"pusd"`
+	Address      string        `json:"address"`
+	Amount       uint64        `json:"amount"`
+	Equiv        uint64        `json:"pusd"`
+	AddressForms *AddressForms `json:"addressforms,omitempty"`
 }
 
 func (s *APIServer) getRichList(ctx context.Context, data json.RawMessage) interface{} {
@@ -240,7 +265,7 @@ func (s *APIServer) getRichList(ctx context.Context, data json.RawMessage) inter
 
 	ticker := fat2.StringToTicker(params.Asset) // already validated
 
-	rich, err := s.Node.Pegnet.SelectRichList(ticker, params.Count)
+	rich, err := s.Node.Pegnet.SelectRichList(ctx, ticker, params.Count)
 	if err != nil {
 		return err
 	}
@@ -263,6 +288,11 @@ func (s *APIServer) getRichList(ctx context.Context, data json.RawMessage) inter
 			}
 			entry.Equiv = uint64(c)
 		}
+		if params.AddressForms {
+			if entry.AddressForms, err = s.addressForms(ctx, *r.Address); err != nil {
+				return err
+			}
+		}
 
 		res = append(res, entry)
 	}
@@ -270,24 +300,34 @@ func (s *APIServer) getRichList(ctx context.Context, data json.RawMessage) inter
 	return res
 }
 
+// ResultGetTransactionStatus is the status of a transaction batch. `Pending`
+// is true for a batch that is only in the pending transactions, which has no
+// height yet.
 type ResultGetTransactionStatus struct {
 	Height   uint32 `json:"height"`
 	Executed int32  `json:"executed"`
+	Pending  bool   `json:"pending,omitempty"`
 }
 
-func (s *APIServer) getTransactionStatus(_ context.Context, data json.RawMessage) interface{} {
+func (s *APIServer) getTransactionStatus(ctx context.Context, data json.RawMessage) interface{} {
 	params := ParamsGetPegnetTransactionStatus{}
 	_, _, err := validate(data, &params)
 	if err != nil {
 		return err
 	}
+	if err := s.pendingTracked(params); err != nil {
+		return err
+	}
 
-	height, executed, err := s.Node.Pegnet.SelectTransactionHistoryStatus(params.Hash)
+	height, executed, err := s.Node.Pegnet.SelectTransactionHistoryStatus(ctx, params.Hash)
 	if err != nil {
 		return jrpc.ErrorInvalidParams(err)
 	}
 
 	if height == 0 {
+		if params.IncludePending && s.Node.Pending.Batch(*params.Hash) != nil {
+			return ResultGetTransactionStatus{Pending: true}
+		}
 		return ErrorTransactionNotFound
 	}
 
@@ -303,14 +343,20 @@ func (s *APIServer) getTransactionStatus(_ context.Context, data json.RawMessage
 // `Count` is the total number of possible transactions
 // `NextOffset` returns the offset to use to get the next set of records.
 //  0 means no more records available
+// `AddressForms` has the equivalent forms of every address in the actions,
+// keyed by FA address, if requested.
+// `Pending` has the matching pending transactions, if requested. They are not
+// paged, nor included in `Count`.
 type ResultGetTransactions struct {
-	Actions    interface{} `json:"actions"`
-	Count      int         `json:"count"`
-	NextOffset int         `json:"nextoffset"`
+	Actions      interface{}                 `json:"actions"`
+	Count        int                         `json:"count"`
+	NextOffset   int                         `json:"nextoffset"`
+	AddressForms map[string]*AddressForms    `json:"addressforms,omitempty"`
+	Pending      []pegnet.HistoryTransaction `json:"pending,omitempty"`
 }
 
-func (s *APIServer) getTransactions(forceTxId bool) func(_ context.Context, data json.RawMessage) interface{} {
-	return func(_ context.Context, data json.RawMessage) interface{} {
+func (s *APIServer) getTransactions(forceTxId bool) func(ctx context.Context, data json.RawMessage) interface{} {
+	return func(ctx context.Context, data json.RawMessage) interface{} {
 		params := ParamsGetPegnetTransaction{}
 		_, _, err := validate(data, &params)
 		if err != nil {
@@ -320,6 +366,9 @@ func (s *APIServer) getTransactions(forceTxId bool) func(_ context.Context, data
 		if forceTxId && params.TxID == "" {
 			return jrpc.ErrorInvalidParams(fmt.Errorf("expect txid param to be populated"))
 		}
+		if err := s.pendingTracked(params); err != nil {
+			return err
+		}
 
 		// using a separate options struct due to golang's circular import restrictions
 		var options pegnet.HistoryQueryOptions
@@ -330,6 +379,9 @@ func (s *APIServer) getTransactions(forceTxId bool) func(_ context.Context, data
 		options.Coinbase = params.Coinbase
 		options.FCTBurn = params.Burn
 		options.Asset = params.Asset
+		options.FromHeight = uint32(params.FromHeight)
+		options.ToHeight = uint32(params.ToHeight)
+		options.FromTime, options.ToTime, _ = params.timeRange() // verified in param
 
 		// Are we searching by txid?
 		if params.TxID != "" {
@@ -344,41 +396,303 @@ func (s *APIServer) getTransactions(forceTxId bool) func(_ context.Context, data
 
 		var actions []pegnet.HistoryTransaction
 		var count int
+		var addr factom.FAAddress
 
 		if params.Hash != "" {
 			hash := new(factom.Bytes32)
 			_ = hash.UnmarshalText([]byte(params.Hash)) // error checked by params.valid
-			actions, count, err = s.Node.Pegnet.SelectTransactionHistoryActionsByHash(hash, options)
+			actions, count, err = s.Node.Pegnet.SelectTransactionHistoryActionsByHash(ctx, hash, options)
 		} else if params.Address != "" {
-			addr, _ := underlyingFA(params.Address) // verified in param
-			actions, count, err = s.Node.Pegnet.SelectTransactionHistoryActionsByAddress(&addr, options)
+			if addr, err = s.resolveAddress(ctx, params.Address); err != nil {
+				return err
+			}
+			actions, count, err = s.Node.Pegnet.SelectTransactionHistoryActionsByAddress(ctx, &addr, options)
 		} else if params.TxID != "" {
 			hash := new(factom.Bytes32)
 			_ = hash.UnmarshalText([]byte(params.txEntryHash)) // error checked by params.valid
-			actions, count, err = s.Node.Pegnet.SelectTransactionHistoryActionsByTxID(hash, options)
+			actions, count, err = s.Node.Pegnet.SelectTransactionHistoryActionsByTxID(ctx, hash, options)
+		} else if params.Height > 0 {
+			actions, count, err = s.Node.Pegnet.SelectTransactionHistoryActionsByHeight(ctx, uint32(params.Height), options)
 		} else {
-			actions, count, err = s.Node.Pegnet.SelectTransactionHistoryActionsByHeight(uint32(params.Height), options)
+			actions, count, err = s.Node.Pegnet.SelectTransactionHistoryActionsByRange(ctx, options)
 		}
 
 		if err != nil {
 			return jrpc.ErrorInvalidParams(err.Error())
 		}
 
+		var res ResultGetTransactions
+		if params.IncludePending {
+			res.Pending = s.pendingTransactions(params, options, addr)
+		}
+
 		if len(actions) == 0 {
-			return ErrorTransactionNotFound
+			if len(res.Pending) == 0 {
+				return ErrorTransactionNotFound
+			}
+			actions = []pegnet.HistoryTransaction{}
 		}
 
-		var res ResultGetTransactions
 		res.Count = count
 		if params.Offset+len(actions) < count {
 			res.NextOffset = params.Offset + len(actions)
 		}
 		res.Actions = actions
 
+		if params.AddressForms {
+			res.AddressForms = make(map[string]*AddressForms)
+			add := func(fa factom.FAAddress) error {
+				if _, ok := res.AddressForms[fa.String()]; ok {
+					return nil
+				}
+				forms, err := s.addressForms(ctx, fa)
+				res.AddressForms[fa.String()] = forms
+				return err
+			}
+			for _, action := range append(actions, res.Pending...) {
+				if err := add(*action.FromAddress); err != nil {
+					return err
+				}
+				for _, out := range action.Outputs {
+					if err := add(out.Address); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
 		return res
 	}
 }
 
+// pendingTransactions returns the pending transactions that match the query
+// of the params. Pending transactions have no height, so none match a query by
+// height, or one with a window that ends at a height.
+func (s *APIServer) pendingTransactions(params ParamsGetPegnetTransaction, options pegnet.HistoryQueryOptions, addr factom.FAAddress) []pegnet.HistoryTransaction {
+	if params.Height > 0 || params.ToHeight > 0 {
+		return nil
+	}
+	hash := params.Hash
+	if params.TxID != "" {
+		hash = params.txEntryHash
+	}
+	var entryHash factom.Bytes32
+	_ = entryHash.UnmarshalText([]byte(hash)) // error checked by params.valid
+	anyType := options.Transfer == options.Conversion && !options.Coinbase && !options.FCTBurn
+
+	var pending []pegnet.HistoryTransaction
+	for _, batch := range s.Node.Pending.Batches() {
+		if hash != "" && *batch.Entry.Hash != entryHash {
+			continue
+		}
+		for _, tx := range batch.History {
+			if options.UseTxIndex && tx.TxIndex != options.TxIndex {
+				continue
+			}
+			if params.Address != "" && !pendingInvolves(tx, addr) {
+				continue
+			}
+			if !anyType && !(options.Transfer && tx.TxAction == pegnet.Transfer) &&
+				!(options.Conversion && tx.TxAction == pegnet.Conversion) {
+				continue
+			}
+			if options.Asset != "" && tx.FromAsset != options.Asset && tx.ToAsset != options.Asset {
+				continue
+			}
+			if !options.FromTime.IsZero() && tx.Timestamp.Before(options.FromTime) ||
+				!options.ToTime.IsZero() && !tx.Timestamp.Before(options.ToTime) {
+				continue
+			}
+			pending = append(pending, tx)
+		}
+	}
+	return pending
+}
+
+func pendingInvolves(tx pegnet.HistoryTransaction, addr factom.FAAddress) bool {
+	if *tx.FromAddress == addr {
+		return true
+	}
+	for _, out := range tx.Outputs {
+		if out.Address == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// ResultExportHistory returns the balance effects of a page of history entries.
+// `Count` is the total number of possible transactions
+// `NextOffset` returns the offset to use to get the next page.
+// 0 means no more records available
+type ResultExportHistory struct {
+	Rows       []pegnet.HistoryBalanceEffect `json:"rows"`
+	Count      int                           `json:"count"`
+	NextOffset int                           `json:"nextoffset"`
+}
+
+// exportHistory flattens the history of an address into balance effects.
+// Large histories are exported a page at a time using `nextoffset`.
+func (s *APIServer) exportHistory(ctx context.Context, data json.RawMessage) interface{} {
+	params := ParamsExportHistory{}
+	_, _, err := validate(data, &params)
+	if err != nil {
+		return err
+	}
+
+	addr, err := s.resolveAddress(ctx, params.Address)
+	if err != nil {
+		return err
+	}
+	var options pegnet.HistoryQueryOptions
+	options.Offset = params.Offset
+	options.FromHeight = uint32(params.FromHeight)
+	options.ToHeight = uint32(params.ToHeight)
+	options.FromTime, options.ToTime, _ = params.transactionParams().timeRange() // verified in param
+
+	actions, count, err := s.Node.Pegnet.SelectTransactionHistoryActionsByAddress(ctx, &addr, options)
+	if err != nil {
+		return jrpc.ErrorInvalidParams(err.Error())
+	}
+
+	res := ResultExportHistory{Rows: make([]pegnet.HistoryBalanceEffect, 0), Count: count}
+	if params.Offset+len(actions) < count {
+		res.NextOffset = params.Offset + len(actions)
+	}
+
+	// Conversions in the same block share their rates
+	type rateSet struct{ rates, averages map[fat2.PTicker]uint64 }
+	rateCache := make(map[int32]rateSet)
+	for _, action := range actions {
+		effects := action.BalanceEffects(addr)
+		if action.TxAction == pegnet.Conversion && len(effects) > 0 {
+			set, ok := rateCache[action.Executed]
+			if !ok {
+				if set.rates, set.averages, err = s.conversionRates(ctx, uint32(action.Executed)); err != nil {
+					return err
+				}
+				rateCache[action.Executed] = set
+			}
+			from, to := fat2.StringToTicker(action.FromAsset), fat2.StringToTicker(action.ToAsset)
+			for i := range effects {
+				effects[i].FromRate, effects[i].ToRate = set.rates[from], set.rates[to]
+				effects[i].FromAverage, effects[i].ToAverage = set.averages[from], set.averages[to]
+			}
+		}
+		res.Rows = append(res.Rows, effects...)
+	}
+
+	return res
+}
+
+// conversionRates returns the rates and PIP-10 averages that were applied to
+// conversions executed at the given height. The averages are nil before
+// PIP-10 activation.
+func (s *APIServer) conversionRates(ctx context.Context, height uint32) (rates, averages map[fat2.PTicker]uint64, err error) {
+	rates, err = s.Node.Pegnet.SelectRates(ctx, height)
+	if err != nil {
+		return nil, nil, err
+	}
+	if height < config.PIP10AverageActivation {
+		return rates, nil, nil
+	}
+
+	// Same as ApplyTransactionBatchesInHolding
+	_, avgHeight, err := s.Node.Pegnet.SelectMostRecentRatesBeforeHeight(ctx, s.Node.Pegnet.DB, height)
+	if err != nil {
+		return nil, nil, err
+	}
+	averages = s.Node.GetPegNetRateAverages(ctx, avgHeight).(map[fat2.PTicker]uint64)
+	return rates, averages, nil
+}
+
+// ResultGetPNLReport is the profit and loss report of an address.
+// All values are denominated in `Currency`, valued at `Height`.
+type ResultGetPNLReport struct {
+	pnl.Report
+	Currency string `json:"currency"`
+	Height   uint32 `json:"height"`
+}
+
+func (s *APIServer) getPNLReport(ctx context.Context, data json.RawMessage) interface{} {
+	params := ParamsGetPNLReport{}
+	_, _, err := validate(data, &params)
+	if err != nil {
+		return err
+	}
+
+	res := ResultGetPNLReport{Currency: "pUSD", Height: s.Node.GetCurrentSync()}
+	if params.Currency != "" {
+		res.Currency = fat2.StringToTicker(params.Currency).String()
+	}
+	method := pnl.FIFO
+	if params.Method != "" {
+		method = pnl.Method(params.Method)
+	}
+
+	// The cost basis needs all acquisitions, so the history always
+	// starts at the beginning
+	var options pegnet.HistoryQueryOptions
+	var since time.Time
+	if params.Year > 0 {
+		since = time.Date(params.Year, 1, 1, 0, 0, 0, 0, time.UTC)
+		options.ToTime = since.AddDate(1, 0, 0)
+		if time.Now().After(options.ToTime) {
+			if res.Height, err = s.Node.Pegnet.SelectTransactionHistoryHeightBeforeTime(ctx, options.ToTime); err != nil {
+				return err
+			}
+		}
+	}
+
+	addr, err := s.resolveAddress(ctx, params.Address)
+	if err != nil {
+		return err
+	}
+	var effects []pegnet.HistoryBalanceEffect
+	for {
+		actions, count, err := s.Node.Pegnet.SelectTransactionHistoryActionsByAddress(ctx, &addr, options)
+		if err != nil {
+			return err
+		}
+		for _, action := range actions {
+			effects = append(effects, action.BalanceEffects(addr)...)
+		}
+		options.Offset += len(actions)
+		if len(actions) == 0 || options.Offset >= count {
+			break
+		}
+	}
+
+	currency := fat2.StringToTicker(res.Currency)
+	rates := make(map[uint32]map[fat2.PTicker]uint64)
+	value := func(asset string, amount int64, height uint32) (int64, error) {
+		r, ok := rates[height]
+		if !ok {
+			// Not every height has rates, use the ones in effect
+			if r, _, err = s.Node.Pegnet.SelectMostRecentRatesBeforeHeight(ctx, s.Node.Pegnet.DB, height+1); err != nil {
+				return 0, err
+			}
+			rates[height] = r
+		}
+		ticker := fat2.StringToTicker(asset)
+		if r[ticker] == 0 || amount == 0 {
+			// Assets without a price, like PEG before it was priced, have no value
+			return 0, nil
+		}
+		if r[currency] == 0 {
+			return 0, fmt.Errorf("no %s rate", res.Currency)
+		}
+		return conversions.Convert(height, amount, r[ticker], r[ticker], r[currency], r[currency])
+	}
+
+	report, err := pnl.Compute(method, effects, since, res.Height, value)
+	if err != nil {
+		return jrpc.ErrorInvalidParams(err.Error())
+	}
+	res.Report = *report
+	return res
+}
+
 // TODO: This is incompatible with FAT.
 type ResultPegnetTickerMap map[fat2.PTicker]uint64
 
@@ -408,20 +722,62 @@ func (r *ResultPegnetTickerMap) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func (s *APIServer) getPegnetBalances(_ context.Context, data json.RawMessage) interface{} {
+// ResultGetPegnetBalances are the balances of an address along with its
+// equivalent forms and its pending amounts, returned if "addressforms" or
+// "includepending" is requested
+type ResultGetPegnetBalances struct {
+	Balances     ResultPegnetTickerMap  `json:"balances"`
+	AddressForms *AddressForms          `json:"addressforms,omitempty"`
+	Pending      *ResultPendingBalances `json:"pending,omitempty"`
+}
+
+// ResultPendingBalances are the amounts the pending transactions send to and
+// from an address. They are not in its balances until they are executed, and
+// the outputs of conversions are not known until then.
+type ResultPendingBalances struct {
+	Incoming ResultPegnetTickerMap `json:"incoming"`
+	Outgoing ResultPegnetTickerMap `json:"outgoing"`
+}
+
+func (s *APIServer) getPegnetBalances(ctx context.Context, data json.RawMessage) interface{} {
 	params := ParamsGetPegnetBalances{}
 	if _, _, err := validate(data, &params); err != nil {
 		return err
 	}
-	add, _ := underlyingFA(params.Address)
+	if err := s.pendingTracked(params); err != nil {
+		return err
+	}
+	add, err := s.resolveAddress(ctx, params.Address)
+	if err != nil {
+		return err
+	}
 
-	bals, err := s.Node.Pegnet.SelectBalances(&add)
+	var pending *ResultPendingBalances
+	if params.IncludePending {
+		incoming, outgoing := s.Node.Pending.Balances(add)
+		pending = &ResultPendingBalances{Incoming: incoming, Outgoing: outgoing}
+	}
+
+	bals, err := s.Node.Pegnet.SelectBalances(ctx, &add)
 	if err == sql.ErrNoRows {
-		return ErrorAddressNotFound
+		// An address is new until its first transaction is executed
+		if pending == nil || len(pending.Incoming) == 0 {
+			return ErrorAddressNotFound
+		}
+		bals, err = make(map[fat2.PTicker]uint64), nil
 	}
 	if err != nil {
 		panic(err) // This is an internal error
 	}
+	if params.AddressForms || params.IncludePending {
+		res := ResultGetPegnetBalances{Balances: bals, Pending: pending}
+		if params.AddressForms {
+			if res.AddressForms, err = s.addressForms(ctx, add); err != nil {
+				return err
+			}
+		}
+		return res
+	}
 	return ResultPegnetTickerMap(bals)
 }
 
@@ -430,8 +786,8 @@ type ResultGetIssuance struct {
 	Issuance   ResultPegnetTickerMap `json:"issuance"`
 }
 
-func (s *APIServer) getPegnetIssuance(_ context.Context, data json.RawMessage) interface{} {
-	issuance, err := s.Node.Pegnet.SelectIssuances()
+func (s *APIServer) getPegnetIssuance(ctx context.Context, data json.RawMessage) interface{} {
+	issuance, err := s.Node.Pegnet.SelectIssuances(ctx)
 	if err == sql.ErrNoRows {
 		return ErrorAddressNotFound
 	}
@@ -472,7 +828,91 @@ func (s *APIServer) getPegnetRates(ctx context.Context, data json.RawMessage) in
 	return ResultPegnetTickerMap(rates)
 }
 
-func (s *APIServer) sendTransaction(_ context.Context, data json.RawMessage) interface{} {
+// ResultRatesAtHeight are the rates of a single height
+type ResultRatesAtHeight struct {
+	Height uint32                `json:"height"`
+	Rates  ResultPegnetTickerMap `json:"rates"`
+}
+
+// ResultRateBucket are the aggregated rates of the heights
+// [FromHeight, ToHeight]
+type ResultRateBucket struct {
+	FromHeight uint32                     `json:"fromheight"`
+	ToHeight   uint32                     `json:"toheight"`
+	Rates      map[string]pegnet.RateOHLC `json:"rates"`
+}
+
+// ResultGetPegnetRatesRange returns either `Rates` or, if a bucket size was
+// requested, `Buckets`. Heights without rates are left out.
+type ResultGetPegnetRatesRange struct {
+	Rates   []ResultRatesAtHeight `json:"rates,omitempty"`
+	Buckets []ResultRateBucket    `json:"buckets,omitempty"`
+}
+
+func (s *APIServer) getPegnetRatesRange(ctx context.Context, data json.RawMessage) interface{} {
+	params := ParamsGetPegnetRatesRange{}
+	if _, _, err := validate(data, &params); err != nil {
+		return err
+	}
+
+	rates, err := s.Node.Pegnet.SelectRatesRange(ctx, params.FromHeight, params.ToHeight)
+	if err != nil {
+		return err
+	}
+	if len(rates) == 0 {
+		return ErrorNotFound
+	}
+
+	filter := make(map[fat2.PTicker]bool)
+	for _, asset := range params.Assets {
+		filter[fat2.StringToTicker(asset)] = true // already validated
+	}
+	include := func(ticker fat2.PTicker) bool {
+		return len(filter) == 0 || filter[ticker]
+	}
+
+	var res ResultGetPegnetRatesRange
+	if params.Bucket == 0 {
+		res.Rates = make([]ResultRatesAtHeight, 0, len(rates))
+		for _, r := range rates {
+			m := make(ResultPegnetTickerMap)
+			for ticker, rate := range r.Rates {
+				if include(ticker) {
+					m[ticker] = rate
+				}
+			}
+			res.Rates = append(res.Rates, ResultRatesAtHeight{Height: r.Height, Rates: m})
+		}
+		return res
+	}
+
+	buckets := pegnet.BucketRates(rates, params.FromHeight, params.Bucket)
+	res.Buckets = make([]ResultRateBucket, 0, len(buckets))
+	for _, b := range buckets {
+		m := make(map[string]pegnet.RateOHLC)
+		for ticker, ohlc := range b.Rates {
+			if include(ticker) {
+				m[ticker.String()] = ohlc
+			}
+		}
+		res.Buckets = append(res.Buckets, ResultRateBucket{FromHeight: b.FromHeight, ToHeight: b.ToHeight, Rates: m})
+	}
+	return res
+}
+
+// ResultSendTransaction is the entry of a transaction. Unless it is a dry run,
+// it is along with the idempotency key and status of its submission, and the
+// error of its last commit or reveal, which is retried by the node.
+type ResultSendTransaction struct {
+	ChainID *factom.Bytes32 `json:"chainid"`
+	TxID    *factom.Bytes32 `json:"txid,omitempty"`
+	Hash    *factom.Bytes32 `json:"entryhash"`
+	Key     string          `json:"idempotencykey,omitempty"`
+	Status  string          `json:"status,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+func (s *APIServer) sendTransaction(ctx context.Context, data json.RawMessage) interface{} {
 	params := ParamsSendTransaction{}
 	_, _, err := validate(data, &params)
 	if err != nil {
@@ -488,43 +928,99 @@ func (s *APIServer) sendTransaction(_ context.Context, data json.RawMessage) int
 
 	entry := params.Entry()
 	entry.ChainID = &config.TransactionChain // TODO consider not passing a pointer to the config.TransactionChain
-	// TODO: attempt to apply
-	//txErr, err := attemptApplyFAT2TxBatch(chain, entry)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//if txErr != nil {
-	//	err := ErrorInvalidTransaction
-	//	err.Data = txErr.Error()
-	//	return err
-	//}
-
-	var txID factom.Bytes32
-	if !params.DryRun {
-		balance, err := ecPrivateKey.ECAddress().GetBalance(nil, s.Node.FactomClient)
-		if err != nil {
-			panic(err)
-		}
-		cost, err := entry.Cost()
+	// A batch that is not valid is ignored by the sync once it is in a
+	// block, so it is not paid for
+	if _, err := fat2.NewTransactionBatch(entry, int32(s.Node.GetCurrentSync()+1)); err != nil {
+		rerr := ErrorInvalidTransaction
+		rerr.Data = err.Error()
+		return rerr
+	}
+	// TODO: check the balances of the inputs, see attemptApplyFAT2TxBatch
+
+	if params.DryRun {
+		var txID factom.Bytes32
+		return ResultSendTransaction{ChainID: entry.ChainID, TxID: &txID, Hash: entry.Hash}
+	}
+
+	cost, err := entry.Cost()
+	if err != nil {
+		rerr := ErrorInvalidTransaction
+		rerr.Data = err.Error()
+		return rerr
+	}
+	sub, err := s.Node.Submissions.Submit(ctx, params.Key, entry, func() error {
+		balance, err := ecPrivateKey.ECAddress().GetBalance(ctx, s.Node.FactomClient)
 		if err != nil {
-			rerr := ErrorInvalidTransaction
-			rerr.Data = err.Error()
-			return rerr
+			return nil // Queued anyway, the commit is retried until factomd answers
 		}
 		if balance < uint64(cost) {
 			return ErrorNoEC
 		}
-		txID, err = entry.ComposeCreate(nil, s.Node.FactomClient, ecPrivateKey)
-		if err != nil {
-			panic(err)
-		}
+		return nil
+	})
+	if err == node.ErrSubmissionKeyReused {
+		return jrpc.ErrorInvalidParams(err.Error())
+	}
+	if err, ok := err.(jrpc.Error); ok {
+		return err
+	}
+	if err != nil {
+		panic(err) // This is an internal error
+	}
+
+	return ResultSendTransaction{ChainID: entry.ChainID, TxID: sub.TxID, Hash: sub.EntryHash,
+		Key: sub.Key, Status: sub.Status, Error: sub.Error}
+}
+
+func (s *APIServer) getSubmissionStatus(ctx context.Context, data json.RawMessage) interface{} {
+	params := ParamsGetSubmissionStatus{}
+	if _, _, err := validate(data, &params); err != nil {
+		return err
 	}
+	sub, err := s.Node.Pegnet.SelectSubmission(ctx, s.Node.Pegnet.DB, params.Key)
+	if err == sql.ErrNoRows {
+		rerr := ErrorNotFound
+		rerr.Data = "no submission with this idempotency key"
+		return rerr
+	}
+	if err != nil {
+		panic(err) // This is an internal error
+	}
+	return sub
+}
 
-	return struct {
-		ChainID *factom.Bytes32 `json:"chainid"`
-		TxID    *factom.Bytes32 `json:"txid,omitempty"`
-		Hash    *factom.Bytes32 `json:"entryhash"`
-	}{ChainID: entry.ChainID, TxID: &txID, Hash: entry.Hash}
+// ResultGetSubmissions returns a page of submissions.
+// `Count` is the total number of submissions
+// `NextOffset` returns the offset to use to get the next page.
+// 0 means no more records available
+type ResultGetSubmissions struct {
+	Submissions []pegnet.Submission `json:"submissions"`
+	Count       int                 `json:"count"`
+	NextOffset  int                 `json:"nextoffset"`
+}
+
+func (s *APIServer) getSubmissions(ctx context.Context, data json.RawMessage) interface{} {
+	params := ParamsGetSubmissions{}
+	if _, _, err := validate(data, &params); err != nil {
+		return err
+	}
+	var statuses []string
+	if params.Status != "" {
+		statuses = []string{params.Status}
+	}
+	subs, count, err := s.Node.Pegnet.SelectSubmissions(ctx, s.Node.Pegnet.DB, statuses, params.Offset, pegnet.QueryLimit)
+	if err != nil {
+		panic(err) // This is an internal error
+	}
+
+	res := ResultGetSubmissions{Submissions: subs, Count: count}
+	if res.Submissions == nil {
+		res.Submissions = []pegnet.Submission{}
+	}
+	if params.Offset+len(subs) < count {
+		res.NextOffset = params.Offset + len(subs)
+	}
+	return res
 }
 
 //func attemptApplyFAT2TxBatch(chain *engine.Chain, e factom.Entry) (txErr, err error) {
@@ -550,15 +1046,20 @@ func (s *APIServer) sendTransaction(_ context.Context, data json.RawMessage) int
 type ResultGetSyncStatus struct {
 	Sync    uint32 `json:"syncheight"`
 	Current int32  `json:"factomheight"`
+	// Factomd is the health of each factomd endpoint
+	Factomd []node.FactomdStatus `json:"factomd,omitempty"`
 }
 
 func (s *APIServer) getSyncStatus(_ context.Context, data json.RawMessage) interface{} {
+	res := ResultGetSyncStatus{Sync: s.Node.GetCurrentSync(), Current: -1}
+	if s.Node.FactomdPool != nil {
+		res.Factomd = s.Node.FactomdPool.Status()
+	}
 	heights := new(factom.Heights)
-	err := heights.Get(nil, s.Node.FactomClient)
-	if err != nil {
-		return ResultGetSyncStatus{Sync: s.Node.GetCurrentSync(), Current: -1}
+	if err := heights.Get(nil, s.Node.FactomClient); err == nil {
+		res.Current = int32(heights.DirectoryBlock)
 	}
-	return ResultGetSyncStatus{Sync: s.Node.GetCurrentSync(), Current: int32(heights.DirectoryBlock)}
+	return res
 }
 
 func (s *APIServer) getGraded(ctx context.Context, data json.RawMessage) interface{} {
@@ -583,6 +1084,28 @@ func (s *APIServer) getGraded(ctx context.Context, data json.RawMessage) interfa
 	return result
 }
 
+func (s *APIServer) getBlockSummary(ctx context.Context, data json.RawMessage) interface{} {
+	params := ParamsGetBlockSummary{}
+	if _, _, err := validate(data, &params); err != nil {
+		return err
+	}
+
+	if params.Height == 0 {
+		params.Height = s.Node.GetCurrentSync()
+	}
+
+	summary, err := s.Node.Pegnet.SelectBlockSummary(ctx, s.Node.Pegnet.DB, params.Height)
+	if err == sql.ErrNoRows {
+		notFound := ErrorNotFound
+		notFound.Data = fmt.Sprintf("height %d is not synced, or was synced before block summaries were recorded", params.Height)
+		return notFound
+	}
+	if err != nil {
+		panic(err) // This is an internal error
+	}
+	return summary
+}
+
 // TODO: Re-eval this function. The chain data that is supplied needs to be reimplemented
 //		return was (*engine.Chain, func(), error)
 func validate(data json.RawMessage, params Params) (interface{}, func(), error) {
@@ -601,9 +1124,6 @@ func validate(data json.RawMessage, params Params) (interface{}, func(), error)
 	if err := params.IsValid(); err != nil {
 		return nil, nil, err
 	}
-	//if params.HasIncludePending() && flag.DisablePending {
-	//	return nil, nil, ErrorPendingDisabled
-	//}
 	chainID := params.ValidChainID()
 	if chainID != nil {
 		if *chainID != config.TransactionChain {
@@ -627,6 +1147,15 @@ func validate(data json.RawMessage, params Params) (interface{}, func(), error)
 	return nil, nil, nil
 }
 
+// pendingTracked returns ErrorPendingDisabled if the params include the
+// pending transactions, and the node is not tracking them
+func (s *APIServer) pendingTracked(params Params) error {
+	if params.HasIncludePending() && s.Node.Pending == nil {
+		return ErrorPendingDisabled
+	}
+	return nil
+}
+
 func unmarshalStrict(data []byte, v interface{}) error {
 	b := bytes.NewBuffer(data)
 	d := json.NewDecoder(b)
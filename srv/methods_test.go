@@ -0,0 +1,67 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
+	"github.com/Factom-Asset-Tokens/factom"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/fat/fat2"
+	"github.com/spf13/viper"
+)
+
+func TestSendTransaction_Invalid(t *testing.T) {
+	es, err := factom.GenerateEsAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := viper.New()
+	conf.Set(config.ECPrivateKey, es.String())
+	s, cleanup := newTestAPIServer(t, conf, 100)
+	defer cleanup()
+
+	fs, err := factom.GenerateFsAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batch fat2.TransactionBatch
+	content := fmt.Sprintf(`{"version":1,"transactions":[{"input":{"address":%q,"type":"pUSD","amount":5},"transfers":[{"address":%q,"amount":5}]}]}`,
+		fs.FAAddress(), factom.FAAddress{1})
+	if err := json.Unmarshal([]byte(content), &batch); err != nil {
+		t.Fatal(err)
+	}
+	batch.Entry.ChainID = &config.TransactionChain
+	signed, err := batch.Sign(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := signed
+	unsigned.ExtIDs = []factom.Bytes{factom.Bytes("nonce")}
+	malformed := signed
+	malformed.Content = factom.Bytes(`{"version":1}`)
+
+	tests := []struct {
+		name  string
+		entry factom.Entry
+		valid bool
+	}{
+		{"a signed batch", signed, true},
+		{"a batch that is not signed", unsigned, false},
+		{"a malformed batch", malformed, false},
+	}
+	for _, tt := range tests {
+		raw, err := tt.entry.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		params, _ := json.Marshal(ParamsSendTransaction{Raw: raw, DryRun: true})
+		res := s.sendTransaction(context.Background(), params)
+		rerr, invalid := res.(jrpc.Error)
+		if invalid == tt.valid || (invalid && rerr.Code != ErrorInvalidTransaction.Code) {
+			t.Errorf("%s: unexpected result %+v", tt.name, res)
+		}
+	}
+}
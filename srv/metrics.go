@@ -0,0 +1,20 @@
+package srv
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+)
+
+// metricsHandler serves the expvar metrics as json, like expvar.Handler, but
+// without the command line, which may hold secrets
+func metricsHandler(w http.ResponseWriter, _ *http.Request) {
+	metrics := make(map[string]json.RawMessage)
+	expvar.Do(func(kv expvar.KeyValue) {
+		if kv.Key != "cmdline" {
+			metrics[kv.Key] = json.RawMessage(kv.Value.String())
+		}
+	})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(metrics)
+}
@@ -0,0 +1,560 @@
+package srv
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
+	"github.com/pegnet/pegnetd/config"
+	"github.com/pegnet/pegnetd/node/pegnet"
+)
+
+// DiscoverMethod returns the OpenRPC document of the api. Method names with
+// the "rpc." prefix are reserved for the JSON-RPC handler, so it is answered
+// before the request reaches it.
+const DiscoverMethod = "rpc.discover"
+
+// OpenRPCVersion is the version of the OpenRPC specification the document
+// follows
+const OpenRPCVersion = "1.2.6"
+
+// OpenRPCDocument describes the methods of the api, see https://spec.open-rpc.org
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []OpenRPCMethod   `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+type OpenRPCInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type OpenRPCMethod struct {
+	Name           string                     `json:"name"`
+	Summary        string                     `json:"summary,omitempty"`
+	Description    string                     `json:"description,omitempty"`
+	ParamStructure string                     `json:"paramStructure"`
+	Params         []OpenRPCContentDescriptor `json:"params"`
+	Result         OpenRPCContentDescriptor   `json:"result"`
+	Errors         []OpenRPCError             `json:"errors,omitempty"`
+}
+
+type OpenRPCContentDescriptor struct {
+	Name     string      `json:"name"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *JSONSchema `json:"schema"`
+}
+
+type OpenRPCError struct {
+	Code    jrpc.ErrorCode `json:"code"`
+	Message string         `json:"message"`
+	Data    interface{}    `json:"data,omitempty"`
+}
+
+type OpenRPCComponents struct {
+	Schemas map[string]*JSONSchema  `json:"schemas"`
+	Errors  map[string]OpenRPCError `json:"errors"`
+}
+
+// JSONSchema is the subset of JSON Schema needed to describe the params and
+// results
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Minimum              *int64                 `json:"minimum,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+}
+
+// methodDoc is what can not be read from the types of a method. The
+// constraints are the ones checked by the IsValid of the params, and by the
+// method itself.
+type methodDoc struct {
+	summary     string
+	params      interface{}
+	required    []string
+	constraints []string
+	// results are the possible results, a method returns one of them
+	results []interface{}
+	errors  []jrpc.Error
+}
+
+var invalidParams = jrpc.ErrorInvalidParams(nil)
+
+var historyWindow = []string{
+	"offset, fromheight and toheight must be >= 0, and toheight >= fromheight",
+	"fromtime and totime are RFC3339 timestamps, totime must be after fromtime",
+}
+
+var methodDocs = map[string]methodDoc{
+	"get-rich-list": {
+		summary:     "The addresses with the largest balances of an asset",
+		params:      ParamsGetRichList{},
+		required:    []string{"asset"},
+		constraints: []string{"asset must be a pegnet asset", "count must be >= 0, 100 by default"},
+		results:     []interface{}{[]ResultGetRichList{}},
+		errors:      []jrpc.Error{invalidParams},
+	},
+	"get-global-rich-list": {
+		summary:     "The addresses with the largest pUSD value of all their balances",
+		params:      ParamsGetGlobalRichList{},
+		constraints: []string{"count must be >= 0, 100 by default"},
+		results:     []interface{}{[]ResultGlobalRichList{}},
+		errors:      []jrpc.Error{invalidParams},
+	},
+	"get-miner-distribution": {
+		summary: "The rewards of the miners in a range of heights",
+		params:  ParamsGetMiningDominance{},
+		constraints: []string{
+			"stop must be >= start",
+			"stop 0 is the synced height",
+			"start 0 with a negative stop is the last -stop heights",
+		},
+		results: []interface{}{pegnet.MinerDominanceResult{}},
+		errors:  []jrpc.Error{invalidParams},
+	},
+	"get-bank": {
+		summary: "The PEG available for conversions at a height",
+		params:  ParamsGetBank{},
+		constraints: []string{
+			"height is the synced height by default",
+			fmt.Sprintf("height must be >= %d, the activation height of the bank", config.V4OPRUpdate),
+		},
+		results: []interface{}{pegnet.BankEntry{}},
+		errors:  []jrpc.Error{invalidParams},
+	},
+	"get-transactions": {
+		summary: "The transactions of an entry, address, txid, height or window",
+		params:  ParamsGetPegnetTransaction{},
+		constraints: append([]string{
+			"at most one of entryhash, address, txid and height",
+			"one of them, or a height or time window, is required",
+			"height can not be combined with fromheight or toheight",
+			"address is an FA, Fe, FE, or 0x ethereum address",
+			"txid is <index>-<entryhash>",
+			"asset must be a pegnet asset",
+			"offset is the nextoffset of the previous page",
+			"includepending adds the matching pending transactions, which are not paged",
+		}, historyWindow...),
+		results: []interface{}{ResultGetTransactions{}},
+		errors:  []jrpc.Error{ErrorTransactionNotFound, ErrorPendingDisabled, invalidParams},
+	},
+	"get-transaction": {
+		summary:     "A single transaction",
+		params:      ParamsGetPegnetTransaction{},
+		required:    []string{"txid"},
+		constraints: []string{"txid is <index>-<entryhash>", "the other filters are the ones of get-transactions"},
+		results:     []interface{}{ResultGetTransactions{}},
+		errors:      []jrpc.Error{ErrorTransactionNotFound, ErrorPendingDisabled, invalidParams},
+	},
+	"get-transaction-status": {
+		summary:  "The height and execution status of a transaction entry",
+		params:   ParamsGetPegnetTransactionStatus{},
+		required: []string{"entryhash"},
+		constraints: []string{
+			"an entry only in the pending transactions is pending if includepending is set",
+		},
+		results: []interface{}{ResultGetTransactionStatus{}},
+		errors:  []jrpc.Error{ErrorTransactionNotFound, ErrorPendingDisabled, invalidParams},
+	},
+	"export-history": {
+		summary:  "The balance changes of an address, a page at a time",
+		params:   ParamsExportHistory{},
+		required: []string{"address"},
+		constraints: append([]string{
+			"address is an FA, Fe, FE, or 0x ethereum address",
+			"offset is the nextoffset of the previous page",
+		}, historyWindow...),
+		results: []interface{}{ResultExportHistory{}},
+		errors:  []jrpc.Error{invalidParams},
+	},
+	"get-pnl-report": {
+		summary:  "The profit and loss report of an address",
+		params:   ParamsGetPNLReport{},
+		required: []string{"address"},
+		constraints: []string{
+			"address is an FA, Fe, FE, or 0x ethereum address",
+			`method is "fifo" (default) or "avg"`,
+			"currency must be a pegnet asset, pUSD by default",
+			"year must be >= 0, 0 reports all time",
+		},
+		results: []interface{}{ResultGetPNLReport{}},
+		errors:  []jrpc.Error{invalidParams},
+	},
+	"get-pegnet-balances": {
+		summary:  "The balances of an address",
+		params:   ParamsGetPegnetBalances{},
+		required: []string{"address"},
+		constraints: []string{
+			"address is an FA, Fe, FE, or 0x ethereum address",
+			"the balances are returned with the forms of the address if addressforms is set",
+			"the balances are returned with the amounts of the pending transactions if includepending is set",
+		},
+		results: []interface{}{ResultPegnetTickerMap{}, ResultGetPegnetBalances{}},
+		errors:  []jrpc.Error{ErrorAddressNotFound, ErrorPendingDisabled, invalidParams},
+	},
+	"get-pegnet-issuance": {
+		summary: "The supply of every asset",
+		results: []interface{}{ResultGetIssuance{}},
+		errors:  []jrpc.Error{ErrorAddressNotFound},
+	},
+	"get-graded": {
+		summary:     "The graded oracle price records of a height",
+		params:      ParamsGetGraded{},
+		constraints: []string{"height must be >= 0, the synced height by default"},
+		results:     []interface{}{pegnet.GradedResult{}},
+		errors:      []jrpc.Error{invalidParams},
+	},
+	"get-block-summary": {
+		summary:     "What syncing a height did: its winners, transactions, PEG conversions and payouts",
+		params:      ParamsGetBlockSummary{},
+		constraints: []string{"height is the synced height by default"},
+		results:     []interface{}{pegnet.BlockSummary{}},
+		errors:      []jrpc.Error{ErrorNotFound, invalidParams},
+	},
+	"send-transaction": {
+		summary: "Submits a transaction entry, paid by the entry credit address of the node",
+		params:  ParamsSendTransaction{},
+		constraints: []string{
+			"either raw, or content and extids are required",
+			"raw can not be combined with content, extids or chainid",
+			"dryrun returns the entry without submitting it",
+			"idempotencykey is the entry hash by default, and at most 128 characters",
+			"an idempotencykey sent again returns its submission, and fails for a different entry",
+		},
+		results: []interface{}{ResultSendTransaction{}},
+		errors:  []jrpc.Error{ErrorInvalidTransaction, ErrorNoEC, invalidParams, ErrorUnauthorized},
+	},
+	"get-submission-status": {
+		summary:  "The status of a transaction entry submitted by send-transaction",
+		params:   ParamsGetSubmissionStatus{},
+		required: []string{"idempotencykey"},
+		constraints: []string{
+			"the status is queued, committed, revealed, included, executed, rejected or failed",
+		},
+		results: []interface{}{pegnet.Submission{}},
+		errors:  []jrpc.Error{ErrorNotFound, invalidParams},
+	},
+	"get-submissions": {
+		summary: "The transaction entries submitted by send-transaction, newest first",
+		params:  ParamsGetSubmissions{},
+		constraints: []string{
+			"status is queued, committed, revealed, included, executed, rejected or failed",
+			"offset is the nextoffset of the previous page",
+		},
+		results: []interface{}{ResultGetSubmissions{}},
+		errors:  []jrpc.Error{invalidParams, ErrorUnauthorized},
+	},
+	"get-sync-status": {
+		summary: "The synced height of the node and the height of factomd",
+		results: []interface{}{ResultGetSyncStatus{}},
+	},
+	"properties": {
+		summary: "The versions of the node",
+		results: []interface{}{PegnetdProperties{}},
+	},
+	"get-pegnet-rates": {
+		summary:     "The conversion rates of a height",
+		params:      ParamsGetPegnetRates{},
+		constraints: []string{"height is the synced height by default"},
+		results:     []interface{}{ResultPegnetTickerMap{}},
+		errors:      []jrpc.Error{ErrorNotFound, invalidParams},
+	},
+	"get-pegnet-rates-range": {
+		summary:  "The conversion rates of a range of heights, optionally in buckets",
+		params:   ParamsGetPegnetRatesRange{},
+		required: []string{"fromheight", "toheight"},
+		constraints: []string{
+			"toheight must be >= fromheight",
+			"bucket must be <= the number of heights from fromheight to toheight",
+			fmt.Sprintf("at most %d heights or buckets", MaxRatesRangeResults),
+			"assets must be pegnet assets, all by default",
+		},
+		results: []interface{}{ResultGetPegnetRatesRange{}},
+		errors:  []jrpc.Error{ErrorNotFound, invalidParams},
+	},
+	"get-rate-limits": {
+		summary: "The rate limits and the usage of every client",
+		results: []interface{}{ResultGetRateLimits{}},
+		errors:  []jrpc.Error{ErrorUnauthorized},
+	},
+}
+
+// fieldTypes are the types of the interface{} fields of results
+var fieldTypes = map[reflect.Type]map[string]reflect.Type{
+	reflect.TypeOf(ResultGetTransactions{}): {
+		"actions": reflect.TypeOf([]pegnet.HistoryTransaction{}),
+	},
+}
+
+var (
+	openRPCOnce sync.Once
+	openRPCDoc  OpenRPCDocument
+)
+
+// OpenRPC returns the OpenRPC document of the api
+func OpenRPC() OpenRPCDocument {
+	openRPCOnce.Do(func() {
+		openRPCDoc = newOpenRPCDocument()
+	})
+	return openRPCDoc
+}
+
+func newOpenRPCDocument() OpenRPCDocument {
+	g := &schemaGenerator{
+		schemas: make(map[string]*JSONSchema),
+		names:   make(map[reflect.Type]string),
+	}
+	doc := OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info: OpenRPCInfo{
+			Title: "pegnetd",
+			Description: "The JSON-RPC api of pegnetd. Every method may also fail with " +
+				"Unauthorized if it is restricted, with Rate Limited, and with Timeout " +
+				"if it runs past its deadline.",
+			Version: config.CompiledInVersion,
+		},
+		Components: OpenRPCComponents{
+			Schemas: g.schemas,
+			Errors:  make(map[string]OpenRPCError),
+		},
+	}
+	for _, err := range Errors {
+		doc.Components.Errors[strings.Replace(err.Message, " ", "", -1)] = openRPCError(err)
+	}
+
+	var names []string
+	for name := range (&APIServer{}).jrpcMethods() {
+		names = append(names, name)
+	}
+	names = append(names, DiscoverMethod)
+	sort.Strings(names)
+
+	for _, name := range names {
+		method := OpenRPCMethod{Name: name, ParamStructure: "by-name", Params: []OpenRPCContentDescriptor{}}
+		if name == DiscoverMethod {
+			method.Summary = "The OpenRPC document of the api"
+			method.Result = OpenRPCContentDescriptor{Name: "result", Schema: &JSONSchema{Type: "object"}}
+			doc.Methods = append(doc.Methods, method)
+			continue
+		}
+
+		md := methodDocs[name]
+		method.Summary = md.summary
+		if len(md.constraints) > 0 {
+			method.Description = "- " + strings.Join(md.constraints, "\n- ")
+		}
+		if md.params != nil {
+			required := make(map[string]bool)
+			for _, r := range md.required {
+				required[r] = true
+			}
+			for _, f := range jsonFields(reflect.TypeOf(md.params)) {
+				method.Params = append(method.Params, OpenRPCContentDescriptor{
+					Name:     f.name,
+					Required: required[f.name],
+					Schema:   g.schema(f.typ),
+				})
+			}
+		}
+
+		result := &JSONSchema{}
+		if len(md.results) == 1 {
+			result = g.schema(reflect.TypeOf(md.results[0]))
+		} else if len(md.results) > 1 {
+			for _, r := range md.results {
+				result.OneOf = append(result.OneOf, g.schema(reflect.TypeOf(r)))
+			}
+		}
+		method.Result = OpenRPCContentDescriptor{Name: "result", Schema: result}
+
+		for _, err := range md.errors {
+			method.Errors = append(method.Errors, openRPCError(err))
+		}
+		doc.Methods = append(doc.Methods, method)
+	}
+	return doc
+}
+
+func openRPCError(err jrpc.Error) OpenRPCError {
+	return OpenRPCError{Code: err.Code, Message: err.Message, Data: err.Data}
+}
+
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// jsonFields returns the fields of a struct as encoding/json marshals them,
+// with the fields of embedded structs promoted
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if f.Anonymous && tag[0] == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(ft)...)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		name := tag[0]
+		if name == "" {
+			name = f.Name
+		}
+		omitEmpty := false
+		for _, opt := range tag[1:] {
+			omitEmpty = omitEmpty || opt == "omitempty"
+		}
+		fields = append(fields, jsonField{name: name, typ: f.Type, omitEmpty: omitEmpty})
+	}
+	return fields
+}
+
+// schemaGenerator builds the schemas of go types. Named structs are added to
+// the components and referenced.
+type schemaGenerator struct {
+	schemas map[string]*JSONSchema
+	names   map[reflect.Type]string
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+	// Hashes, addresses and tickers marshal to strings, and maps keyed by
+	// ticker to objects keyed by its string
+	if t.Kind() != reflect.Map && (marshals(t, jsonMarshalerType) || marshals(t, textMarshalerType)) {
+		return &JSONSchema{Type: "string"}
+	}
+
+	var zero int64
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &JSONSchema{Ref: "#/components/schemas/" + g.register(t)}
+	}
+	// interface{}, any value
+	return &JSONSchema{}
+}
+
+// marshals returns true if t, or a pointer to it, implements the marshaler
+func marshals(t, marshaler reflect.Type) bool {
+	return t.Implements(marshaler) || reflect.PtrTo(t).Implements(marshaler)
+}
+
+// register adds the schema of a named struct to the components, and returns
+// its name
+func (g *schemaGenerator) register(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + name
+	}
+	g.names[t] = name
+	g.schemas[name] = nil // Recursive types reference the name
+	g.schemas[name] = g.structSchema(t)
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *JSONSchema {
+	s := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+	for _, f := range jsonFields(t) {
+		typ := f.typ
+		if override, ok := fieldTypes[t][f.name]; ok {
+			typ = override
+		}
+		s.Properties[f.name] = g.schema(typ)
+		if !f.omitEmpty {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+	return s
+}
+
+// discoverHandler answers rpc.discover requests with the OpenRPC document, and
+// passes every other request on
+func discoverHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := readBody(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Method string          `json:"method"`
+			ID     json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil || req.Method != DiscoverMethod {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id := req.ID
+		if len(id) == 0 {
+			id = json.RawMessage("null")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      id,
+			"result":  OpenRPC(),
+		})
+	})
+}
@@ -0,0 +1,66 @@
+package srv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOpenRPC_MethodDocs(t *testing.T) {
+	methods := (&APIServer{}).jrpcMethods()
+	for name := range methods {
+		md, ok := methodDocs[name]
+		if !ok {
+			t.Errorf("%s: no methodDocs entry", name)
+			continue
+		}
+		if md.summary == "" {
+			t.Errorf("%s: no summary", name)
+		}
+		if len(md.results) == 0 {
+			t.Errorf("%s: no results", name)
+		}
+	}
+	for name := range methodDocs {
+		if _, ok := methods[name]; !ok {
+			t.Errorf("%s: methodDocs entry of a method that does not exist", name)
+		}
+	}
+
+	documented := make(map[string]OpenRPCMethod)
+	for _, method := range newOpenRPCDocument().Methods {
+		documented[method.Name] = method
+	}
+	for name := range methods {
+		method, ok := documented[name]
+		if !ok {
+			t.Errorf("%s: not in the document", name)
+			continue
+		}
+		md := methodDocs[name]
+		if md.params == nil {
+			if len(method.Params) != 0 {
+				t.Errorf("%s: unexpected params %v", name, method.Params)
+			}
+			continue
+		}
+
+		params := make(map[string]OpenRPCContentDescriptor)
+		for _, p := range method.Params {
+			params[p.Name] = p
+		}
+		fields := jsonFields(reflect.TypeOf(md.params))
+		if len(fields) == 0 || len(fields) != len(method.Params) {
+			t.Errorf("%s: expected the %d params of %T, got %d", name, len(fields), md.params, len(method.Params))
+		}
+		for _, f := range fields {
+			if p, ok := params[f.name]; !ok || p.Schema == nil {
+				t.Errorf("%s: param %s of %T is missing", name, f.name, md.params)
+			}
+		}
+		for _, r := range md.required {
+			if !params[r].Required {
+				t.Errorf("%s: required param %s is not a required param of %T", name, r, md.params)
+			}
+		}
+	}
+}
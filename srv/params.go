@@ -30,6 +30,7 @@ import (
 	"github.com/Factom-Asset-Tokens/factom"
 	"github.com/pegnet/pegnetd/fat/fat2"
 	"github.com/pegnet/pegnetd/node/pegnet"
+	"github.com/pegnet/pegnetd/node/pnl"
 )
 
 type Params interface {
@@ -68,8 +69,11 @@ func (p ParamsGetMiningDominance) ValidChainID() *factom.Bytes32 {
 	return nil
 }
 
+// ParamsGetGlobalRichList are the parameters of the global rich list.
+// `addressforms` adds the equivalent forms of each address to the result.
 type ParamsGetGlobalRichList struct {
-	Count int `json:"count,omitempty"`
+	Count        int  `json:"count,omitempty"`
+	AddressForms bool `json:"addressforms,omitempty"`
 }
 
 func (p ParamsGetGlobalRichList) HasIncludePending() bool { return false }
@@ -83,9 +87,12 @@ func (p ParamsGetGlobalRichList) ValidChainID() *factom.Bytes32 {
 	return nil
 }
 
+// ParamsGetRichList are the parameters of the rich list of an asset.
+// `addressforms` adds the equivalent forms of each address to the result.
 type ParamsGetRichList struct {
-	Asset string `json:"asset,omitempty"`
-	Count int    `json:"count,omitempty"`
+	Asset        string `json:"asset,omitempty"`
+	Count        int    `json:"count,omitempty"`
+	AddressForms bool   `json:"addressforms,omitempty"`
 }
 
 func (p ParamsGetRichList) HasIncludePending() bool { return false }
@@ -159,11 +166,61 @@ func (ParamsGetPegnetRates) ValidChainID() *factom.Bytes32 {
 	return nil
 }
 
+// MaxRatesRangeResults is the maximum number of heights or buckets returned
+// by a single get-pegnet-rates-range request
+const MaxRatesRangeResults = 10000
+
+// ParamsGetPegnetRatesRange are the parameters for retrieving the rates of the
+// heights [fromheight, toheight]. `assets` limits the response to the given
+// assets, all assets are returned if empty. If `bucket` is set, the rates are
+// aggregated into buckets of that many blocks, starting at fromheight.
+type ParamsGetPegnetRatesRange struct {
+	FromHeight uint32   `json:"fromheight"`
+	ToHeight   uint32   `json:"toheight"`
+	Assets     []string `json:"assets,omitempty"`
+	Bucket     uint32   `json:"bucket,omitempty"`
+}
+
+func (ParamsGetPegnetRatesRange) HasIncludePending() bool { return false }
+
+func (p ParamsGetPegnetRatesRange) IsValid() error {
+	if p.FromHeight == 0 || p.ToHeight == 0 {
+		return jrpc.ErrorInvalidParams(`required: "fromheight" and "toheight"`)
+	}
+	if p.FromHeight > p.ToHeight {
+		return jrpc.ErrorInvalidParams(`"toheight" must be >= "fromheight"`)
+	}
+	// In uint64, the range and the buckets can be as large as a uint32
+	results := uint64(p.ToHeight) - uint64(p.FromHeight) + 1
+	if uint64(p.Bucket) > results {
+		return jrpc.ErrorInvalidParams(`"bucket" must be <= the number of heights from "fromheight" to "toheight"`)
+	}
+	if p.Bucket > 0 {
+		results = (results + uint64(p.Bucket) - 1) / uint64(p.Bucket)
+	}
+	if results > MaxRatesRangeResults {
+		return jrpc.ErrorInvalidParams(fmt.Sprintf("range too large, at most %d heights or buckets can be returned", MaxRatesRangeResults))
+	}
+	for _, asset := range p.Assets {
+		if fat2.StringToTicker(asset) == fat2.PTickerInvalid {
+			return jrpc.ErrorInvalidParams(fmt.Sprintf("invalid asset %s", asset))
+		}
+	}
+	return nil
+}
+func (ParamsGetPegnetRatesRange) ValidChainID() *factom.Bytes32 {
+	return nil
+}
+
+// ParamsGetPegnetTransactionStatus are the parameters of the status of a
+// transaction batch. `includepending` also looks for it in the pending
+// transactions.
 type ParamsGetPegnetTransactionStatus struct {
-	Hash *factom.Bytes32 `json:"entryhash,omitempty"`
+	Hash           *factom.Bytes32 `json:"entryhash,omitempty"`
+	IncludePending bool            `json:"includepending,omitempty"`
 }
 
-func (p ParamsGetPegnetTransactionStatus) HasIncludePending() bool { return false }
+func (p ParamsGetPegnetTransactionStatus) HasIncludePending() bool { return p.IncludePending }
 func (p ParamsGetPegnetTransactionStatus) IsValid() error {
 	if p.Hash == nil {
 		return jrpc.ErrorInvalidParams(`required: "entryhash"`)
@@ -176,9 +233,15 @@ func (p ParamsGetPegnetTransactionStatus) ValidChainID() *factom.Bytes32 {
 
 // ParamsGetPegnetTransaction are the parameters for retrieving transactions from
 // the history system.
-// You need to specify exactly one of either `hash`, `address`, or `height`.
+// You need to specify at most one of either `hash`, `address`, `txid`, or `height`.
+// If none of them are given, a height or time window is required.
 // `offset` is the value from a previous query's `nextoffset`.
 // `desc` returns transactions in newest->oldest order
+// `fromheight` and `toheight` are inclusive block heights.
+// `fromtime` (inclusive) and `totime` (exclusive) are RFC3339 timestamps.
+// `address` can be an FA, Fe, FE, or 0x ethereum address.
+// `addressforms` adds the equivalent forms of every address in the result.
+// `includepending` adds the matching pending transactions, separately.
 type ParamsGetPegnetTransaction struct {
 	Hash       string `json:"entryhash,omitempty"`
 	Address    string `json:"address,omitempty"`
@@ -191,13 +254,21 @@ type ParamsGetPegnetTransaction struct {
 	Burn       bool   `json:"burn,omitempty"`
 	Asset      string `json:"asset,omitempty"`
 
+	FromHeight int    `json:"fromheight,omitempty"`
+	ToHeight   int    `json:"toheight,omitempty"`
+	FromTime   string `json:"fromtime,omitempty"`
+	ToTime     string `json:"totime,omitempty"`
+
+	AddressForms   bool `json:"addressforms,omitempty"`
+	IncludePending bool `json:"includepending,omitempty"`
+
 	// TxID is in the format #-[Entryhash], where '#' == tx index
 	TxID string `json:"txid,omitempty"`
 	// Used by the server to store the entryhash in the txid
 	txEntryHash string
 }
 
-func (p ParamsGetPegnetTransaction) HasIncludePending() bool { return false }
+func (p ParamsGetPegnetTransaction) HasIncludePending() bool { return p.IncludePending }
 func (p ParamsGetPegnetTransaction) IsValid() error {
 	if p.Offset < 0 {
 		return jrpc.ErrorInvalidParams(`offset must be >= 0`)
@@ -216,13 +287,20 @@ func (p ParamsGetPegnetTransaction) IsValid() error {
 	if p.Height > 0 {
 		count++
 	}
-	if count != 1 {
-		if count == 0 {
-			return jrpc.ErrorInvalidParams(`need to specify either "entryhash" or "address", "txid", or "height"`)
-		}
+	if count > 1 {
 		return jrpc.ErrorInvalidParams(`cannot specify more than one of "entryhash", "address", "txid", or "height"`)
 	}
 
+	if err := p.validRange(); err != nil {
+		return err
+	}
+	if count == 0 && !p.hasRange() {
+		return jrpc.ErrorInvalidParams(`need to specify either "entryhash" or "address", "txid", "height", or a height or time window`)
+	}
+	if p.Height > 0 && (p.FromHeight > 0 || p.ToHeight > 0) {
+		return jrpc.ErrorInvalidParams(`cannot combine "height" with "fromheight" or "toheight"`)
+	}
+
 	if p.Asset != "" {
 		ticker := fat2.StringToTicker(p.Asset)
 		if ticker == fat2.PTickerInvalid {
@@ -232,7 +310,7 @@ func (p ParamsGetPegnetTransaction) IsValid() error {
 
 	// error check input
 	if p.Address != "" {
-		if _, err := underlyingFA(p.Address); err != nil {
+		if err := validAddress(p.Address); err != nil {
 			return jrpc.ErrorInvalidParams("address: " + err.Error())
 		}
 	}
@@ -255,17 +333,129 @@ func (p ParamsGetPegnetTransaction) ValidChainID() *factom.Bytes32 {
 	return nil
 }
 
+func (p ParamsGetPegnetTransaction) hasRange() bool {
+	return p.FromHeight > 0 || p.ToHeight > 0 || p.FromTime != "" || p.ToTime != ""
+}
+
+func (p ParamsGetPegnetTransaction) validRange() error {
+	if p.FromHeight < 0 || p.ToHeight < 0 {
+		return jrpc.ErrorInvalidParams(`"fromheight" and "toheight" must be >= 0`)
+	}
+	if p.ToHeight > 0 && p.FromHeight > p.ToHeight {
+		return jrpc.ErrorInvalidParams(`"toheight" must be >= "fromheight"`)
+	}
+	from, to, err := p.timeRange()
+	if err != nil {
+		return jrpc.ErrorInvalidParams(err.Error())
+	}
+	if !to.IsZero() && !from.Before(to) {
+		return jrpc.ErrorInvalidParams(`"totime" must be after "fromtime"`)
+	}
+	return nil
+}
+
+// timeRange parses the RFC3339 time window. Unset times are returned as the
+// zero time.
+func (p ParamsGetPegnetTransaction) timeRange() (from, to time.Time, err error) {
+	if p.FromTime != "" {
+		if from, err = time.Parse(time.RFC3339, p.FromTime); err != nil {
+			return from, to, fmt.Errorf("fromtime: %s", err.Error())
+		}
+	}
+	if p.ToTime != "" {
+		if to, err = time.Parse(time.RFC3339, p.ToTime); err != nil {
+			return from, to, fmt.Errorf("totime: %s", err.Error())
+		}
+	}
+	return from, to, nil
+}
+
+// ParamsExportHistory are the parameters for exporting the balance history of
+// an address. The window fields are the same as in ParamsGetPegnetTransaction.
+// `offset` is the value from a previous query's `nextoffset`.
+type ParamsExportHistory struct {
+	Address    string `json:"address"`
+	Offset     int    `json:"offset,omitempty"`
+	FromHeight int    `json:"fromheight,omitempty"`
+	ToHeight   int    `json:"toheight,omitempty"`
+	FromTime   string `json:"fromtime,omitempty"`
+	ToTime     string `json:"totime,omitempty"`
+}
+
+func (p ParamsExportHistory) HasIncludePending() bool { return false }
+func (p ParamsExportHistory) IsValid() error {
+	if p.Address == "" {
+		return jrpc.ErrorInvalidParams(`required: "address"`)
+	}
+	return p.transactionParams().IsValid()
+}
+func (p ParamsExportHistory) ValidChainID() *factom.Bytes32 {
+	return nil
+}
+
+// transactionParams returns the equivalent history query
+func (p ParamsExportHistory) transactionParams() ParamsGetPegnetTransaction {
+	return ParamsGetPegnetTransaction{
+		Address:    p.Address,
+		Offset:     p.Offset,
+		FromHeight: p.FromHeight,
+		ToHeight:   p.ToHeight,
+		FromTime:   p.FromTime,
+		ToTime:     p.ToTime,
+	}
+}
+
+// ParamsGetPNLReport are the parameters for the profit and loss report of an
+// address. `method` is either "fifo" (default) or "avg". `currency` is the asset
+// the report is valued in, pUSD by default. `year` limits the realized gains to
+// disposals in that (UTC) year, 0 reports all time.
+type ParamsGetPNLReport struct {
+	Address  string `json:"address"`
+	Method   string `json:"method,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Year     int    `json:"year,omitempty"`
+}
+
+func (p ParamsGetPNLReport) HasIncludePending() bool { return false }
+func (p ParamsGetPNLReport) IsValid() error {
+	if p.Address == "" {
+		return jrpc.ErrorInvalidParams(`required: "address"`)
+	}
+	if err := validAddress(p.Address); err != nil {
+		return jrpc.ErrorInvalidParams("address: " + err.Error())
+	}
+	if p.Method != "" && p.Method != string(pnl.FIFO) && p.Method != string(pnl.Average) {
+		return jrpc.ErrorInvalidParams(`method must be "fifo" or "avg"`)
+	}
+	if p.Currency != "" && fat2.StringToTicker(p.Currency) == fat2.PTickerInvalid {
+		return jrpc.ErrorInvalidParams("invalid currency")
+	}
+	if p.Year < 0 {
+		return jrpc.ErrorInvalidParams("year must be >= 0")
+	}
+	return nil
+}
+func (p ParamsGetPNLReport) ValidChainID() *factom.Bytes32 {
+	return nil
+}
+
+// ParamsGetPegnetBalances are the parameters of the balances of an FA, Fe,
+// FE, or 0x ethereum address. `addressforms` returns the balances along with
+// the equivalent forms of the address. `includepending` returns them along
+// with the amounts of the pending transactions.
 type ParamsGetPegnetBalances struct {
-	Address string `json:"address,omitempty"`
+	Address        string `json:"address,omitempty"`
+	AddressForms   bool   `json:"addressforms,omitempty"`
+	IncludePending bool   `json:"includepending,omitempty"`
 }
 
-func (p ParamsGetPegnetBalances) HasIncludePending() bool { return false }
+func (p ParamsGetPegnetBalances) HasIncludePending() bool { return p.IncludePending }
 
 func (p ParamsGetPegnetBalances) IsValid() error {
 	if p.Address == "" {
 		return jrpc.ErrorInvalidParams(`required: "address"`)
 	}
-	if _, err := underlyingFA(p.Address); err != nil {
+	if err := validAddress(p.Address); err != nil {
 		return jrpc.ErrorInvalidParams("address: " + err.Error())
 	}
 	return nil
@@ -289,16 +479,38 @@ func (p ParamsGetGraded) ValidChainID() *factom.Bytes32 {
 	return nil
 }
 
+type ParamsGetBlockSummary struct {
+	Height uint32 `json:"height,omitempty"`
+}
+
+func (ParamsGetBlockSummary) HasIncludePending() bool { return false }
+func (ParamsGetBlockSummary) IsValid() error {
+	return nil
+}
+func (ParamsGetBlockSummary) ValidChainID() *factom.Bytes32 {
+	return nil
+}
+
+// MaxIdempotencyKeyLength is the longest idempotency key of a submission
+const MaxIdempotencyKeyLength = 128
+
+// ParamsSendTransaction are the parameters of a transaction entry.
+// `idempotencykey` identifies the submission, sending it again with the same
+// key returns the submission instead. It is the entry hash by default.
 type ParamsSendTransaction struct {
 	ParamsToken
 	ExtIDs  []factom.Bytes `json:"extids,omitempty"`
 	Content factom.Bytes   `json:"content,omitempty"`
 	Raw     factom.Bytes   `json:"raw,omitempty"`
 	DryRun  bool           `json:"dryrun,omitempty"`
+	Key     string         `json:"idempotencykey,omitempty"`
 	entry   factom.Entry
 }
 
 func (p *ParamsSendTransaction) IsValid() error {
+	if len(p.Key) > MaxIdempotencyKeyLength {
+		return jrpc.ErrorInvalidParams(fmt.Sprintf("idempotencykey must be at most %d characters", MaxIdempotencyKeyLength))
+	}
 	if p.Raw != nil {
 		if p.ExtIDs != nil || p.Content != nil || p.ParamsToken != (ParamsToken{}) {
 			return jrpc.ErrorInvalidParams(
@@ -333,3 +545,47 @@ func (p *ParamsSendTransaction) IsValid() error {
 func (p ParamsSendTransaction) Entry() factom.Entry {
 	return p.entry
 }
+
+// ParamsGetSubmissionStatus are the parameters of the status of a submission of
+// send-transaction, by its idempotency key
+type ParamsGetSubmissionStatus struct {
+	Key string `json:"idempotencykey,omitempty"`
+}
+
+func (ParamsGetSubmissionStatus) HasIncludePending() bool { return false }
+func (p ParamsGetSubmissionStatus) IsValid() error {
+	if p.Key == "" {
+		return jrpc.ErrorInvalidParams(`required: "idempotencykey"`)
+	}
+	return nil
+}
+func (ParamsGetSubmissionStatus) ValidChainID() *factom.Bytes32 {
+	return nil
+}
+
+// ParamsGetSubmissions are the parameters of the submissions of
+// send-transaction, newest first. `status` only returns the ones with the
+// status. `offset` is the value from a previous query's `nextoffset`.
+type ParamsGetSubmissions struct {
+	Status string `json:"status,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+func (ParamsGetSubmissions) HasIncludePending() bool { return false }
+func (p ParamsGetSubmissions) IsValid() error {
+	if p.Offset < 0 {
+		return jrpc.ErrorInvalidParams(`offset must be >= 0`)
+	}
+	if p.Status == "" {
+		return nil
+	}
+	for _, status := range pegnet.SubmissionStatuses {
+		if p.Status == status {
+			return nil
+		}
+	}
+	return jrpc.ErrorInvalidParams(fmt.Sprintf("invalid status %s", p.Status))
+}
+func (ParamsGetSubmissions) ValidChainID() *factom.Bytes32 {
+	return nil
+}
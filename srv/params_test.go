@@ -0,0 +1,28 @@
+package srv
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParamsGetPegnetRatesRange_IsValid(t *testing.T) {
+	tests := []struct {
+		name   string
+		params ParamsGetPegnetRatesRange
+		valid  bool
+	}{
+		{"heights", ParamsGetPegnetRatesRange{FromHeight: 1, ToHeight: MaxRatesRangeResults}, true},
+		{"too many heights", ParamsGetPegnetRatesRange{FromHeight: 1, ToHeight: MaxRatesRangeResults + 1}, false},
+		{"buckets", ParamsGetPegnetRatesRange{FromHeight: 1, ToHeight: math.MaxUint32, Bucket: math.MaxUint32 / 2}, true},
+		{"too many buckets", ParamsGetPegnetRatesRange{FromHeight: 1, ToHeight: math.MaxUint32, Bucket: 2}, false},
+		{"a single bucket of every height", ParamsGetPegnetRatesRange{FromHeight: 1, ToHeight: math.MaxUint32, Bucket: math.MaxUint32}, true},
+		{"a bucket larger than the range", ParamsGetPegnetRatesRange{FromHeight: 2, ToHeight: math.MaxUint32, Bucket: math.MaxUint32}, false},
+		{"to before from", ParamsGetPegnetRatesRange{FromHeight: 2, ToHeight: 1}, false},
+		{"an invalid asset", ParamsGetPegnetRatesRange{FromHeight: 1, ToHeight: 1, Assets: []string{"pFOO"}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.params.IsValid(); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid %v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
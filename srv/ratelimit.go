@@ -0,0 +1,333 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pegnet/pegnetd/config"
+	"github.com/spf13/viper"
+)
+
+// DefaultMethodWeights are the costs of the methods that are more expensive
+// than a single token. get-global-rich-list scans every address.
+var DefaultMethodWeights = map[string]int{
+	"get-global-rich-list":   50,
+	"get-rich-list":          10,
+	"get-miner-distribution": 10,
+	"get-pegnet-issuance":    5,
+	"export-history":         20,
+	"get-pnl-report":         20,
+	"get-pegnet-rates-range": 5,
+	"send-transaction":       5,
+}
+
+var rateLimitMetrics = expvar.NewMap("ratelimit")
+
+// rateLimiter is a token bucket per client. Clients are the authenticated
+// callers, or the IP address of callers that are not authenticated. Every
+// method costs its weight in tokens, and the buckets refill at a steady rate.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	// Limits of the IP addresses, and of the authenticated callers
+	rate, burst       float64
+	keyRate, keyBurst float64
+	weights           map[string]int
+	trustProxy        bool
+
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	requests uint64
+	rejected uint64
+}
+
+func newRateLimiter(conf *viper.Viper) *rateLimiter {
+	l := &rateLimiter{
+		buckets:    make(map[string]*bucket),
+		rate:       conf.GetFloat64(config.APIRateLimitRate),
+		burst:      conf.GetFloat64(config.APIRateLimitBurst),
+		keyRate:    conf.GetFloat64(config.APIRateLimitKeyRate),
+		keyBurst:   conf.GetFloat64(config.APIRateLimitKeyBurst),
+		trustProxy: conf.GetBool(config.APIRateLimitTrustProxy),
+		weights:    make(map[string]int),
+		now:        time.Now,
+	}
+	for method, weight := range DefaultMethodWeights {
+		l.weights[method] = weight
+	}
+	for method, weight := range conf.GetStringMap(config.APIRateLimitWeights) {
+		if w, ok := intValue(weight); ok && w >= 0 {
+			l.weights[method] = w
+		}
+	}
+	if l.burst < l.rate {
+		l.burst = l.rate
+	}
+	if l.keyBurst < l.keyRate {
+		l.keyBurst = l.keyRate
+	}
+	return l
+}
+
+// intValue returns the int of a config value, which is an int64 in toml, and
+// a float64 in json
+func intValue(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func (l *rateLimiter) enabled() bool {
+	return l.rate > 0 || l.keyRate > 0
+}
+
+func (l *rateLimiter) weight(method string) int {
+	if w, ok := l.weights[method]; ok {
+		return w
+	}
+	return 1
+}
+
+// limits returns the rate and burst of a client, with a zero rate if it is
+// not limited
+func (l *rateLimiter) limits(client string) (float64, float64) {
+	if strings.HasPrefix(client, "ip:") {
+		return l.rate, l.burst
+	}
+	return l.keyRate, l.keyBurst
+}
+
+// cost returns the tokens a request of the methods costs. Every request whose
+// method can not be read costs a single token.
+func (l *rateLimiter) cost(methods []string, unreadable int) float64 {
+	cost := float64(unreadable)
+	for _, method := range methods {
+		cost += float64(l.weight(method))
+	}
+	return cost
+}
+
+// take takes the cost from the bucket of the client. If there are not enough
+// tokens, nothing is taken, and the time until there will be is returned. A
+// request that costs more than the burst is never allowed, and the returned
+// time is < 0.
+func (l *rateLimiter) take(client string, cost float64) (bool, time.Duration) {
+	rate, burst := l.limits(client)
+	if rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b, ok := l.buckets[client]
+	if !ok {
+		l.prune(now)
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	b.requests++
+	if cost > burst {
+		b.rejected++
+		return false, -1
+	}
+	if b.tokens >= cost {
+		b.tokens -= cost
+		return true, 0
+	}
+	b.rejected++
+	wait := time.Duration((cost - b.tokens) / rate * float64(time.Second))
+	return false, wait
+}
+
+// prune drops the buckets of clients that have been idle long enough for the
+// bucket to be full again. Must be called with the lock held.
+func (l *rateLimiter) prune(now time.Time) {
+	if len(l.buckets) < 1024 {
+		return
+	}
+	for client, b := range l.buckets {
+		rate, burst := l.limits(client)
+		if rate <= 0 || now.Sub(b.last).Seconds()*rate >= burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// client returns the authenticated caller, or the IP address of the request
+func (l *rateLimiter) client(r *http.Request) string {
+	if c := caller(r); c != "" {
+		return c
+	}
+	if l.trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return "ip:" + strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+var (
+	knownMethodsOnce sync.Once
+	knownMethods     map[string]bool
+)
+
+// metricMethod returns the name a method is counted under in the metrics. The
+// methods come from the request body, so every name that is not a method of
+// the api is counted as unknown, to keep the number of metrics bounded.
+func metricMethod(method string) string {
+	knownMethodsOnce.Do(func() {
+		knownMethods = map[string]bool{EventsMethod: true, DiscoverMethod: true}
+		for name := range (&APIServer{}).jrpcMethods() {
+			knownMethods[name] = true
+		}
+	})
+	if knownMethods[method] {
+		return method
+	}
+	return "unknown"
+}
+
+// Handler rejects requests of clients that ran out of tokens
+func (l *rateLimiter) Handler(next http.Handler) http.Handler {
+	if !l.enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods, unreadable, err := methodsOf(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(methods) == 0 && unreadable == 0 {
+			unreadable = 1 // Empty batches still cost a token
+		}
+
+		cost := l.cost(methods, unreadable)
+		ok, wait := l.take(l.client(r), cost)
+		if ok {
+			rateLimitMetrics.Add("allowed", 1)
+			next.ServeHTTP(w, r)
+			return
+		}
+		rateLimitMetrics.Add("rejected", 1)
+		for _, method := range methods {
+			rateLimitMetrics.Add("rejected."+metricMethod(method), 1)
+		}
+		if unreadable > 0 {
+			rateLimitMetrics.Add("rejected.unknown", int64(unreadable))
+		}
+
+		if wait < 0 {
+			// Waiting does not help, the batch has to be split
+			_, burst := l.limits(l.client(r))
+			writeError(w, http.StatusTooManyRequests, ErrorRateLimited, ResultRateLimited{Cost: cost, Burst: burst})
+			return
+		}
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		writeError(w, http.StatusTooManyRequests, ErrorRateLimited, ResultRateLimited{RetryAfter: retryAfter})
+	})
+}
+
+// ResultRateLimited is the data of the ErrorRateLimited error
+type ResultRateLimited struct {
+	// Seconds until the request would be allowed
+	RetryAfter int `json:"retryafter"`
+	// The cost and the burst of a request that costs more than the burst of
+	// the client, which is never allowed
+	Cost  float64 `json:"cost,omitempty"`
+	Burst float64 `json:"burst,omitempty"`
+}
+
+// ResultGetRateLimits is the configuration and current usage of the rate
+// limits
+type ResultGetRateLimits struct {
+	Enabled  bool           `json:"enabled"`
+	Rate     float64        `json:"rate"`
+	Burst    float64        `json:"burst"`
+	KeyRate  float64        `json:"keyrate"`
+	KeyBurst float64        `json:"keyburst"`
+	Weights  map[string]int `json:"weights"`
+	Clients  []RateLimitUse `json:"clients"`
+}
+
+// RateLimitUse is the usage of a single client
+type RateLimitUse struct {
+	Client   string    `json:"client"`
+	Tokens   float64   `json:"tokens"`
+	Burst    float64   `json:"burst"`
+	Requests uint64    `json:"requests"`
+	Rejected uint64    `json:"rejected"`
+	LastSeen time.Time `json:"lastseen"`
+}
+
+// usage returns the usage of the clients, the ones with the most rejected
+// requests first
+func (l *rateLimiter) usage() ResultGetRateLimits {
+	res := ResultGetRateLimits{
+		Enabled:  l.enabled(),
+		Rate:     l.rate,
+		Burst:    l.burst,
+		KeyRate:  l.keyRate,
+		KeyBurst: l.keyBurst,
+		Weights:  l.weights,
+		Clients:  []RateLimitUse{},
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	for client, b := range l.buckets {
+		rate, burst := l.limits(client)
+		res.Clients = append(res.Clients, RateLimitUse{
+			Client:   client,
+			Tokens:   math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate),
+			Burst:    burst,
+			Requests: b.requests,
+			Rejected: b.rejected,
+			LastSeen: b.last,
+		})
+	}
+	l.mu.Unlock()
+
+	sort.Slice(res.Clients, func(i, j int) bool {
+		if res.Clients[i].Rejected != res.Clients[j].Rejected {
+			return res.Clients[i].Rejected > res.Clients[j].Rejected
+		}
+		return res.Clients[i].Client < res.Clients[j].Client
+	})
+	return res
+}
+
+func (s *APIServer) getRateLimits(_ context.Context, _ json.RawMessage) interface{} {
+	return s.limiter.usage()
+}
@@ -0,0 +1,100 @@
+package srv
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pegnet/pegnetd/config"
+	"github.com/spf13/viper"
+)
+
+func TestRateLimiter(t *testing.T) {
+	conf := viper.New()
+	conf.Set(config.APIRateLimitRate, 1)
+	conf.Set(config.APIRateLimitBurst, 60)
+	l := newRateLimiter(conf)
+	now := time.Unix(1589276400, 0)
+	l.now = func() time.Time { return now }
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := l.Handler(ok)
+
+	batch := func(methods ...string) string {
+		var reqs []string
+		for _, method := range methods {
+			reqs = append(reqs, `{"jsonrpc":"2.0","id":1,"method":"`+method+`"}`)
+		}
+		return "[" + strings.Join(reqs, ",") + "]"
+	}
+	rich := make([]string, 20)
+	for i := range rich {
+		rich[i] = "get-global-rich-list"
+	}
+	// The handler still serves the valid requests of a batch with an
+	// invalid one
+	invalid := strings.TrimSuffix(batch(rich...), "]") + ",1]"
+
+	tests := []struct {
+		name   string
+		body   string
+		wait   time.Duration
+		status int
+		data   ResultRateLimited
+	}{
+		{"a single method", batch("get-sync-status"), 0, http.StatusOK, ResultRateLimited{}},
+		{"within the burst", batch("get-global-rich-list"), 0, http.StatusOK, ResultRateLimited{}},
+		{"out of tokens", batch("get-global-rich-list"), 0, http.StatusTooManyRequests, ResultRateLimited{RetryAfter: 41}},
+		{"refilled", batch("get-global-rich-list"), 41 * time.Second, http.StatusOK, ResultRateLimited{}},
+		{"a batch over the burst", batch(rich...), time.Hour, http.StatusTooManyRequests, ResultRateLimited{Cost: 1000, Burst: 60}},
+		{"the bucket is not drained by it", batch("get-global-rich-list"), 0, http.StatusOK, ResultRateLimited{}},
+		{"a batch with an invalid request", invalid, 0, http.StatusTooManyRequests, ResultRateLimited{Cost: 1001, Burst: 60}},
+		{"a single invalid request", `[1,2]`, 0, http.StatusOK, ResultRateLimited{}},
+		{"invalid requests cost a token each", `not json`, 0, http.StatusOK, ResultRateLimited{}},
+		{"out of tokens for invalid requests", batch("get-global-rich-list"), 0, http.StatusTooManyRequests, ResultRateLimited{RetryAfter: 43}},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.wait)
+		r := httptest.NewRequest(http.MethodPost, "/v1", strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, w.Code)
+			continue
+		}
+		if w.Code == http.StatusOK {
+			continue
+		}
+		var res struct {
+			Error struct {
+				Code int               `json:"code"`
+				Data ResultRateLimited `json:"data"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Error.Code != int(ErrorRateLimited.Code) || res.Error.Data != tt.data {
+			t.Errorf("%s: unexpected error %+v", tt.name, res.Error)
+		}
+		if retryAfter := w.Header().Get("Retry-After"); (retryAfter != "") != (tt.data.RetryAfter > 0) {
+			t.Errorf("%s: unexpected Retry-After %q", tt.name, retryAfter)
+		}
+	}
+}
+
+func TestMetricMethod(t *testing.T) {
+	for method, expected := range map[string]string{
+		"get-sync-status": "get-sync-status",
+		EventsMethod:      EventsMethod,
+		"":                "unknown",
+		"no-such-method":  "unknown",
+	} {
+		if got := metricMethod(method); got != expected {
+			t.Errorf("%q: expected %q, got %q", method, expected, got)
+		}
+	}
+}
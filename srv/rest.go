@@ -0,0 +1,315 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
+	log "github.com/sirupsen/logrus"
+)
+
+// RESTPrefix is the path of the REST gateway
+const RESTPrefix = "/api/v1/"
+
+// restMaxAge is how long responses about the synced height may be cached.
+// Responses about an explicit height below the synced height do not change.
+const restMaxAge = 60
+
+// restRoute maps a REST path to the JSON-RPC method that serves it. Path
+// segments in braces, and the query string, are the params of the method.
+type restRoute struct {
+	path   string
+	method string
+	// params is the params type of the method, nil if it takes none
+	params interface{}
+}
+
+var restRoutes = []restRoute{
+	{"status", "get-sync-status", nil},
+	{"balances/{address}", "get-pegnet-balances", ParamsGetPegnetBalances{}},
+	{"rates", "get-pegnet-rates", ParamsGetPegnetRates{}},
+	{"rates/{height}", "get-pegnet-rates", ParamsGetPegnetRates{}},
+	{"transactions", "get-transactions", ParamsGetPegnetTransaction{}},
+	{"tx/{txid}", "get-transaction", ParamsGetPegnetTransaction{}},
+	{"issuance", "get-pegnet-issuance", nil},
+	{"bank", "get-bank", ParamsGetBank{}},
+	{"bank/{height}", "get-bank", ParamsGetBank{}},
+	{"richlist", "get-global-rich-list", ParamsGetGlobalRichList{}},
+	{"richlist/{asset}", "get-rich-list", ParamsGetRichList{}},
+	{"graded/{height}", "get-graded", ParamsGetGraded{}},
+	{"blocks", "get-block-summary", ParamsGetBlockSummary{}},
+	{"blocks/{height}", "get-block-summary", ParamsGetBlockSummary{}},
+	{"submissions", "get-submissions", ParamsGetSubmissions{}},
+	{"submissions/{idempotencykey}", "get-submission-status", ParamsGetSubmissionStatus{}},
+}
+
+// restVolatile are the methods whose responses change before the next block
+// is synced, so they are not cached
+var restVolatile = map[string]bool{
+	"get-submission-status": true,
+	"get-submissions":       true,
+}
+
+// volatile is true if the response changes before the next block is synced,
+// which is also the case for the ones with the pending transactions
+func (route restRoute) volatile(query url.Values) bool {
+	if restVolatile[route.method] {
+		return true
+	}
+	if values, ok := query["includepending"]; ok {
+		pending, _ := parseRESTValue(reflect.Bool, values[0]) // checked by restParams
+		return pending == true
+	}
+	return false
+}
+
+// restAliases are query parameters that are named differently in the REST
+// gateway
+var restAliases = map[string]string{
+	"cursor": "offset",
+}
+
+// match returns the values of the path segments in braces if the path matches
+// the route
+func (route restRoute) match(path string) (map[string]string, bool) {
+	want := strings.Split(route.path, "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+	vars := make(map[string]string)
+	for i := range want {
+		if strings.HasPrefix(want[i], "{") {
+			if got[i] == "" {
+				return nil, false
+			}
+			vars[strings.Trim(want[i], "{}")] = got[i]
+		} else if want[i] != got[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+func matchRESTRoute(path string) (*restRoute, map[string]string) {
+	path = strings.TrimPrefix(path, RESTPrefix)
+	for i := range restRoutes {
+		if vars, ok := restRoutes[i].match(path); ok {
+			return &restRoutes[i], vars
+		}
+	}
+	return nil, nil
+}
+
+// restParams builds the JSON params of the method from the path and query
+// values. Values are converted to the type of the field with the same json
+// name, so the method validates them exactly like a JSON-RPC request.
+func (route restRoute) restParams(vars map[string]string, query map[string][]string) (json.RawMessage, error) {
+	params := make(map[string]interface{})
+	set := func(name, value string) error {
+		field := name
+		if alias, ok := restAliases[name]; ok {
+			field = alias
+		}
+		if route.params == nil {
+			return fmt.Errorf("%s takes no parameters", route.method)
+		}
+		kind, ok := jsonFieldKind(reflect.TypeOf(route.params), field)
+		if !ok {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+		v, err := parseRESTValue(kind, value)
+		if err != nil {
+			return fmt.Errorf("parameter %q: %v", name, err)
+		}
+		params[field] = v
+		return nil
+	}
+
+	for name, values := range query {
+		if len(values) > 1 {
+			return nil, fmt.Errorf("parameter %q is given more than once", name)
+		}
+		if err := set(name, values[0]); err != nil {
+			return nil, err
+		}
+	}
+	// Path values take precedence over the query
+	for name, value := range vars {
+		if err := set(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(params) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(params)
+}
+
+// jsonFieldKind returns the kind of the field of the struct with the json
+// name, including the fields of embedded structs
+func jsonFieldKind(t reflect.Type, name string) (reflect.Kind, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if kind, ok := jsonFieldKind(field.Type, name); ok {
+				return kind, true
+			}
+			continue
+		}
+		if strings.Split(field.Tag.Get("json"), ",")[0] == name {
+			return field.Type.Kind(), true
+		}
+	}
+	return reflect.Invalid, false
+}
+
+func parseRESTValue(kind reflect.Kind, value string) (interface{}, error) {
+	switch kind {
+	case reflect.Bool:
+		// A flag without a value is set, eg "?desc"
+		if value == "" {
+			return true, nil
+		}
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(value, 10, 64)
+	}
+	// Strings, and the types that unmarshal from a json string
+	return value, nil
+}
+
+type restMatchKey struct{}
+
+type restMatch struct {
+	route *restRoute
+	vars  map[string]string
+}
+
+// restHandler serves the REST gateway. Requests are matched to their JSON-RPC
+// method before they reach protect, so the method is authorized and rate
+// limited like the JSON-RPC request would be.
+func (s *APIServer) restHandler(protect func(http.Handler) http.Handler) http.Handler {
+	serve := protect(http.HandlerFunc(s.serveREST))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, vars := matchRESTRoute(r.URL.Path)
+		if route == nil {
+			writeRESTError(w, http.StatusNotFound, ErrorNotFound, fmt.Sprintf("no route for %s", r.URL.Path))
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeRESTError(w, http.StatusMethodNotAllowed, jrpc.NewError(jrpc.ErrorCodeInvalidRequest, jrpc.ErrorMessageInvalidRequest, nil),
+				fmt.Sprintf("%s is not allowed", r.Method))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), methodsKey{}, []string{route.method})
+		ctx = context.WithValue(ctx, restMatchKey{}, restMatch{route: route, vars: vars})
+		serve.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (s *APIServer) serveREST(w http.ResponseWriter, r *http.Request) {
+	match := r.Context().Value(restMatchKey{}).(restMatch)
+	route := match.route
+
+	params, err := route.restParams(match.vars, r.URL.Query())
+	if err != nil {
+		writeRESTError(w, http.StatusBadRequest, jrpc.ErrorInvalidParams(err), nil)
+		return
+	}
+
+	// Responses only change when a block is synced, so the synced height is
+	// the version of every response, other than the volatile ones
+	synced := s.Node.GetCurrentSync()
+	etag := fmt.Sprintf(`"%d"`, synced)
+	w.Header().Set("X-Pegnet-Height", strconv.FormatUint(uint64(synced), 10))
+	if route.volatile(r.URL.Query()) {
+		w.Header().Set("Cache-Control", "no-store")
+	} else {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", restMaxAge))
+		if height, err := strconv.ParseUint(match.vars["height"], 10, 32); err == nil && height > 0 && uint32(height) < synced {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+		if inm := r.Header.Get("If-None-Match"); inm != "" && (inm == etag || inm == "W/"+etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	result, err := s.callREST(r.Context(), route.method, params)
+	if err != nil {
+		var methodErr jrpc.Error
+		if !errors.As(err, &methodErr) {
+			methodErr = jrpc.NewError(jrpc.ErrorCodeInternal, jrpc.ErrorMessageInternal, nil)
+		}
+		w.Header().Del("ETag")
+		w.Header().Set("Cache-Control", "no-store")
+		writeRESTError(w, restStatus(methodErr), methodErr, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// callREST calls the JSON-RPC method, and recovers from its panics like the
+// JSON-RPC handler does
+func (s *APIServer) callREST(ctx context.Context, method string, params json.RawMessage) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("method", method).Errorf("rest: panic running method: %v", r)
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	result = s.jrpcMethods()[method](ctx, params)
+	if err, ok := result.(error); ok {
+		return nil, err
+	}
+	return result, nil
+}
+
+// restStatus returns the http status of a method error
+func restStatus(err jrpc.Error) int {
+	switch err.Code {
+	case jrpc.ErrorCodeInvalidParams, jrpc.ErrorCodeInvalidRequest, ErrorInvalidTransaction.Code:
+		return http.StatusBadRequest
+	case ErrorTokenNotFound.Code, ErrorTransactionNotFound.Code, ErrorAddressNotFound.Code, ErrorNotFound.Code:
+		return http.StatusNotFound
+	case ErrorTokenSyncing.Code, ErrorPendingDisabled.Code:
+		return http.StatusServiceUnavailable
+	case ErrorUnauthorized.Code:
+		return http.StatusUnauthorized
+	case ErrorRateLimited.Code:
+		return http.StatusTooManyRequests
+	case ErrorTimeout.Code:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// writeRESTError writes the error object of the JSON-RPC response, without the
+// JSON-RPC envelope
+func writeRESTError(w http.ResponseWriter, status int, err jrpc.Error, data interface{}) {
+	if data != nil {
+		err.Data = data
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": err})
+}
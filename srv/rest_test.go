@@ -0,0 +1,106 @@
+package srv
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestMatchRESTRoute(t *testing.T) {
+	tests := []struct {
+		path   string
+		method string
+		vars   map[string]string
+	}{
+		{RESTPrefix + "status", "get-sync-status", map[string]string{}},
+		{RESTPrefix + "status/", "get-sync-status", map[string]string{}},
+		{RESTPrefix + "rates", "get-pegnet-rates", map[string]string{}},
+		{RESTPrefix + "rates/222270", "get-pegnet-rates", map[string]string{"height": "222270"}},
+		{RESTPrefix + "richlist", "get-global-rich-list", map[string]string{}},
+		{RESTPrefix + "richlist/PEG", "get-rich-list", map[string]string{"asset": "PEG"}},
+		{RESTPrefix + "submissions/abc", "get-submission-status", map[string]string{"idempotencykey": "abc"}},
+		{RESTPrefix + "balances/", "", nil},
+		{RESTPrefix + "rates/1/2", "", nil},
+		{RESTPrefix + "nothing", "", nil},
+	}
+	for _, tt := range tests {
+		route, vars := matchRESTRoute(tt.path)
+		if route == nil {
+			if tt.method != "" {
+				t.Errorf("%s: expected %s, got no route", tt.path, tt.method)
+			}
+			continue
+		}
+		if route.method != tt.method || len(vars) != len(tt.vars) {
+			t.Errorf("%s: expected %s %v, got %s %v", tt.path, tt.method, tt.vars, route.method, vars)
+			continue
+		}
+		for name, value := range tt.vars {
+			if vars[name] != value {
+				t.Errorf("%s: expected %v, got %v", tt.path, tt.vars, vars)
+			}
+		}
+	}
+}
+
+func TestRESTParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		query  string
+		params string
+		valid  bool
+	}{
+		{"no params", "status", "", "", true},
+		{"params of a route that takes none", "status", "height=1", "", false},
+		{"a path value", "rates/222270", "", `{"height":222270}`, true},
+		{"a path value over the query", "rates/222270", "height=1", `{"height":222270}`, true},
+		{"a flag", "transactions", "address=FA2&desc", `{"address":"FA2","desc":true}`, true},
+		{"the pending flag", "balances/FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", "includepending",
+			`{"address":"FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q","includepending":true}`, true},
+		{"an alias", "transactions", "address=FA2&cursor=50&desc=false", `{"address":"FA2","desc":false,"offset":50}`, true},
+		{"an unknown param", "transactions", "limit=10", "", false},
+		{"a param given twice", "transactions", "offset=1&offset=2", "", false},
+		{"an invalid number", "rates", "height=abc", "", false},
+		{"a negative height", "rates", "height=-1", "", false},
+		{"an invalid flag", "transactions", "desc=maybe", "", false},
+	}
+	for _, tt := range tests {
+		route, vars := matchRESTRoute(RESTPrefix + tt.path)
+		if route == nil {
+			t.Fatalf("%s: no route for %s", tt.name, tt.path)
+		}
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params, err := route.restParams(vars, query)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid %v, got %v", tt.name, tt.valid, err)
+			continue
+		}
+		if string(params) != tt.params {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.params, params)
+		}
+	}
+}
+
+func TestRESTRoute_Volatile(t *testing.T) {
+	tests := []struct {
+		path     string
+		query    string
+		volatile bool
+	}{
+		{"rates", "", false},
+		{"submissions", "", true},
+		{"submissions/abc", "", true},
+		{"balances/FA2", "includepending", true},
+		{"balances/FA2", "includepending=false", false},
+	}
+	for _, tt := range tests {
+		route, _ := matchRESTRoute(RESTPrefix + tt.path)
+		query, _ := url.ParseQuery(tt.query)
+		if route.volatile(query) != tt.volatile {
+			t.Errorf("%s?%s: expected volatile %v", tt.path, tt.query, tt.volatile)
+		}
+	}
+}
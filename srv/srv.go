@@ -30,6 +30,7 @@ import (
 	jrpc "github.com/AdamSLevy/jsonrpc2/v13"
 	"github.com/pegnet/pegnetd/config"
 	"github.com/pegnet/pegnetd/node"
+	"github.com/pegnet/pegnetd/node/pegnet"
 	"github.com/rs/cors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -44,12 +45,27 @@ var srv http.Server
 type APIServer struct {
 	Node   *node.Pegnetd
 	Config *viper.Viper
+
+	limiter   *rateLimiter
+	cache     *responseCache
+	deadlines *deadlines
+	events    *eventBroker
 }
 
 func NewAPIServer(conf *viper.Viper, n *node.Pegnetd) *APIServer {
 	s := new(APIServer)
 	s.Node = n
 	s.Config = conf
+	s.limiter = newRateLimiter(conf)
+	s.cache = newResponseCache(conf)
+	s.deadlines = newDeadlines(conf)
+	s.events = newEventBroker()
+	if n != nil {
+		n.OnBlockSynced(func(summary pegnet.BlockSummary) {
+			s.cache.invalidate(summary.Height)
+			s.events.publish(summary)
+		})
+	}
 
 	return s
 }
@@ -67,28 +83,41 @@ func (s *APIServer) Start(stop <-chan struct{}) (done <-chan struct{}) {
 		func(w http.ResponseWriter, r *http.Request) {
 			jrpcHandler(w, r)
 		})
-	// TODO: Renable tls auth
-	//if flag.HasAuth {
-	//	authOpts := httpauth.AuthOptions{
-	//		User:     flag.Username,
-	//		Password: flag.Password,
-	//		UnauthorizedHandler: http.HandlerFunc(
-	//			func(w http.ResponseWriter, _ *http.Request) {
-	//				w.WriteHeader(http.StatusUnauthorized)
-	//				w.Write([]byte(`{}`))
-	//			}),
-	//	}
-	//	handler = httpauth.BasicAuth(authOpts)(handler)
-	//}
+	auth := newAPIAuth(s.Config)
+	protect := func(h http.Handler) http.Handler {
+		return auth.Handler(s.limiter.Handler(h))
+	}
+	handler = protect(discoverHandler(s.heightHandler(handler)))
 
 	// Set up server.
 	srvMux := http.NewServeMux()
 
 	srvMux.Handle("/", handler)
 	srvMux.Handle("/v1", handler)
+	srvMux.Handle(RESTPrefix, s.restHandler(protect))
+	srvMux.Handle(EventsPath, s.eventsHandler(protect))
+	srvMux.HandleFunc("/debug/vars", metricsHandler)
 
-	cors := cors.New(cors.Options{AllowedOrigins: []string{"*"}})
+	origins := s.Config.GetStringSlice(config.APICORSOrigins)
+	cors := cors.New(cors.Options{
+		AllowedOrigins: origins,
+		AllowedHeaders: []string{"Content-Type", "Authorization", "Last-Event-ID"},
+		ExposedHeaders: []string{"ETag", "X-Pegnet-Height", "Retry-After"},
+		// Browsers only send credentials to explicitly allowed origins
+		AllowCredentials: len(origins) > 0 && origins[0] != "*",
+	})
 	srv = http.Server{Handler: cors.Handler(srvMux)}
+	// The event streams never go idle, they have to be ended for Shutdown
+	srv.RegisterOnShutdown(s.events.close)
+
+	certFile, keyFile := s.Config.GetString(config.APITLSCert), s.Config.GetString(config.APITLSKey)
+	if certFile != "" {
+		tlsConfig, err := serverTLSConfig(s.Config)
+		if err != nil {
+			log.WithError(err).Fatal("failed to load the api tls config")
+		}
+		srv.TLSConfig = tlsConfig
+	}
 
 	if strings.Contains(s.Config.GetString(config.APIListen), ":") {
 		// This means the use set the listen address rather than just the port
@@ -100,15 +129,18 @@ func (s *APIServer) Start(stop <-chan struct{}) (done <-chan struct{}) {
 
 	// Start server.
 	_done := make(chan struct{})
-	log.Infof("Listening on %v...", srv.Addr)
+	if certFile != "" {
+		log.Infof("Listening on %v with tls...", srv.Addr)
+	} else {
+		log.Infof("Listening on %v...", srv.Addr)
+	}
 	go func() {
 		var err error
-		// TODO: Renable tls
-		//if flag.HasTLS {
-		//	err = srv.ListenAndServeTLS(flag.TLSCertFile, flag.TLSKeyFile)
-		//} else {
-		err = srv.ListenAndServe()
-		//}
+		if certFile != "" {
+			err = srv.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
 		if err != http.ErrServerClosed {
 			log.Errorf("srv.ListenAndServe(): %v", err)
 		}